@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/config"
@@ -37,6 +42,20 @@ func main() {
 		Handler: handler,
 		Addr:    env.Config.HTTPAddr,
 	}
+	// Stop the server on shutdown so that the background workers are stopped by env.Close
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+
+		logrus.Info("Shutting down Web Server")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logrus.WithError(err).Error("Server shutdown failed")
+		}
+	}()
+
 	logrus.WithFields(logrus.Fields{
 		"HTTP Address": env.Config.HTTPAddr,
 	}).Info("Starting Web Server")
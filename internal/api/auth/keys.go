@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// minSecretSize - the HMAC secrets shorter than 256 bits are refused
+const minSecretSize = 32
+
+// signingKey - a key the tokens are signed or verified with, the keys without the private part
+// only verify the tokens signed before a rotation
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private interface{} // []byte, *rsa.PrivateKey or ed25519.PrivateKey
+	public  interface{} // []byte, *rsa.PublicKey or ed25519.PublicKey
+}
+
+// keySet - the keys the tokens are verified with by their kid, the new tokens are signed with one
+type keySet struct {
+	signing *signingKey
+	byKid   map[string]*signingKey
+}
+
+// keys - a random secret until Configure runs, the tokens are lost on restart
+var keys = randomKeySet()
+
+func randomKeySet() *keySet {
+	secret := make([]byte, 64)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	key := &signingKey{method: jwt.SigningMethodHS512, private: secret, public: secret}
+	return &keySet{signing: key, byKid: map[string]*signingKey{"": key}}
+}
+
+// Configure - loads the signing keys and the token lifetimes from the settings
+func Configure(cfg *config.Info) error {
+
+	if cfg.JWT.AccessLifetime > 0 {
+		accessTokenDuration = time.Duration(cfg.JWT.AccessLifetime) * time.Second
+	}
+	if cfg.JWT.RefreshLifetime > 0 {
+		refreshTokenDuration = time.Duration(cfg.JWT.RefreshLifetime) * time.Second
+	}
+	if cfg.MFA.ChallengeLifetime > 0 {
+		mfaChallengeDuration = time.Duration(cfg.MFA.ChallengeLifetime) * time.Second
+	}
+
+	set := &keySet{byKid: map[string]*signingKey{}}
+	for _, entry := range cfg.JWT.Keys {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("jwt key %q is not kid:path", entry)
+		}
+		if _, ok := set.byKid[parts[0]]; ok {
+			return fmt.Errorf("jwt key %q is listed twice", parts[0])
+		}
+		data, err := ioutil.ReadFile(parts[1])
+		if err != nil {
+			return errors.Wrapf(err, "could not read jwt key %q", parts[0])
+		}
+		key, err := parseKey(parts[0], data)
+		if err != nil {
+			return errors.Wrapf(err, "could not load jwt key %q", parts[0])
+		}
+		set.byKid[key.kid] = key
+		if set.signing == nil && cfg.JWT.SigningKey == "" && key.private != nil {
+			set.signing = key
+		}
+	}
+	if cfg.JWT.SigningKey != "" {
+		set.signing = set.byKid[cfg.JWT.SigningKey]
+		if set.signing == nil {
+			return fmt.Errorf("jwt signing key %q is not listed", cfg.JWT.SigningKey)
+		}
+	}
+
+	// a single secret is enough when the keys are not rotated
+	if len(set.byKid) == 0 && cfg.JWT.Secret != "" {
+		key, err := hmacKey("", []byte(cfg.JWT.Secret))
+		if err != nil {
+			return err
+		}
+		set.signing = key
+		set.byKid[""] = key
+	}
+
+	if len(set.byKid) == 0 {
+		logrus.Warn("No jwt key is set, the tokens are signed with a random secret and do not survive a restart")
+		return nil
+	}
+	if set.signing == nil {
+		return errors.New("no jwt key has a private key to sign with")
+	}
+	if set.signing.private == nil {
+		return fmt.Errorf("jwt signing key %q has no private key", set.signing.kid)
+	}
+	keys = set
+	return nil
+}
+
+// parseKey - the PEM keys are RSA or Ed25519 keys, any other content is an HMAC secret
+func parseKey(kid string, data []byte) (*signingKey, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return hmacKey(kid, []byte(strings.TrimSpace(string(data))))
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &signingKey{kid: kid, method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &signingKey{kid: kid, method: jwt.SigningMethodRS256, public: k}, nil
+	case ed25519.PrivateKey:
+		return &signingKey{kid: kid, method: SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &signingKey{kid: kid, method: SigningMethodEdDSA, public: k}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 keys are supported", parsed)
+}
+
+func hmacKey(kid string, secret []byte) (*signingKey, error) {
+	if len(secret) < minSecretSize {
+		return nil, fmt.Errorf("jwt secret %q is shorter than %d bytes", kid, minSecretSize)
+	}
+	return &signingKey{kid: kid, method: jwt.SigningMethodHS512, private: secret, public: secret}, nil
+}
+
+// verificationKey - the key of the kid in the header, the tokens without one were signed before the
+// keys had ids and are checked with the signing key
+func (s *keySet) verificationKey(token *jwt.Token) (interface{}, error) {
+
+	kid, _ := token.Header["kid"].(string)
+	key := s.signing
+	if kid != "" {
+		key = s.byKid[kid]
+	}
+	if key == nil {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	// a token signed with another algorithm than the one of the key is forged
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+	}
+	return key.public, nil
+}
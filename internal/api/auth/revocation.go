@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// ErrTokenRevoked - the token was issued before the tokens of the user were revoked
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// RevocationStore - keeps the token version of the users, the tokens carry the version they were
+// issued with and the ones with an older version are revoked
+type RevocationStore interface {
+	TokenVersion(ctx context.Context, userID model.UserID) (int64, error)
+	RevokeTokens(ctx context.Context, userID model.UserID) error
+}
+
+// revocations - the store the tokens are checked against, the versions are lost on restart unless
+// a shared store is set
+var revocations RevocationStore = NewMemoryRevocations()
+
+// SetRevocationStore - replaces the store of the token versions, the servers sharing a store revoke
+// the tokens together
+func SetRevocationStore(store RevocationStore) {
+	revocations = store
+}
+
+// RevokeTokens - revokes the access and refresh tokens issued to the user until now
+func RevokeTokens(ctx context.Context, userID model.UserID) error {
+	return revocations.RevokeTokens(ctx, userID)
+}
+
+// CheckRevoked - checks the token of the principal was issued after the last revocation
+func CheckRevoked(ctx context.Context, principal *model.Principal) error {
+
+	version, err := revocations.TokenVersion(ctx, principal.UserID)
+	if err != nil {
+		return err
+	}
+	if principal.TokenVersion < version {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// MemoryRevocations - keeps the token versions in memory, for a single server and the tests
+type MemoryRevocations struct {
+	mu       sync.RWMutex
+	versions map[model.UserID]int64
+}
+
+// NewMemoryRevocations - creates an empty in memory store
+func NewMemoryRevocations() *MemoryRevocations {
+	return &MemoryRevocations{versions: map[model.UserID]int64{}}
+}
+
+// TokenVersion - the version the new tokens of the user are issued with
+func (m *MemoryRevocations) TokenVersion(ctx context.Context, userID model.UserID) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.versions[userID], nil
+}
+
+// RevokeTokens - moves the user to the next version
+func (m *MemoryRevocations) RevokeTokens(ctx context.Context, userID model.UserID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.versions[userID]++
+	return nil
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// useRevocations - the tests run against their own store, the one of the package is put back after
+func useRevocations(t *testing.T, store RevocationStore) {
+	t.Helper()
+	previous := revocations
+	SetRevocationStore(store)
+	t.Cleanup(func() { SetRevocationStore(previous) })
+}
+
+// issuedPrincipals - the principals of the access, refresh and MFA tokens issued to the user now
+func issuedPrincipals(t *testing.T, userID model.UserID) map[string]*model.Principal {
+	t.Helper()
+
+	ctx := context.Background()
+	tokens, err := IssueToken(ctx, model.Principal{UserID: userID, Name: "Jane", Role: "agent"})
+	if err != nil {
+		t.Fatalf("IssueToken() error = %v", err)
+	}
+	access, err := VerifyToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+	refresh, err := VerifyRefreshToken(tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("VerifyRefreshToken() error = %v", err)
+	}
+	challenge, _, err := IssueMFAChallenge(ctx, userID)
+	if err != nil {
+		t.Fatalf("IssueMFAChallenge() error = %v", err)
+	}
+	mfa, err := VerifyMFAChallenge(challenge)
+	if err != nil {
+		t.Fatalf("VerifyMFAChallenge() error = %v", err)
+	}
+	return map[string]*model.Principal{"access": access, "refresh": refresh, "mfa": mfa}
+}
+
+func checkPrincipals(t *testing.T, when string, principals map[string]*model.Principal, want error) {
+	t.Helper()
+	for use, principal := range principals {
+		if err := CheckRevoked(context.Background(), principal); err != want {
+			t.Errorf("%s: CheckRevoked(%s token) = %v, want %v", when, use, err, want)
+		}
+	}
+}
+
+func TestCheckRevoked(t *testing.T) {
+
+	ctx := context.Background()
+	useRevocations(t, NewMemoryRevocations())
+	const jane, john model.UserID = "jane", "john"
+
+	beforeRevoke := issuedPrincipals(t, jane)
+	johnTokens := issuedPrincipals(t, john)
+	checkPrincipals(t, "before the revocation", beforeRevoke, nil)
+
+	if err := RevokeTokens(ctx, jane); err != nil {
+		t.Fatalf("RevokeTokens() error = %v", err)
+	}
+	checkPrincipals(t, "issued before the revocation", beforeRevoke, ErrTokenRevoked)
+	checkPrincipals(t, "issued to another user", johnTokens, nil)
+
+	afterRevoke := issuedPrincipals(t, jane)
+	checkPrincipals(t, "issued after the revocation", afterRevoke, nil)
+
+	// every revocation refuses the tokens issued until then
+	if err := RevokeTokens(ctx, jane); err != nil {
+		t.Fatalf("RevokeTokens() error = %v", err)
+	}
+	checkPrincipals(t, "issued before the first revocation", beforeRevoke, ErrTokenRevoked)
+	checkPrincipals(t, "issued before the second revocation", afterRevoke, ErrTokenRevoked)
+	checkPrincipals(t, "issued after the second revocation", issuedPrincipals(t, jane), nil)
+}
+
+func TestMemoryRevocations(t *testing.T) {
+
+	ctx := context.Background()
+	store := NewMemoryRevocations()
+
+	if version, err := store.TokenVersion(ctx, "jane"); err != nil || version != 0 {
+		t.Errorf("TokenVersion() of a new user = %d, %v, want 0", version, err)
+	}
+	for want := int64(1); want <= 3; want++ {
+		if err := store.RevokeTokens(ctx, "jane"); err != nil {
+			t.Fatal(err)
+		}
+		if version, _ := store.TokenVersion(ctx, "jane"); version != want {
+			t.Errorf("TokenVersion() = %d, want %d", version, want)
+		}
+	}
+	if version, _ := store.TokenVersion(ctx, "john"); version != 0 {
+		t.Errorf("TokenVersion() of another user = %d, want 0", version)
+	}
+}
+
+// failingRevocations - a shared store that cannot be reached
+type failingRevocations struct{}
+
+var errStoreDown = errors.New("connection refused")
+
+func (failingRevocations) TokenVersion(ctx context.Context, userID model.UserID) (int64, error) {
+	return 0, errStoreDown
+}
+
+func (failingRevocations) RevokeTokens(ctx context.Context, userID model.UserID) error {
+	return errStoreDown
+}
+
+func TestCheckRevokedStoreDown(t *testing.T) {
+
+	ctx := context.Background()
+	useRevocations(t, NewMemoryRevocations())
+	principals := issuedPrincipals(t, "jane")
+
+	// the tokens are refused rather than accepted without knowing whether they were revoked
+	useRevocations(t, failingRevocations{})
+	checkPrincipals(t, "store down", principals, errStoreDown)
+	if _, err := IssueToken(ctx, model.Principal{UserID: "jane"}); err != errStoreDown {
+		t.Errorf("IssueToken() error = %v, want %v", err, errStoreDown)
+	}
+	if err := RevokeTokens(ctx, "jane"); err != errStoreDown {
+		t.Errorf("RevokeTokens() error = %v, want %v", err, errStoreDown)
+	}
+}
@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -8,9 +11,24 @@ import (
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
 )
 
-var jwtKey = []byte("sectre key")                           //TODO: change the key
-var accessTokenDuration = time.Duration(60) * time.Minute   //30 Mins
-var refreshTokenDuration = time.Duration(40*24) * time.Hour //30 days
+// the lifetimes of the tokens, they are replaced by the ones of the settings in Configure
+var accessTokenDuration = time.Duration(60) * time.Minute   //60 Mins
+var refreshTokenDuration = time.Duration(40*24) * time.Hour //40 days
+
+// mfaChallengeDuration - the time the user has to type the code of their app after the password
+var mfaChallengeDuration = time.Duration(5) * time.Minute
+
+const (
+	// accessUse - the tokens sent with the requests
+	accessUse = "access"
+	// refreshUse - the tokens only accepted to issue new tokens
+	refreshUse = "refresh"
+	// mfaUse - the tokens only accepted with the second factor, returned by the login of the users with MFA
+	mfaUse = "mfa"
+)
+
+// ErrTokenUse - the token cannot be used for the request, a refresh or MFA token sent as access token
+var ErrTokenUse = errors.New("invalid token use")
 
 // CustomClaims - wraps the jwt standard claims, so User info can be added.
 type CustomClaims struct {
@@ -18,6 +36,9 @@ type CustomClaims struct {
 	Name   string       `json:"name,omitempty"`
 	Role   string       `json:"role,omitempty"`
 	Type   string       `json:"type,omitempty"`
+	Use    string       `json:"use,omitempty"`
+	// Version - the token version of the user, the tokens are revoked by moving to the next one
+	Version int64 `json:"ver,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -30,18 +51,25 @@ type Tokens struct {
 }
 
 //IssueToken generate access and refresh token
-func IssueToken(principal model.Principal) (*Tokens, error) {
+func IssueToken(ctx context.Context, principal model.Principal) (*Tokens, error) {
 
 	if principal.UserID == model.NilUserID {
 		return nil, errors.New("invalid principal")
 	}
 
-	accessToken, accessTokenExpiresAt, err := generateToken(principal, accessTokenDuration)
+	// the tokens revoked until now are older than the ones issued
+	version, err := revocations.TokenVersion(ctx, principal.UserID)
 	if err != nil {
 		return nil, err
 	}
+	principal.TokenVersion = version
 
-	refreshToken, refreshTokenExpiresAt, err := generateToken(principal, refreshTokenDuration)
+	accessToken, accessTokenExpiresAt, err := generateToken(principal, accessUse, accessTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshTokenExpiresAt, err := generateToken(principal, refreshUse, refreshTokenDuration)
 	if err != nil {
 		return nil, err
 	}
@@ -55,25 +83,39 @@ func IssueToken(principal model.Principal) (*Tokens, error) {
 	return tokens, nil
 }
 
-func generateToken(principal model.Principal, duration time.Duration) (string, int64, error) {
+func generateToken(principal model.Principal, use string, duration time.Duration) (string, int64, error) {
 
 	now := time.Now()
 
+	// the id keeps apart the tokens issued in the same second
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", 0, err
+	}
+
 	// Generate Access Tokens
 	claims := &CustomClaims{
-		UserID: principal.UserID,
-		Name:   principal.Name,
-		Role:   principal.Role,
-		Type:   principal.Type,
+		UserID:  principal.UserID,
+		Name:    principal.Name,
+		Role:    principal.Role,
+		Type:    principal.Type,
+		Use:     use,
+		Version: principal.TokenVersion,
 		StandardClaims: jwt.StandardClaims{
+			Id:        hex.EncodeToString(id),
 			IssuedAt:  now.Unix(),
 			ExpiresAt: now.Add(duration).Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+	key := keys.signing
+	token := jwt.NewWithClaims(key.method, claims)
+	// the kid tells which key to verify the token with once the keys are rotated
+	if key.kid != "" {
+		token.Header["kid"] = key.kid
+	}
 
-	tokenString, err := token.SignedString(jwtKey)
+	tokenString, err := token.SignedString(key.private)
 	if err != nil {
 		return "", 0, err
 	}
@@ -85,29 +127,81 @@ func generateToken(principal model.Principal, duration time.Duration) (string, i
 // VerifyToken -  checks the the token submitted is valid
 func VerifyToken(accessToken string) (*model.Principal, error) {
 
+	principal, claims, err := parseToken(accessToken)
+	// the tokens issued before the use claim are access tokens
+	if err == nil && claims.Use != "" && claims.Use != accessUse {
+		return nil, ErrTokenUse
+	}
+	return principal, err
+}
+
+// VerifyRefreshToken - checks the token is a valid refresh token, it still has to match the session
+func VerifyRefreshToken(refreshToken string) (*model.Principal, error) {
+
+	principal, claims, err := parseToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Use != refreshUse {
+		return nil, ErrTokenUse
+	}
+	return principal, nil
+}
+
+// IssueMFAChallenge - a short lived token proving the password of the user was checked, it is exchanged
+// for the access and refresh tokens with a code of their authenticator app
+func IssueMFAChallenge(ctx context.Context, userID model.UserID) (string, int64, error) {
+
+	if userID == model.NilUserID {
+		return "", 0, errors.New("invalid principal")
+	}
+	// the challenges pending are revoked with the tokens of the user
+	version, err := revocations.TokenVersion(ctx, userID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return generateToken(model.Principal{UserID: userID, TokenVersion: version}, mfaUse, mfaChallengeDuration)
+}
+
+// VerifyMFAChallenge - checks the token is a valid MFA challenge
+func VerifyMFAChallenge(mfaToken string) (*model.Principal, error) {
+
+	principal, claims, err := parseToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Use != mfaUse {
+		return nil, ErrTokenUse
+	}
+	return principal, nil
+}
+
+func parseToken(tokenString string) (*model.Principal, *CustomClaims, error) {
+
 	claims := &CustomClaims{}
 
-	tkn, err := jwt.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
-	})
+	tkn, err := jwt.ParseWithClaims(tokenString, claims, keys.verificationKey)
 
 	if err != nil {
 		if err == jwt.ErrSignatureInvalid {
-			return nil, err
+			return nil, nil, err
 		}
-		return nil, err
+		return nil, nil, err
 	}
 	principal := &model.Principal{
 		UserID: claims.UserID,
 		Name:   claims.Name,
 		Role:   claims.Role,
 		Type:   claims.Type,
+
+		TokenVersion: claims.Version,
 	}
 
 	// return principal even if token is invalid because we need to get the UserID
 	if !tkn.Valid {
-		return principal, err
+		return principal, claims, err
 	}
 
-	return principal, nil
+	return principal, claims, nil
 }
@@ -0,0 +1,14 @@
+package errors
+
+import "net/http"
+
+var (
+	// ErrFileTooLarge - the file is over the size limit
+	ErrFileTooLarge = APIError{Code: http.StatusRequestEntityTooLarge, Err: "File is too large"}
+	// ErrFileType - the type of the file is not allowed
+	ErrFileType = APIError{Code: http.StatusUnsupportedMediaType, Err: "File type is not allowed"}
+	// ErrTooManyFiles - more files were sent than a note can have
+	ErrTooManyFiles = APIError{Code: http.StatusBadRequest, Err: "Too many files"}
+	// ErrNoFileStore - the file store could not be created
+	ErrNoFileStore = APIError{Code: http.StatusServiceUnavailable, Err: "Files cannot be stored"}
+)
@@ -0,0 +1,10 @@
+package errors
+
+import "net/http"
+
+var (
+	// ErrInboundMailExists - a mailbox with the same server, user and mailbox exists
+	ErrInboundMailExists = APIError{Code: http.StatusConflict, Err: "Inbound mail already exists"}
+	// ErrNoSecretKey - the mailbox secrets cannot be encrypted without a key
+	ErrNoSecretKey = APIError{Code: http.StatusInternalServerError, Err: "No key is configured to encrypt the mailbox secret"}
+)
@@ -0,0 +1,22 @@
+package errors
+
+import "net/http"
+
+var (
+	// ErrInvalidMFAToken - the challenge token returned by the login is unknown or expired
+	ErrInvalidMFAToken = APIError{Code: http.StatusUnauthorized, Err: "Invalid or expired MFA token, sign in again"}
+	// ErrInvalidMFACode - the code of the authenticator app or the recovery code is wrong or was already used
+	ErrInvalidMFACode = APIError{Code: http.StatusUnauthorized, Err: "Invalid authentication code"}
+	// ErrMFALocked - too many invalid codes were typed, the user has to wait before trying again
+	ErrMFALocked = APIError{Code: http.StatusTooManyRequests, Err: "Too many invalid codes, try again later"}
+	// ErrMFAAlreadyEnabled - the user already confirmed an authenticator app
+	ErrMFAAlreadyEnabled = APIError{Code: http.StatusConflict, Err: "Two-factor authentication is already enabled"}
+	// ErrMFANotEnrolled - the user has no authenticator app waiting for the code
+	ErrMFANotEnrolled = APIError{Code: http.StatusBadRequest, Err: "Two-factor authentication is not set up"}
+	// ErrMFARequired - the type or a role of the user requires MFA, it cannot be turned off
+	ErrMFARequired = APIError{Code: http.StatusForbidden, Err: "Two-factor authentication is required for the user"}
+	// ErrMFARequirementExists - MFA is already required for the user type or role
+	ErrMFARequirementExists = APIError{Code: http.StatusConflict, Err: "MFA requirement already exists"}
+	// ErrNoMFASecretKey - the TOTP secrets cannot be encrypted without a key
+	ErrNoMFASecretKey = APIError{Code: http.StatusInternalServerError, Err: "No key is configured to encrypt the TOTP secret"}
+)
@@ -0,0 +1,8 @@
+package errors
+
+import "net/http"
+
+var (
+	// ErrOutboundMailExists - a mail server with the same address, user, port and sender exists
+	ErrOutboundMailExists = APIError{Code: http.StatusConflict, Err: "Outbound mail already exists"}
+)
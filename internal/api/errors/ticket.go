@@ -19,6 +19,21 @@ var (
 	// ErrStatusExists - Status already exists in the database
 	ErrStatusExists = APIError{Code: http.StatusConflict, Err: "Status already exists"}
 
+	// ErrInitialStatusExists - only one status can be the initial status
+	ErrInitialStatusExists = APIError{Code: http.StatusConflict, Err: "An initial status already exists"}
+
+	// ErrTransitionExists - the transition between the statuses already exists
+	ErrTransitionExists = APIError{Code: http.StatusConflict, Err: "Transition already exists"}
+
+	// ErrIllegalTransition - the workflow does not allow the ticket to move to the status
+	ErrIllegalTransition = APIError{Code: http.StatusBadRequest, Err: "The ticket cannot move from its current status to the requested status"}
+
+	// ErrTransitionNoteRequired - the transition needs a note explaining the change
+	ErrTransitionNoteRequired = APIError{Code: http.StatusBadRequest, Err: "A note is required to move the ticket to the requested status"}
+
+	// ErrTransitionRoleRequired - the transition is limited to a role the user does not have
+	ErrTransitionRoleRequired = APIError{Code: http.StatusForbidden, Err: "You do not have the role required to move the ticket to the requested status"}
+
 	// ErrTicketExists - User already exists in the database
 	ErrTicketExists = APIError{Code: http.StatusConflict, Err: "Ticket already exists"}
 
@@ -32,6 +47,33 @@ var (
 	ErrUpdatingTicket = APIError{Code: http.StatusInternalServerError, Err: "Error updating ticket"}
 
 
+	// ErrInvalidAssignee - tickets can only be assigned to active agents and admins
+	ErrInvalidAssignee = APIError{Code: http.StatusBadRequest, Err: "Tickets can only be assigned to agents or admins"}
+
+	// ErrTicketAlreadyAssigned - the ticket is already assigned to the user
+	ErrTicketAlreadyAssigned = APIError{Code: http.StatusConflict, Err: "Ticket is already assigned to the user"}
+
+	// ErrTicketNotAssigned - the ticket has no assignee
+	ErrTicketNotAssigned = APIError{Code: http.StatusConflict, Err: "Ticket is not assigned"}
+
+	// ErrTicketUserExists - the user is already involved in the ticket
+	ErrTicketUserExists = APIError{Code: http.StatusConflict, Err: "The user is already involved in the ticket"}
+
+	// ErrTicketClosed - the ticket has already been closed
+	ErrTicketClosed = APIError{Code: http.StatusConflict, Err: "Ticket is already closed"}
+
+	// ErrTicketNotClosed - only closed tickets can be reopened
+	ErrTicketNotClosed = APIError{Code: http.StatusConflict, Err: "Ticket is not closed"}
+
+	// ErrCalendarExists - a business calendar with the name already exists
+	ErrCalendarExists = APIError{Code: http.StatusConflict, Err: "Calendar already exists"}
+
+	// ErrEscalationExists - the SLA already has an escalation step for the level
+	ErrEscalationExists = APIError{Code: http.StatusConflict, Err: "An escalation step already exists for the level"}
+
+	// ErrTicketEmailExists - the mail was already added to a ticket
+	ErrTicketEmailExists = APIError{Code: http.StatusConflict, Err: "The mail was already received"}
+
 	// ErrCauseExists - Cause already exists in the database
 	ErrCauseExists = APIError{Code: http.StatusConflict, Err: "Cause already exists"}
 	
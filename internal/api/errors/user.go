@@ -9,4 +9,12 @@ var (
 	ErrEmailAlreadyExists = APIError{Code: http.StatusBadRequest, Err: "Email already exists"}
 	// ErrCreatingUser - tells the user that
 	ErrCreatingUser = APIError{Code: http.StatusBadRequest, Err: "Error creating user"}
+	// ErrInvalidRefreshToken - the refresh token is not the one of an active session
+	ErrInvalidRefreshToken = APIError{Code: http.StatusUnauthorized, Err: "Invalid refresh token"}
+	// ErrRefreshTokenReused - a refresh token was presented after being rotated, the sessions of the user are revoked
+	ErrRefreshTokenReused = APIError{Code: http.StatusUnauthorized, Err: "Refresh token was already used, sign in again"}
+	// ErrInvalidAccountToken - the mailed token is unknown, used or expired, the cases are not told apart
+	ErrInvalidAccountToken = APIError{Code: http.StatusBadRequest, Err: "Invalid or expired token"}
+	// ErrEmailNotVerified - the user has to follow the link mailed to them before signing in
+	ErrEmailNotVerified = APIError{Code: http.StatusForbidden, Err: "Email is not verified"}
 )
@@ -13,6 +13,8 @@ func CORS(handler http.Handler)  http.Handler {
 		handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization"}),
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "HEAD", "OPTIONS","PATCH","DELETE"}),
 		handlers.AllowedOrigins([]string{"http://localhost:8080"}),
+		// the refresh token cookie is only kept and sent back with the credentials allowed
+		handlers.AllowCredentials(),
 	)
 
 	return cors(handler)
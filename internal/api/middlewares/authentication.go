@@ -47,6 +47,9 @@ func CheckToken(r *http.Request) (*http.Request, error) {
 
 		return r, err
 	}
+	if err := auth.CheckRevoked(r.Context(), principal); err != nil {
+		return r, err
+	}
 	// log.Printf("Principlal => %+v\n", principal)
 
 	return r.WithContext(WithPricipalContext(r.Context(), *principal)), nil
@@ -134,6 +134,32 @@ func (a *Authorizer) ObjAuthorize(obj, act string) func(next http.Handler) http.
 	}
 }
 
+// Authenticate - only checks the token, for the endpoints every signed in user can use on their own data
+func (a *Authorizer) Authenticate(next http.Handler) http.Handler {
+
+	logger := logrus.WithField("func", "[Authorizer.Authenticate]")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		token, err := getToken(r)
+		if err != nil {
+			logger.WithError(err).Debug("retrieving token")
+			utils.WriteError(w, http.StatusUnauthorized, "Invalid Token", nil)
+			return
+		}
+
+		principal, err := a.getPrincipal(r.Context(), token)
+		if err != nil {
+			logrus.Debug(err.Error())
+			utils.WriteError(w, http.StatusUnauthorized, err.Error(), nil)
+			return
+		}
+
+		ctx := addDetailsToContext(r.Context(), principal)
+		ctx = context.WithValue(ctx, principalContextKey, *principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (a *Authorizer) enforce(role, object, action string) (authorized bool, err error) {
 	authorized, err = a.enforcer.Enforce(role, object, action)
 	return
@@ -161,6 +187,11 @@ func (a *Authorizer) getPrincipal(ctx context.Context, accessToken string) (*mod
 
 	principal, err := auth.VerifyToken(accessToken)
 	if err == nil {
+		// the user was deactivated, deleted or changed their password after the token was issued
+		if err := auth.CheckRevoked(ctx, principal); err != nil {
+			logrus.WithError(err).WithField("userID", principal.UserID).Info("Rejecting the token")
+			return nil, err
+		}
 
 		// TODO: uncommenet code when cache is added
 
@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"net/url"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// ClosedTicketFilter - builds the closed ticket filter from the request query
+//
+// Supported parameters: cause_id, closed_by, closed_from, closed_to (RFC3339),
+// order (asc|desc), limit and offset, the ids are uuids
+func ClosedTicketFilter(query url.Values) (*model.ClosedTicketFilter, error) {
+
+	// the ids are compared to uuid columns, anything else is refused before the query
+	if err := utils.VerifyUUIDParams(query, "cause_id", "closed_by"); err != nil {
+		return nil, err
+	}
+
+	filter := &model.ClosedTicketFilter{
+		CauseID:    model.CauseID(query.Get("cause_id")),
+		ClosedByID: model.UserID(query.Get("closed_by")),
+		Order:      query.Get("order"),
+	}
+
+	var err error
+	if filter.ClosedFrom, err = utils.OptionalTimeParam(query, "closed_from"); err != nil {
+		return nil, err
+	}
+	if filter.ClosedTo, err = utils.OptionalTimeParam(query, "closed_to"); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit, err = utils.IntParam(query, "limit", model.DefaultTicketLimit); err != nil {
+		return nil, err
+	}
+	if filter.Offset, err = utils.IntParam(query, "offset", 0); err != nil {
+		return nil, err
+	}
+
+	if err := filter.Verify(); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
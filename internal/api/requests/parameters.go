@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"strings"
 
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
 )
@@ -14,6 +15,8 @@ type UserParameters struct {
 	model.SessionData
 	Password        string `json:"password"`
 	ConfirmPassword string `json:"confirm_password"`
+	// IsActive - deactivated users cannot sign in and their tokens are revoked
+	IsActive *bool `json:"is_active,omitempty"`
 }
 
 // Decode - UserParameters to JSON
@@ -90,3 +93,154 @@ func (u *UserParameters) ToUser() *model.User {
 
 	return user
 }
+
+// RefreshParameters - the refresh token and the device of the session, the token can also come in
+// the refresh cookie
+type RefreshParameters struct {
+	model.SessionData
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Decode - RefreshParameters from JSON, the body can be empty when the token is in the cookie
+func (p *RefreshParameters) Decode(reader io.Reader) error {
+	if err := json.NewDecoder(reader).Decode(p); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+//Verify all fields before the tokens are issued
+func (p *RefreshParameters) Verify() error {
+	if err := p.SessionData.Verify(); err != nil {
+		return err
+	}
+	if len(p.RefreshToken) == 0 {
+		return errors.New("RefreshToken is required")
+	}
+	return nil
+}
+
+// EmailParameters - the email of the account a mail is asked for
+type EmailParameters struct {
+	Email string `json:"email"`
+}
+
+// Decode - EmailParameters from JSON
+func (p *EmailParameters) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(p)
+}
+
+//Verify all fields before the mail is sent
+func (p *EmailParameters) Verify() error {
+	p.Email = strings.TrimSpace(p.Email)
+	if len(p.Email) == 0 {
+		return errors.New("Email is required")
+	}
+	return nil
+}
+
+// TokenParameters - the token mailed to the user
+type TokenParameters struct {
+	Token string `json:"token"`
+}
+
+// Decode - TokenParameters from JSON
+func (p *TokenParameters) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(p)
+}
+
+//Verify all fields before the token is used
+func (p *TokenParameters) Verify() error {
+	if len(p.Token) == 0 {
+		return errors.New("Token is required")
+	}
+	return nil
+}
+
+// ResetPasswordParameters - the token mailed to the user and their new password
+type ResetPasswordParameters struct {
+	TokenParameters
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+
+// Decode - ResetPasswordParameters from JSON
+func (p *ResetPasswordParameters) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(p)
+}
+
+//Verify all fields before the password is set
+func (p *ResetPasswordParameters) Verify() error {
+	if err := p.TokenParameters.Verify(); err != nil {
+		return err
+	}
+	if p.Password == "" {
+		return errors.New("Password field is required")
+	}
+	if p.Password != p.ConfirmPassword {
+		return errors.New("Passwords don't match")
+	}
+	return nil
+}
+
+// MFACodeParameters - the code of the authenticator app or one of the recovery codes
+type MFACodeParameters struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// Decode - MFACodeParameters from JSON
+func (p *MFACodeParameters) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(p)
+}
+
+//Verify all fields before the code is checked
+func (p *MFACodeParameters) Verify() error {
+	p.Code = strings.TrimSpace(p.Code)
+	p.RecoveryCode = strings.TrimSpace(p.RecoveryCode)
+	if p.Code == "" && p.RecoveryCode == "" {
+		return errors.New("Code or recovery_code is required")
+	}
+	return nil
+}
+
+// MFATokenParameters - the challenge token returned by the login
+type MFATokenParameters struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+// Decode - MFATokenParameters from JSON
+func (p *MFATokenParameters) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(p)
+}
+
+//Verify all fields before the token is used
+func (p *MFATokenParameters) Verify() error {
+	if len(p.MFAToken) == 0 {
+		return errors.New("mfa_token is required")
+	}
+	return nil
+}
+
+// MFALoginParameters - the second step of the login, the challenge token and a code for the device
+type MFALoginParameters struct {
+	model.SessionData
+	MFATokenParameters
+	MFACodeParameters
+}
+
+// Decode - MFALoginParameters from JSON
+func (p *MFALoginParameters) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(p)
+}
+
+//Verify all fields before the login is completed
+func (p *MFALoginParameters) Verify() error {
+	if err := p.SessionData.Verify(); err != nil {
+		return err
+	}
+	if err := p.MFATokenParameters.Verify(); err != nil {
+		return err
+	}
+	return p.MFACodeParameters.Verify()
+}
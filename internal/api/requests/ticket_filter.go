@@ -0,0 +1,62 @@
+package requests
+
+import (
+	"net/url"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// TicketFilter - builds the ticket filter from the request query
+//
+// Supported parameters: status_id, priority_id, category_id, source_id, sla_id,
+// assigned_to, created_by, created_from, created_to, deadline_from, deadline_to (RFC3339),
+// state (open|closed|all, defaults to open), sla_state (ok|at_risk|breached|paused), sort, order (asc|desc),
+// limit and offset, the ids are uuids
+func TicketFilter(query url.Values) (*model.TicketFilter, error) {
+
+	// the ids are compared to uuid columns, anything else is refused before the query
+	if err := utils.VerifyUUIDParams(query, "status_id", "priority_id", "category_id", "source_id", "sla_id", "assigned_to", "created_by"); err != nil {
+		return nil, err
+	}
+
+	filter := &model.TicketFilter{
+		StatusID:   model.StatusID(query.Get("status_id")),
+		PriorityID: model.PriorityID(query.Get("priority_id")),
+		CategoryID: model.CategoryID(query.Get("category_id")),
+		SourceID:   model.SourceID(query.Get("source_id")),
+		SLAID:      model.SLAID(query.Get("sla_id")),
+		AssignedID: model.UserID(query.Get("assigned_to")),
+		UserID:     model.UserID(query.Get("created_by")),
+		State:      query.Get("state"),
+		SLAState:   query.Get("sla_state"),
+		Sort:       query.Get("sort"),
+		Order:      query.Get("order"),
+	}
+
+	var err error
+	if filter.CreatedFrom, err = utils.OptionalTimeParam(query, "created_from"); err != nil {
+		return nil, err
+	}
+	if filter.CreatedTo, err = utils.OptionalTimeParam(query, "created_to"); err != nil {
+		return nil, err
+	}
+	if filter.DeadlineFrom, err = utils.OptionalTimeParam(query, "deadline_from"); err != nil {
+		return nil, err
+	}
+	if filter.DeadlineTo, err = utils.OptionalTimeParam(query, "deadline_to"); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit, err = utils.IntParam(query, "limit", model.DefaultTicketLimit); err != nil {
+		return nil, err
+	}
+	if filter.Offset, err = utils.IntParam(query, "offset", 0); err != nil {
+		return nil, err
+	}
+
+	if err := filter.Verify(); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
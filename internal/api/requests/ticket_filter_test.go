@@ -0,0 +1,78 @@
+package requests
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+func TestTicketFilter(t *testing.T) {
+
+	const statusID = "2b1c7e2e-8f0a-4c3d-9e1b-5a6f7d8c9e0f"
+	tests := []struct {
+		name       string
+		query      string
+		wantErr    bool
+		wantOffset int
+	}{
+		{"defaults", "", false, 0},
+		{"uuid filters", "status_id=" + statusID + "&assigned_to=" + statusID + "&created_by=" + statusID, false, 0},
+		{"offset", "offset=100&limit=50", false, 100},
+		{"invalid status", "status_id=open", true, 0},
+		{"invalid priority", "priority_id=1", true, 0},
+		{"invalid category", "category_id=' OR 1=1 --", true, 0},
+		{"invalid source", "source_id=mail", true, 0},
+		{"invalid sla", "sla_id=gold", true, 0},
+		{"invalid assignee", "assigned_to=me", true, 0},
+		{"invalid creator", "created_by=jane", true, 0},
+		{"negative offset", "offset=-1", true, 0},
+		{"offset not a number", "offset=MTAw", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filter, err := TicketFilter(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TicketFilter(%s) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if filter.Offset != tt.wantOffset {
+				t.Errorf("Offset = %d, want %d", filter.Offset, tt.wantOffset)
+			}
+			if tt.query == "" && (filter.State != model.TicketStateOpen || filter.Limit != model.DefaultTicketLimit) {
+				t.Errorf("State = %s, Limit = %d, want the defaults", filter.State, filter.Limit)
+			}
+		})
+	}
+}
+
+func TestClosedTicketFilter(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"defaults", "", false},
+		{"uuid filters", "cause_id=2b1c7e2e-8f0a-4c3d-9e1b-5a6f7d8c9e0f&closed_by=2b1c7e2e-8f0a-4c3d-9e1b-5a6f7d8c9e0f", false},
+		{"invalid cause", "cause_id=hardware", true},
+		{"invalid closer", "closed_by=jane", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ClosedTicketFilter(query); (err != nil) != tt.wantErr {
+				t.Errorf("ClosedTicketFilter(%s) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -24,4 +24,20 @@ type ActGranted struct {
 // ActRevoked is an act indicates that revoking action was finished
 type ActRevoked struct {
 	Revoked bool `json:"revoked"`
-}
\ No newline at end of file
+}
+// ActTested is an act indicates that the connection test was finished
+type ActTested struct {
+	Connected bool   `json:"connected"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ActSent is an act indicates that the mail was queued, it is also sent when no mail was so the
+// accounts cannot be guessed
+type ActSent struct {
+	Sent bool `json:"sent"`
+}
+
+// ActVerified is an act indicates that the verification was finished
+type ActVerified struct {
+	Verified bool `json:"verified"`
+}
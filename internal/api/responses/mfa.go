@@ -0,0 +1,30 @@
+package responses
+
+// MFAChallenge - returned by the login instead of the tokens when the user signs in with a code
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresAt   int64  `json:"expires_at"`
+	// EnrollmentRequired - MFA is required for the user who has no authenticator app yet
+	EnrollmentRequired bool `json:"enrollment_required,omitempty"`
+}
+
+// MFAEnrollment - the secret of a new authenticator app, the URI is shown as a QR code. The recovery
+// codes are only returned once.
+type MFAEnrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+// MFARecoveryCodes - the new recovery codes, they are only returned once
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAStatus - the two-factor authentication of the user
+type MFAStatus struct {
+	Enabled       bool `json:"enabled"`
+	Required      bool `json:"required"`
+	RecoveryCodes int  `json:"recovery_codes"`
+}
@@ -0,0 +1,8 @@
+package responses
+
+// Page - wraps a list with the paging metadata
+type Page struct {
+	Data       interface{} `json:"data"`
+	Total      int         `json:"total"`
+	NextOffset int         `json:"next_offset,omitempty"` // the offset of the next page, omitted on the last page
+}
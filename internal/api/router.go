@@ -21,6 +21,7 @@ func NewRouter(env *env.Env) (http.Handler, error) {
 	v1.LoadRoutes(router, env, authorizer)
 
 	router.HandleFunc("/version", v1.VersionHandler)
+	router.HandleFunc("/.well-known/jwks.json", v1.JWKSHandler).Methods("GET")
 
 	router.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
 
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"errors"
 	"net/url"
+	"strconv"
 	"time"
+
+	uuid "github.com/satori/go.uuid"
 )
 
 // TimeParam - get the time value from the request
@@ -23,3 +27,47 @@ func TimeParam(query url.Values, name string) (time.Time, error) {
 
 	return parsed, nil
 }
+
+// OptionalTimeParam - get the time value from the request, nil when the value is not set
+func OptionalTimeParam(query url.Values, name string) (*time.Time, error) {
+
+	if query.Get(name) == "" {
+		return nil, nil
+	}
+
+	t, err := TimeParam(query, name)
+	if err != nil {
+		return nil, errors.New(name + " must be an RFC3339 time")
+	}
+	return &t, nil
+}
+
+// IntParam - get the int value from the request, returns the default when the value is not set
+func IntParam(query url.Values, name string, def int) (int, error) {
+
+	value := query.Get(name)
+	if value == "" {
+		return def, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return def, errors.New(name + " must be a number")
+	}
+	return parsed, nil
+}
+
+// VerifyUUIDParams - ensures the values given for the names are uuids, the names that are not set are skipped
+func VerifyUUIDParams(query url.Values, names ...string) error {
+
+	for _, name := range names {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		if _, err := uuid.FromString(value); err != nil {
+			return errors.New(name + " must be a uuid")
+		}
+	}
+	return nil
+}
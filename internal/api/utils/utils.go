@@ -51,7 +51,7 @@ func WriteError(w http.ResponseWriter, code int, err interface{}, data interface
 
 // WriteJSON returns a JSON data and HTTP Status Code
 func WriteJSON(w http.ResponseWriter, code int, data interface{}) {
-	w.Header().Set("Content-Type", "aplication/json")
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
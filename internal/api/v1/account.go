@@ -0,0 +1,277 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/requests"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/email"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ForgotPassword - mails a link to choose a new password, the answer is the same whether the email
+// has an account or not
+// POST - /password/forgot
+func (api *UserAPI) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> account.go -> UserApi.ForgotPassword()")
+
+	var parameters requests.EmailParameters
+	if err := parameters.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := parameters.Verify(); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Not all fields were found", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// the mail is prepared after the answer so its time does not tell the account exists
+	go api.mailAccountToken(parameters.Email, model.PurposePasswordReset)
+
+	utils.WriteJSON(w, http.StatusAccepted, &responses.ActSent{
+		Sent: true,
+	})
+}
+
+// ResetPassword - sets the password of the user the mailed token was sent to, their sessions and
+// tokens are revoked
+// POST - /password/reset
+func (api *UserAPI) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> account.go -> UserApi.ResetPassword()")
+
+	var parameters requests.ResetPasswordParameters
+	if err := parameters.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := parameters.Verify(); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !utils.ValidatePassword(parameters.Password) {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid Password", map[string]string{
+			"error": ("Password: 8 Characters conatining one lower, one upper, one number, one character"),
+		})
+		return
+	}
+
+	hashed, err := model.HashPassword(parameters.Password)
+	if err != nil {
+		logger.WithError(err).Warn("Could not hash password.")
+		utils.WriteError(w, http.StatusInternalServerError, "could not hash password", nil)
+		return
+	}
+
+	userID, err := api.db.ResetPassword(ctx, parameters.Token, hashed)
+	if err == apiErr.ErrInvalidAccountToken {
+		logger.Info("Invalid password reset token")
+		utils.WriteError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Error resetting the password")
+		utils.WriteError(w, http.StatusInternalServerError, "Error resetting the password", nil)
+		return
+	}
+
+	logger = logger.WithField("userID", userID)
+	// whoever knew the old password is signed out
+	api.revokeUser(ctx, logger, userID)
+
+	logger.Info("Password Reset")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActUpdated{
+		Updated: true,
+	})
+}
+
+// VerifyEmail - marks the email of the user the mailed token was sent to as verified, they can sign in
+// POST - /email/verify
+func (api *UserAPI) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> account.go -> UserApi.VerifyEmail()")
+
+	var parameters requests.TokenParameters
+	if err := parameters.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := parameters.Verify(); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Not all fields were found", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	userID, err := api.db.VerifyEmail(ctx, parameters.Token)
+	if err == apiErr.ErrInvalidAccountToken {
+		logger.Info("Invalid email verification token")
+		utils.WriteError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Error verifying the email")
+		utils.WriteError(w, http.StatusInternalServerError, "Error verifying the email", nil)
+		return
+	}
+
+	logger.WithField("userID", userID).Info("Email Verified")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActVerified{
+		Verified: true,
+	})
+}
+
+// ResendVerification - mails a new verification link, the answer is the same whether the email has an
+// account waiting for verification or not
+// POST - /email/verify/resend
+func (api *UserAPI) ResendVerification(w http.ResponseWriter, r *http.Request) {
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> account.go -> UserApi.ResendVerification()")
+
+	var parameters requests.EmailParameters
+	if err := parameters.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := parameters.Verify(); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Not all fields were found", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	go api.mailAccountToken(parameters.Email, model.PurposeEmailVerification)
+
+	utils.WriteJSON(w, http.StatusAccepted, &responses.ActSent{
+		Sent: true,
+	})
+}
+
+// mailAccountToken - mails a token to the account of the email, nothing is sent to the emails without
+// an active account or to the verified ones for a verification
+func (api *UserAPI) mailAccountToken(address string, purpose model.AccountTokenPurpose) {
+
+	ctx := context.Background()
+	logger := logrus.WithFields(logrus.Fields{
+		"func":    "user -> account.go -> UserApi.mailAccountToken()",
+		"purpose": purpose,
+	})
+
+	user, err := api.db.GetUserByEmail(ctx, address)
+	if err != nil {
+		logger.WithError(err).Debug("No account for the email")
+		return
+	}
+	if user.IsActive != nil && !*user.IsActive {
+		logger.WithField("userID", user.ID).Info("No mail for a deactivated user")
+		return
+	}
+	if purpose == model.PurposeEmailVerification && user.EmailVerifiedAt != nil {
+		logger.WithField("userID", user.ID).Debug("The email is already verified")
+		return
+	}
+
+	if err := api.sendAccountToken(ctx, user, purpose); err != nil {
+		logger.WithError(err).WithField("userID", user.ID).Warn("Error mailing the account token")
+	}
+}
+
+// mailVerification - mails the verification link of a new account
+func (api *UserAPI) mailVerification(user *model.User) {
+
+	logger := logrus.WithField("func", "user -> account.go -> UserApi.mailVerification()")
+	if err := api.sendAccountToken(context.Background(), user, model.PurposeEmailVerification); err != nil {
+		logger.WithError(err).WithField("userID", user.ID).Warn("Error mailing the email verification")
+	}
+}
+
+// mailExistingAccount - tells the owner of the email that someone tried to sign up with it, the
+// deactivated accounts are left alone
+func (api *UserAPI) mailExistingAccount(address string) {
+
+	ctx := context.Background()
+	logger := logrus.WithField("func", "user -> account.go -> UserApi.mailExistingAccount()")
+
+	user, err := api.db.GetUserByEmail(ctx, address)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving the account of the email")
+		return
+	}
+	if user.IsActive != nil && !*user.IsActive {
+		logger.WithField("userID", user.ID).Info("No mail for a deactivated user")
+		return
+	}
+
+	link := api.env.Config.Accounts.WebURL + "/password/forgot"
+	if err := api.env.Outbound.Account(ctx, email.EventAccountExists, user, link, time.Time{}); err != nil {
+		logger.WithError(err).WithField("userID", user.ID).Warn("Error mailing the existing account")
+	}
+}
+
+// sendAccountToken - creates a token for the user and queues the mail with its link
+func (api *UserAPI) sendAccountToken(ctx context.Context, user *model.User, purpose model.AccountTokenPurpose) error {
+
+	cfg := api.env.Config.Accounts
+	event, path, lifetime := email.EventPasswordReset, "/password/reset", cfg.ResetLifetime
+	if purpose == model.PurposeEmailVerification {
+		event, path, lifetime = email.EventVerifyEmail, "/email/verify", cfg.VerificationLifetime
+	}
+
+	token, err := newAccountToken()
+	if err != nil {
+		return err
+	}
+	accountToken := &model.AccountToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Token:     token,
+		ExpiresAt: time.Now().Add(time.Duration(lifetime) * time.Second),
+	}
+	if err := api.db.CreateAccountToken(ctx, accountToken); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s%s?token=%s", cfg.WebURL, path, url.QueryEscape(token))
+	return api.env.Outbound.Account(ctx, event, user, link, accountToken.ExpiresAt)
+}
+
+// newAccountToken - a random token that cannot be guessed, safe in the query of a link
+func newAccountToken() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
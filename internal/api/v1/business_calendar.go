@@ -0,0 +1,212 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/env"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+	"github.com/sirupsen/logrus"
+)
+
+// CalendarAPI - structure holds handlers for the business calendars
+type CalendarAPI struct {
+	db  database.Database
+	env *env.Env
+}
+
+// Load help create a subrouter for the business calendars
+func loadBusinessCalendar(router *mux.Router, env *env.Env, authorizer *middlewares.Authorizer) {
+
+	api := &CalendarAPI{env: env, db: env.DB}
+
+	// calendars are managed with the SLAs that use them
+	apiEndpoint := []apiEndpoint{
+
+		newAPIEndpoint("POST", "/business_calendars", api.Create, authorizer.ObjAuthorize("ticket_sla", "create")),
+		newAPIEndpoint("GET", "/business_calendars/{calendarID}", api.Get, authorizer.ObjAuthorize("ticket_sla", "view")), //retrieves a calendar using its ID
+		newAPIEndpoint("GET", "/business_calendars", api.List, authorizer.ObjAuthorize("ticket_sla", "list")),             //retrieves all the calendars
+
+		newAPIEndpoint("PATCH", "/business_calendars/{calendarID}", api.Update, authorizer.ObjAuthorize("ticket_sla", "update")),  //updates a calendar using its ID
+		newAPIEndpoint("DELETE", "/business_calendars/{calendarID}", api.Delete, authorizer.ObjAuthorize("ticket_sla", "delete")), //delete a calendar using its ID
+
+	}
+
+	for _, api := range apiEndpoint {
+
+		router.HandleFunc(api.Path, api.Func).Methods(api.Method)
+	}
+
+}
+
+// Create - Creates a new business calendar
+// POST - /business_calendars
+func (api *CalendarAPI) Create(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "business_calendar.go -> CalendarAPI.Create()")
+
+	var calendar model.Calendar
+
+	if err := calendar.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := calendar.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	logger = logger.WithField("calendar", *calendar.Name)
+	if err := api.db.CreateCalendar(ctx, &calendar); err != nil {
+		logger.WithError(err).Warn(err.Error())
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	createdCalendar, err := api.db.GetCalendarByID(ctx, &calendar.ID)
+	if err != nil {
+		logger.WithError(err).Warn(err.Error())
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, createdCalendar)
+}
+
+// Get -  retreives a business calendar with its working hours and holidays
+// GET - /business_calendars/{calendarID}
+func (api *CalendarAPI) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := logrus.WithField("func", "business_calendar.go -> CalendarAPI.Get()")
+
+	vars := mux.Vars(r)
+	calendarID := model.CalendarID(vars["calendarID"])
+
+	calendar, err := api.db.GetCalendarByID(ctx, &calendarID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching calendar ID: %v", calendarID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	logger.WithField("CalendarID", calendarID).Debug("Get Calendar Complete")
+
+	utils.WriteJSON(w, http.StatusOK, calendar)
+}
+
+// Update - Updates a business calendar, the working hours and holidays are replaced when sent
+// PATCH - /business_calendars/{calendarID}
+func (api *CalendarAPI) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "business_calendar.go -> CalendarAPI.Update()")
+
+	vars := mux.Vars(r)
+	calendarID := model.CalendarID(vars["calendarID"])
+
+	principal := middlewares.GetPrincipal(r)
+
+	logger = logger.WithFields(logrus.Fields{
+		"CalendarID": calendarID,
+		"pricipal":   principal,
+	})
+
+	var calendar model.Calendar
+	if err := calendar.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	storedCalendar, err := api.db.GetCalendarByID(ctx, &calendarID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching calendar ID: %v", calendarID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	storedCalendar.UpdateValues(&calendar)
+	if err := storedCalendar.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.UpdateCalendar(ctx, storedCalendar); err != nil {
+		logger.WithError(err).Warn("Error updating calendar.")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Calendar Updated")
+
+	utils.WriteJSON(w, http.StatusOK, storedCalendar)
+}
+
+// List - List all the business calendars
+// GET - /business_calendars
+func (api *CalendarAPI) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "business_calendar.go -> CalendarAPI.List()")
+
+	calendars, err := api.db.ListAllCalendars(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Retreiving all calendars")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Calendars Returned")
+
+	utils.WriteJSON(w, http.StatusOK, &calendars)
+}
+
+// Delete - Deletes a business calendar, its SLAs go back to the wall clock
+// DELETE - /business_calendars/{calendarID}
+func (api *CalendarAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "business_calendar.go -> CalendarAPI.Delete()")
+
+	vars := mux.Vars(r)
+	calendarID := model.CalendarID(vars["calendarID"])
+
+	logger = logger.WithField("CalendarID", calendarID)
+
+	deleted, err := api.db.DeleteCalendar(ctx, &calendarID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error deleting calendar: %v", calendarID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.WithField("Calendar Deleted", deleted).Info()
+
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
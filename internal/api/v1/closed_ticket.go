@@ -3,11 +3,11 @@ package v1
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/requests"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/env"
@@ -31,7 +31,7 @@ func loadClosedTicketAPI(router *mux.Router, env *env.Env, authorizer *middlewar
 
 	apiEndpoint := []apiEndpoint{
 
-		newAPIEndpoint("GET", "/closed_tickets/", closedTicketAPI.List, authorizer.ObjAuthorize("closed_ticket", "view")),                  //retrieves all closed tickets
+		newAPIEndpoint("GET", "/closed_tickets", closedTicketAPI.List, authorizer.ObjAuthorize("closed_ticket", "list")),                   //retrieves the closed tickets matching the query filters
 		newAPIEndpoint("GET", "/closed_tickets/{ticketID}", closedTicketAPI.Get, authorizer.ObjAuthorize("closed_ticket", "view")),         //retrieves a ticket using its ID
 		newAPIEndpoint("DELETE", "/closed_tickets/{ticketID}", closedTicketAPI.Delete, authorizer.ObjAuthorize("closed_ticket", "delete")), //retrieves a ticket using its ID
 
@@ -43,7 +43,8 @@ func loadClosedTicketAPI(router *mux.Router, env *env.Env, authorizer *middlewar
 
 }
 
-// Get -  retreives ticket information
+// Get -  retreives a closed ticket with its closing remark
+// GET - /closed_tickets/{ticketID}
 func (api *ClosedTicketAPI) Get(w http.ResponseWriter, r *http.Request) {
 	logger := logrus.WithField("func", "[API-Gateway] -> closedTicketAPI.Get()")
 
@@ -52,25 +53,21 @@ func (api *ClosedTicketAPI) Get(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	ticket, err := api.db.GetTicketByID(ctx, &ticketID)
+	ticket, err := api.db.GetClosedTicket(ctx, &ticketID)
 	if err != nil {
-		errMessage := fmt.Sprintf("Error fetching ticket TicketID: %v", ticketID)
+		errMessage := fmt.Sprintf("Error fetching closed ticket TicketID: %v", ticketID)
 		logger.WithError(err).Warn(errMessage)
 		utils.WriteError(w, http.StatusNotFound, err.Error(), nil)
 		return
 	}
-	if err := api.getTicketProps(ctx, ticket); err != nil {
-		logger.WithError(err).Error()
-		utils.WriteError(w, http.StatusNotFound, err, nil)
-		return
-	}
+	trimClosedTicketProps(ticket)
 	logger.WithField("TicketID", ticketID).Debug("Get Ticket Complete")
 
 	utils.WriteJSON(w, http.StatusOK, ticket)
 }
 
-// List - List all the tickets
-// GET - /tickets
+// List - List the closed tickets matching the query filters
+// GET - /closed_tickets?cause_id=&closed_by=&closed_from=&closed_to=&order=desc&limit=50&offset=0
 // Permission Admin
 func (api *ClosedTicketAPI) List(w http.ResponseWriter, r *http.Request) {
 	// Show function name in error logs to track errors faster
@@ -81,33 +78,41 @@ func (api *ClosedTicketAPI) List(w http.ResponseWriter, r *http.Request) {
 	logger = logger.WithFields(logrus.Fields{
 		"pricipal": principal,
 	})
-	log.Printf("Query => %+v\n", r.URL.Query())
 	ctx := r.Context()
 
-	tickets, err := api.db.ListAllTickets(ctx)
+	filter, err := requests.ClosedTicketFilter(r.URL.Query())
 	if err != nil {
-		errMessage := fmt.Sprintf("Error retreiving all the tickets")
+		logger.WithError(err).Warn("Error with submitted query")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted query", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tickets, total, err := api.db.ListClosedTickets(ctx, filter)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error retreiving the closed tickets")
 		logger.WithError(err).Warn(errMessage)
-		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving all the tickets", nil)
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the closed tickets", nil)
 		return
 
 	}
-	// add all the properties for the tickets
+	// remove the ids already present in the ticket properties
 	for index := range tickets {
-		if err := api.getTicketProps(ctx, tickets[index]); err != nil {
-			logger.WithError(err).Error()
-			utils.WriteError(w, http.StatusNotFound, err, nil)
-			return
-		}
+		trimClosedTicketProps(tickets[index])
 	}
-	logger.Info("Tickets List Returned")
+	logger.Info("Closed Tickets List Returned")
 
-	utils.WriteJSON(w, http.StatusOK, &tickets)
+	page := responses.Page{Data: tickets, Total: total}
+	if next := filter.Offset + len(tickets); next < total {
+		page.NextOffset = next
+	}
+	utils.WriteJSON(w, http.StatusOK, &page)
 
 }
 
-// Delete - Deletes a ticket
-// DELETE - /tickets/{userID}
+// Delete - Deletes a closed ticket
+// DELETE - /closed_tickets/{ticketID}
 func (api *ClosedTicketAPI) Delete(w http.ResponseWriter, r *http.Request) {
 	// Show function name in error logs to track errors faster
 	logger := logrus.WithField("func", "[API-Gateway] -> closedTicketAPI.Delete()")
@@ -123,7 +128,14 @@ func (api *ClosedTicketAPI) Delete(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	deleted, err := api.db.DeleteTicket(ctx, &ticketID)
+	// only closed tickets are deleted through the archive
+	if _, err := api.db.GetClosedTicket(ctx, &ticketID); err != nil {
+		logger.WithError(err).Warn("Retrieving closed ticket")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	deleted, err := api.db.DeleteTicket(ctx, &ticketID, model.NewActivity(ticketID, principal.UserID, model.ActivityDeleted))
 	if err != nil {
 		errMessage := fmt.Sprintf("Error deleting ticket: %v", ticketID)
 		logger.WithError(err).Warn(errMessage)
@@ -132,7 +144,6 @@ func (api *ClosedTicketAPI) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if deleted {
-
 		logger.Info("Ticket Deleted")
 	}
 
@@ -142,114 +153,23 @@ func (api *ClosedTicketAPI) Delete(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func (api *ClosedTicketAPI) getTicketProps(ctx context.Context, ticket *model.Ticket) (err error) {
+// trimClosedTicketProps - removes the ids already present in the ticket properties and the closing remark
+func trimClosedTicketProps(ticket *model.Ticket) {
+	trimTicketProps(ticket)
 
-	ticket.Category, err = api.env.DB.GetCategoryByID(ctx, &ticket.CategoryID)
-	if err != nil {
-		logrus.WithError(err).Warn("Error category of ticket")
-		return
-	}
-	ticket.Priority, err = api.db.GetPriorityByID(ctx, &ticket.PriorityID)
-	if err != nil {
-		logrus.WithError(err).Warn("Error priority of ticket")
-		return
+	if ticket.ClosingRemark != nil && ticket.ClosingRemark.Cause != nil {
+		ticket.ClosingRemark.CauseID = model.NilCauseID
 	}
-	ticket.Status, err = api.db.GetStatusByID(ctx, &ticket.StatusID)
-	if err != nil {
-		logrus.WithError(err).Warn("Error status of ticket")
-		return
-	}
-	ticket.SLA, err = api.db.GetSLAByID(ctx, &ticket.SLAID)
-	if err != nil {
-		logrus.WithError(err).Warn("Error SLA of ticket")
-		return
-	}
-	ticket.Source, err = api.db.GetSourceByID(ctx, &ticket.SourceID)
-	if err != nil {
-		logrus.WithError(err).Warn("Error source of ticket")
-		return
-	}
-
-	ticket.CreatedBy, err = api.db.GetUserByID(ctx, &ticket.UserID)
-	if err != nil {
-		logrus.WithError(err).Warn("Error user id of the person who created the ticket")
-		return
-	}
-	
-	/*
-		ticket.ClosingRemark, err = api.db.ClosingRemark(ctx, &ticket.ID)
-		if err != nil {
-			logrus.WithError(err).Warn("Error retrieving the closing remark")
-			return
-		}else {
-			//fetch the root cause
-			ticket.ClosingRemark.Cause, err = api.db.GetCauseByID(ctx, &ticket.ClosingRemark.CauseID)
-			if err != nil {
-				logrus.WithError(err).Warn("Error retrieving root cause of ticket")
-				return
-			}
-			ticket.ClosingRemark.ClosedBy, err = api.db.GetUserByID(ctx, &ticket.ClosingRemark.UserID)
-			if err != nil {
-				logrus.WithError(err).Warn("Error retrieving who closed the ticket")
-				return
-			}
-		} */
-
-	// Remove all the unecessary
-	// category
-	ticket.CategoryID = model.NilCategoryID
-	ticket.Category.Description = nil
-	ticket.Category.Weight = nil
-	ticket.Category.CreatedAt = nil
-	ticket.Category.UpdatedAt = nil
-	ticket.Category.DeletedAt = nil
-
-	ticket.PriorityID = model.NilPriorityID
-	ticket.Priority.Weight = nil
-	ticket.Priority.CreatedAt = nil
-	ticket.Priority.UpdatedAt = nil
-	ticket.Priority.DeletedAt = nil
-
-	ticket.StatusID = model.NilStatusID
-	ticket.Status.Weight = nil
-	ticket.Status.CreatedAt = nil
-	ticket.Status.UpdatedAt = nil
-	ticket.Status.DeletedAt = nil
-
-	ticket.SLAID = model.NilSLAID
-	ticket.SLA.Weight = nil
-	ticket.SLA.CreatedAt = nil
-	ticket.SLA.UpdatedAt = nil
-	ticket.SLA.DeletedAt = nil
-
-	ticket.SourceID = model.NilSourceID
-	ticket.Source.Weight = nil
-	ticket.Source.CreatedAt = nil
-	ticket.Source.UpdatedAt = nil
-	ticket.Source.DeletedAt = nil
-
-	ticket.UserID = model.NilUserID
-	ticket.CreatedBy.Name = func() *string {
-		s := fmt.Sprintf("%s %s", *ticket.CreatedBy.Firstname, *ticket.CreatedBy.Lastname)
-		return &s
-	}()
-	ticket.CreatedBy.Firstname = nil
-	ticket.CreatedBy.Lastname = nil
-	ticket.CreatedBy.Email = nil
-	ticket.CreatedBy.Type = nil
-	ticket.CreatedBy.RoleID = model.NilRoleID
-	ticket.CreatedBy.PasswordHash = nil
-	ticket.CreatedBy.IsActive = nil
-	ticket.CreatedBy.IsSystem = nil
-	ticket.CreatedBy.CreatedAt = nil
-	ticket.CreatedBy.UpdatedAt = nil
-	ticket.CreatedBy.DeletedAt = nil
-
-	return
 }
 
 func (api *ClosedTicketAPI) getNoteProps(ctx context.Context, note *model.Note) (err error) {
 
+	// notes added by the system, escalations and mail replies, have no author
+	if note.UserID == model.NilUserID {
+		note.TicketID = model.NilTicketID
+		return nil
+	}
+
 	note.CreatedBy, err = api.db.GetUserByID(ctx, &note.UserID)
 	if err != nil {
 		logrus.WithError(err).Warn("Error fetching the user who created the ticket")
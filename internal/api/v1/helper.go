@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"net"
 	"net/http"
 
 	"github.com/justinas/alice"
@@ -26,3 +27,20 @@ func tagSpan(span opentracing.Span, key, value string) {
 		span.SetTag(key, value)
 	}
 }
+
+// stringValue - the value of an optional string, empty when it is not set
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// clientIP - the address the request came from, without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,285 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/env"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/email"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+	"github.com/sirupsen/logrus"
+)
+
+// InboundMailAPI - structure holds handlers for the inbound mailboxes
+type InboundMailAPI struct {
+	db  database.Database
+	env *env.Env
+}
+
+// Load help create a subrouter for the inbound mailboxes
+func loadInboundMail(router *mux.Router, env *env.Env, authorizer *middlewares.Authorizer) {
+
+	api := &InboundMailAPI{env: env, db: env.DB}
+
+	apiEndpoint := []apiEndpoint{
+
+		newAPIEndpoint("POST", "/inbound_mails", api.Create, authorizer.ObjAuthorize("inbound_mail", "create")),
+		newAPIEndpoint("GET", "/inbound_mails/{inboundMailID}", api.Get, authorizer.ObjAuthorize("inbound_mail", "view")),           //retrieves a mailbox using its ID
+		newAPIEndpoint("GET", "/inbound_mails", api.List, authorizer.ObjAuthorize("inbound_mail", "list")),                          //retrieves all the mailboxes
+		newAPIEndpoint("GET", "/inbound_mails/{inboundMailID}/health", api.Health, authorizer.ObjAuthorize("inbound_mail", "view")), //the sync state of a mailbox
+
+		newAPIEndpoint("PATCH", "/inbound_mails/{inboundMailID}", api.Update, authorizer.ObjAuthorize("inbound_mail", "update")),   //updates a mailbox using its ID
+		newAPIEndpoint("DELETE", "/inbound_mails/{inboundMailID}", api.Delete, authorizer.ObjAuthorize("inbound_mail", "delete")),  //delete a mailbox using its ID
+		newAPIEndpoint("POST", "/inbound_mails/{inboundMailID}/test", api.Test, authorizer.ObjAuthorize("inbound_mail", "update")), //connects to the mailbox with its settings
+
+	}
+
+	for _, api := range apiEndpoint {
+
+		router.HandleFunc(api.Path, api.Func).Methods(api.Method)
+	}
+
+}
+
+// Create - Creates a new mailbox and starts polling it
+// POST - /inbound_mails
+func (api *InboundMailAPI) Create(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	principal := middlewares.GetPrincipal(r)
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "inbound_mail.go -> InboundMailAPI.Create()")
+
+	var inboundMail model.InboudMail
+
+	if err := inboundMail.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := inboundMail.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	inboundMail.UserID = principal.UserID
+	logger = logger.WithField("inbound mail", *inboundMail.Name)
+	if err := api.db.CreateInboundMail(ctx, &inboundMail); err != nil {
+		logger.WithError(err).Warn(err.Error())
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	api.reload(inboundMail.ID)
+
+	createdInboundMail, err := api.db.GetInboundMailByID(ctx, &inboundMail.ID)
+	if err != nil {
+		logger.WithError(err).Warn(err.Error())
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, createdInboundMail)
+}
+
+// Get -  retreives a mailbox, the secret is not returned
+// GET - /inbound_mails/{inboundMailID}
+func (api *InboundMailAPI) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := logrus.WithField("func", "inbound_mail.go -> InboundMailAPI.Get()")
+
+	vars := mux.Vars(r)
+	inboundMailID := model.InboudMailID(vars["inboundMailID"])
+
+	inboundMail, err := api.db.GetInboundMailByID(ctx, &inboundMailID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching inbound mail ID: %v", inboundMailID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	logger.WithField("InboundMailID", inboundMailID).Debug("Get Inbound Mail Complete")
+
+	utils.WriteJSON(w, http.StatusOK, inboundMail)
+}
+
+// Update - Updates a mailbox, its poller is restarted with the new settings
+// PATCH - /inbound_mails/{inboundMailID}
+func (api *InboundMailAPI) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "inbound_mail.go -> InboundMailAPI.Update()")
+
+	vars := mux.Vars(r)
+	inboundMailID := model.InboudMailID(vars["inboundMailID"])
+
+	principal := middlewares.GetPrincipal(r)
+
+	logger = logger.WithFields(logrus.Fields{
+		"InboundMailID": inboundMailID,
+		"pricipal":      principal,
+	})
+
+	var inboundMail model.InboudMail
+	if err := inboundMail.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	storedInboundMail, err := api.db.GetInboundMailByID(ctx, &inboundMailID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching inbound mail ID: %v", inboundMailID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	storedInboundMail.UpdateValues(&inboundMail)
+	if err := storedInboundMail.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.UpdateInboudMail(ctx, storedInboundMail); err != nil {
+		logger.WithError(err).Warn("Error updating inbound mail.")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	api.reload(inboundMailID)
+
+	logger.Info("Inbound Mail Updated")
+
+	utils.WriteJSON(w, http.StatusOK, storedInboundMail)
+}
+
+// List - List all the mailboxes
+// GET - /inbound_mails
+func (api *InboundMailAPI) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "inbound_mail.go -> InboundMailAPI.List()")
+
+	inboundMails, err := api.db.ListInboundMails(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Retreiving all inbound mails")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Inbound Mails Returned")
+
+	utils.WriteJSON(w, http.StatusOK, &inboundMails)
+}
+
+// Delete - Deletes a mailbox and stops polling it, the tickets it created are kept
+// DELETE - /inbound_mails/{inboundMailID}
+func (api *InboundMailAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "inbound_mail.go -> InboundMailAPI.Delete()")
+
+	vars := mux.Vars(r)
+	inboundMailID := model.InboudMailID(vars["inboundMailID"])
+
+	logger = logger.WithField("InboundMailID", inboundMailID)
+
+	deleted, err := api.db.DeleteInboundMail(ctx, &inboundMailID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error deleting inbound mail: %v", inboundMailID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	api.reload(inboundMailID)
+
+	logger.WithField("Inbound Mail Deleted", deleted).Info()
+
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
+
+// Test - connects to the mailbox with its saved settings
+// POST - /inbound_mails/{inboundMailID}/test
+func (api *InboundMailAPI) Test(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "inbound_mail.go -> InboundMailAPI.Test()")
+
+	vars := mux.Vars(r)
+	inboundMailID := model.InboudMailID(vars["inboundMailID"])
+
+	logger = logger.WithField("InboundMailID", inboundMailID)
+
+	inboundMail, err := api.db.GetInboundMailByID(ctx, &inboundMailID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching inbound mail ID: %v", inboundMailID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	result := responses.ActTested{Connected: true}
+	if err := email.TestConnection(inboundMail); err != nil {
+		logger.WithError(err).Info("Inbound mail connection failed")
+		result = responses.ActTested{Connected: false, Error: err.Error()}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, &result)
+}
+
+// Health - the sync state of a mailbox and of its poller
+// GET - /inbound_mails/{inboundMailID}/health
+func (api *InboundMailAPI) Health(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := logrus.WithField("func", "inbound_mail.go -> InboundMailAPI.Health()")
+
+	vars := mux.Vars(r)
+	inboundMailID := model.InboudMailID(vars["inboundMailID"])
+
+	inboundMail, err := api.db.GetInboundMailByID(ctx, &inboundMailID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching inbound mail ID: %v", inboundMailID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, api.env.Mailboxes.Health(inboundMail))
+}
+
+// reload - restarts the poller of the mailbox in the background, the running poll is waited for
+func (api *InboundMailAPI) reload(inboundMailID model.InboudMailID) {
+	go func() {
+		// the reload waits for the polls of the other reloads to finish
+		ctx, cancelFunc := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancelFunc()
+		if err := api.env.Mailboxes.Reload(ctx, inboundMailID); err != nil {
+			logrus.WithError(err).WithField("InboundMailID", inboundMailID).Warn("Reloading the mailbox poller")
+		}
+	}()
+}
@@ -0,0 +1,16 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/auth"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+)
+
+// JWKSHandler - the public keys the other services verify our tokens with
+// GET - /.well-known/jwks.json
+func JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	// the keys only change on restart, the verifiers can keep them for a while
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.WriteJSON(w, http.StatusOK, auth.JWKS())
+}
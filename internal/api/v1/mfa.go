@@ -0,0 +1,644 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/auth"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/requests"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/totp"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// mfaMaxAttempts - the invalid codes accepted before the user has to wait
+	mfaMaxAttempts = 5
+	// mfaLockout - the time the invalid codes are counted over and the user waits once locked
+	mfaLockout = 15 * time.Minute
+	// recoveryCodeCount - the recovery codes given to the user, each is used once
+	recoveryCodeCount = 10
+)
+
+// recoveryEncoding - the recovery codes are typed by hand, base32 has no letters looking alike
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// LoginMFA - the second step of the login, exchanges the challenge token and a code for the tokens.
+// The first code of a user enrolling during the login enables their app.
+// POST - /login/mfa
+func (api *UserAPI) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.LoginMFA()")
+
+	var parameters requests.MFALoginParameters
+	if err := parameters.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := parameters.Verify(); err != nil {
+		logger.WithError(err).Warn("Not all fields found")
+		utils.WriteError(w, http.StatusBadRequest, "Not all fields were found", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, ok := api.challengedUser(ctx, w, logger, parameters.MFAToken)
+	if !ok {
+		return
+	}
+	logger = logger.WithFields(logrus.Fields{
+		"userID":   user.ID,
+		"deviceID": parameters.DeviceID,
+	})
+
+	mfa, err := api.db.GetMFA(ctx, &user.ID)
+	if err == sql.ErrNoRows {
+		logger.Warn("The user has no authenticator app")
+		utils.WriteError(w, http.StatusBadRequest, apiErr.ErrMFANotEnrolled, nil)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Error retrieving the MFA of the user")
+		utils.WriteError(w, http.StatusInternalServerError, "Currently unable to login", nil)
+		return
+	}
+	if err := api.verifyMFACode(ctx, mfa, &parameters.MFACodeParameters); err != nil {
+		writeMFAError(w, logger, err)
+		return
+	}
+
+	if err := api.loadAccess(ctx, user); err != nil {
+		logger.WithError(err).Warn("Error fetching the actions and role of the user")
+		utils.WriteError(w, http.StatusInternalServerError, "Currently unable to login", nil)
+		return
+	}
+	logger.Debug("User logged in with MFA")
+
+	api.writeToTokenResponse(r, w, http.StatusOK, user, parameters.DeviceID, "", true)
+}
+
+// EnrollMFAAtLogin - starts the enrolment of the users MFA is required for who have no app yet, the
+// challenge token of the login proves their password
+// POST - /login/mfa/enroll
+func (api *UserAPI) EnrollMFAAtLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.EnrollMFAAtLogin()")
+
+	var parameters requests.MFATokenParameters
+	if err := parameters.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := parameters.Verify(); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Not all fields were found", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, ok := api.challengedUser(ctx, w, logger, parameters.MFAToken)
+	if !ok {
+		return
+	}
+	logger = logger.WithField("userID", user.ID)
+
+	enrollment, err := api.enrollMFA(ctx, user)
+	if err != nil {
+		writeMFAError(w, logger, err)
+		return
+	}
+
+	logger.Info("MFA enrolment started at login")
+	utils.WriteJSON(w, http.StatusOK, enrollment)
+}
+
+// GetMFA - the two-factor authentication of the user
+// GET - /users/me/mfa
+func (api *UserAPI) GetMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.GetMFA()")
+
+	principal := middlewares.GetPrincipal(r)
+	logger = logger.WithField("userID", principal.UserID)
+
+	var status responses.MFAStatus
+	mfa, err := api.db.GetMFA(ctx, &principal.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		logger.WithError(err).Warn("Error retrieving the MFA of the user")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the MFA", nil)
+		return
+	}
+	status.Enabled = mfa.Enabled()
+	if status.Enabled {
+		if status.RecoveryCodes, err = api.db.CountRecoveryCodes(ctx, &principal.UserID); err != nil {
+			logger.WithError(err).Warn("Error counting the recovery codes")
+			utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the MFA", nil)
+			return
+		}
+	}
+	if status.Required, err = api.db.IsMFARequired(ctx, &principal.UserID); err != nil {
+		logger.WithError(err).Warn("Error checking the MFA requirements")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the MFA", nil)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, &status)
+}
+
+// EnrollMFA - creates the secret of a new authenticator app, it is enabled by confirming a first code
+// POST - /users/me/mfa/totp
+func (api *UserAPI) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.EnrollMFA()")
+
+	principal := middlewares.GetPrincipal(r)
+	logger = logger.WithField("userID", principal.UserID)
+
+	user, err := api.db.GetUserByID(ctx, &principal.UserID)
+	if err != nil {
+		logger.WithError(err).Warn("Error retrieving the user")
+		utils.WriteError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	enrollment, err := api.enrollMFA(ctx, user)
+	if err != nil {
+		writeMFAError(w, logger, err)
+		return
+	}
+
+	logger.Info("MFA enrolment started")
+	utils.WriteJSON(w, http.StatusOK, enrollment)
+}
+
+// ConfirmMFA - enables the authenticator app of the enrolment with a first code
+// POST - /users/me/mfa/totp/confirm
+func (api *UserAPI) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.ConfirmMFA()")
+
+	principal := middlewares.GetPrincipal(r)
+	logger = logger.WithField("userID", principal.UserID)
+
+	var parameters requests.MFACodeParameters
+	if !decodeMFACode(w, r, logger, &parameters) {
+		return
+	}
+
+	mfa, err := api.db.GetMFA(ctx, &principal.UserID)
+	if err == sql.ErrNoRows {
+		utils.WriteError(w, http.StatusBadRequest, apiErr.ErrMFANotEnrolled, nil)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Error retrieving the MFA of the user")
+		utils.WriteError(w, http.StatusInternalServerError, "Error enabling the MFA", nil)
+		return
+	}
+	if mfa.Enabled() {
+		utils.WriteError(w, http.StatusConflict, apiErr.ErrMFAAlreadyEnabled, nil)
+		return
+	}
+	if err := api.verifyMFACode(ctx, mfa, &parameters); err != nil {
+		writeMFAError(w, logger, err)
+		return
+	}
+
+	logger.Info("MFA Enabled")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActUpdated{
+		Updated: true,
+	})
+}
+
+// DisableMFA - removes the authenticator app of the user, a code is asked again so a stolen token
+// cannot turn it off
+// DELETE - /users/me/mfa/totp
+func (api *UserAPI) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.DisableMFA()")
+
+	principal := middlewares.GetPrincipal(r)
+	logger = logger.WithField("userID", principal.UserID)
+
+	var parameters requests.MFACodeParameters
+	if !decodeMFACode(w, r, logger, &parameters) {
+		return
+	}
+
+	required, err := api.db.IsMFARequired(ctx, &principal.UserID)
+	if err != nil {
+		logger.WithError(err).Warn("Error checking the MFA requirements")
+		utils.WriteError(w, http.StatusInternalServerError, "Error disabling the MFA", nil)
+		return
+	}
+	if required {
+		utils.WriteError(w, http.StatusForbidden, apiErr.ErrMFARequired, nil)
+		return
+	}
+
+	mfa, ok := api.enabledMFA(ctx, w, logger, principal.UserID)
+	if !ok {
+		return
+	}
+	if err := api.verifyMFACode(ctx, mfa, &parameters); err != nil {
+		writeMFAError(w, logger, err)
+		return
+	}
+
+	deleted, err := api.db.DisableMFA(ctx, &principal.UserID)
+	if err != nil {
+		logger.WithError(err).Warn("Error disabling the MFA")
+		utils.WriteError(w, http.StatusInternalServerError, "Error disabling the MFA", nil)
+		return
+	}
+
+	logger.Info("MFA Disabled")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
+
+// RegenerateRecoveryCodes - replaces the recovery codes of the user, the previous ones no longer work
+// POST - /users/me/mfa/recovery_codes
+func (api *UserAPI) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.RegenerateRecoveryCodes()")
+
+	principal := middlewares.GetPrincipal(r)
+	logger = logger.WithField("userID", principal.UserID)
+
+	var parameters requests.MFACodeParameters
+	if !decodeMFACode(w, r, logger, &parameters) {
+		return
+	}
+
+	mfa, ok := api.enabledMFA(ctx, w, logger, principal.UserID)
+	if !ok {
+		return
+	}
+	if err := api.verifyMFACode(ctx, mfa, &parameters); err != nil {
+		writeMFAError(w, logger, err)
+		return
+	}
+
+	codes, err := newRecoveryCodes()
+	if err == nil {
+		err = api.db.ReplaceRecoveryCodes(ctx, &principal.UserID, normalizeRecoveryCodes(codes))
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Error replacing the recovery codes")
+		utils.WriteError(w, http.StatusInternalServerError, "Error creating the recovery codes", nil)
+		return
+	}
+
+	logger.Info("Recovery Codes Replaced")
+	utils.WriteJSON(w, http.StatusOK, &responses.MFARecoveryCodes{
+		RecoveryCodes: codes,
+	})
+}
+
+// ResetMFA - removes the authenticator app of a user who lost it, they enroll again at their next login
+// when MFA is required for them
+// DELETE - /users/{userID}/mfa
+// Permission Admin
+func (api *UserAPI) ResetMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.ResetMFA()")
+
+	userID := model.UserID(mux.Vars(r)["userID"])
+	principal := middlewares.GetPrincipal(r)
+
+	logger = logger.WithFields(logrus.Fields{
+		"UserID":   userID,
+		"pricipal": principal,
+	})
+
+	deleted, err := api.db.DisableMFA(ctx, &userID)
+	if err != nil {
+		logger.WithError(err).Warn("Error resetting the MFA")
+		utils.WriteError(w, http.StatusInternalServerError, "Error resetting the MFA", nil)
+		return
+	}
+
+	logger.Info("MFA Reset")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
+
+// CreateMFARequirement - requires MFA for the users of a type or with a role
+// POST - /mfa_requirements
+// Permission Admin
+func (api *UserAPI) CreateMFARequirement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.CreateMFARequirement()")
+
+	var requirement model.MFARequirement
+	if err := requirement.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := requirement.Verify(); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.CreateMFARequirement(ctx, &requirement); err != nil {
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
+		logger.WithError(err).Warn("Error creating the MFA requirement")
+		utils.WriteError(w, http.StatusInternalServerError, "Error creating the MFA requirement", nil)
+		return
+	}
+
+	logger.WithField("requirementID", requirement.ID).Info("MFA Requirement Created")
+	utils.WriteJSON(w, http.StatusCreated, &requirement)
+}
+
+// ListMFARequirements - the user types and roles that have to sign in with MFA
+// GET - /mfa_requirements
+// Permission Admin
+func (api *UserAPI) ListMFARequirements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.ListMFARequirements()")
+
+	requirements, err := api.db.ListMFARequirements(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Error retrieving the MFA requirements")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the MFA requirements", nil)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, &requirements)
+}
+
+// DeleteMFARequirement - MFA is optional again for the user type or role
+// DELETE - /mfa_requirements/{requirementID}
+// Permission Admin
+func (api *UserAPI) DeleteMFARequirement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> mfa.go -> UserApi.DeleteMFARequirement()")
+
+	requirementID := model.MFARequirementID(mux.Vars(r)["requirementID"])
+	logger = logger.WithField("requirementID", requirementID)
+
+	deleted, err := api.db.DeleteMFARequirement(ctx, &requirementID)
+	if err != nil {
+		logger.WithError(err).Warn("Error deleting the MFA requirement")
+		utils.WriteError(w, http.StatusInternalServerError, "Error deleting the MFA requirement", nil)
+		return
+	}
+
+	logger.Info("MFA Requirement Deleted")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
+
+// mfaChallenge - the challenge returned by the login of the users signing in with a code, nil when the
+// user signs in with their password alone
+func (api *UserAPI) mfaChallenge(ctx context.Context, userID model.UserID) (*responses.MFAChallenge, error) {
+
+	mfa, err := api.db.GetMFA(ctx, &userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// the users MFA is required for enroll an app before they get their tokens
+	enrollment := false
+	if !mfa.Enabled() {
+		required, err := api.db.IsMFARequired(ctx, &userID)
+		if err != nil || !required {
+			return nil, err
+		}
+		enrollment = true
+	}
+
+	token, expiresAt, err := auth.IssueMFAChallenge(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &responses.MFAChallenge{
+		MFARequired:        true,
+		MFAToken:           token,
+		ExpiresAt:          expiresAt,
+		EnrollmentRequired: enrollment,
+	}, nil
+}
+
+// challengedUser - the user of the challenge token, the error is written when it is not valid
+func (api *UserAPI) challengedUser(ctx context.Context, w http.ResponseWriter, logger *logrus.Entry, mfaToken string) (*model.User, bool) {
+
+	principal, err := auth.VerifyMFAChallenge(mfaToken)
+	if err == nil {
+		err = auth.CheckRevoked(ctx, principal)
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Invalid MFA token")
+		utils.WriteError(w, http.StatusUnauthorized, apiErr.ErrInvalidMFAToken, nil)
+		return nil, false
+	}
+
+	user, err := api.db.GetUserByID(ctx, &principal.UserID)
+	if err != nil {
+		logger.WithError(err).Warn("Error retrieving the user")
+		utils.WriteError(w, http.StatusUnauthorized, apiErr.ErrInvalidMFAToken, nil)
+		return nil, false
+	}
+	if user.DeletedAt != nil || (user.IsActive != nil && !*user.IsActive) {
+		logger.WithField("userID", user.ID).Warn("The user can no longer sign in")
+		utils.WriteError(w, http.StatusUnauthorized, apiErr.ErrInvalidMFAToken, nil)
+		return nil, false
+	}
+	return user, true
+}
+
+// enabledMFA - the enabled authenticator app of the user, the error is written when there is none
+func (api *UserAPI) enabledMFA(ctx context.Context, w http.ResponseWriter, logger *logrus.Entry, userID model.UserID) (*model.MFA, bool) {
+
+	mfa, err := api.db.GetMFA(ctx, &userID)
+	if err != nil && err != sql.ErrNoRows {
+		logger.WithError(err).Warn("Error retrieving the MFA of the user")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the MFA", nil)
+		return nil, false
+	}
+	if !mfa.Enabled() {
+		utils.WriteError(w, http.StatusBadRequest, apiErr.ErrMFANotEnrolled, nil)
+		return nil, false
+	}
+	return mfa, true
+}
+
+// enrollMFA - a new secret and recovery codes for the user, the app is not enabled until a first
+// code is confirmed
+func (api *UserAPI) enrollMFA(ctx context.Context, user *model.User) (*responses.MFAEnrollment, error) {
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := api.db.EnrollMFA(ctx, &user.ID, secret, normalizeRecoveryCodes(codes)); err != nil {
+		return nil, err
+	}
+
+	account := string(user.ID)
+	if user.Email != nil {
+		account = *user.Email
+	}
+	return &responses.MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(api.env.Config.MFA.Issuer, account, secret),
+		RecoveryCodes:   codes,
+	}, nil
+}
+
+// verifyMFACode - checks the code of the app or a recovery code, the first code of an app that is not
+// enabled yet enables it. The invalid codes are counted and lock the user out for a while.
+func (api *UserAPI) verifyMFACode(ctx context.Context, mfa *model.MFA, parameters *requests.MFACodeParameters) error {
+
+	now := time.Now()
+	if mfa.Locked(mfaMaxAttempts, mfaLockout, now) {
+		return apiErr.ErrMFALocked
+	}
+
+	valid := false
+	if parameters.Code != "" {
+		step, ok, err := totp.Validate(mfa.Secret, parameters.Code, now, mfa.LastUsedStep)
+		if err != nil {
+			return err
+		}
+		// the same code is only accepted once
+		if ok && mfa.Enabled() {
+			valid, err = api.db.UseTOTPStep(ctx, &mfa.UserID, step)
+		} else if ok {
+			valid, err = api.db.EnableMFA(ctx, &mfa.UserID, step)
+		}
+		if err != nil {
+			return err
+		}
+	} else if mfa.Enabled() {
+		var err error
+		valid, err = api.db.UseRecoveryCode(ctx, &mfa.UserID, normalizeRecoveryCode(parameters.RecoveryCode))
+		if err != nil {
+			return err
+		}
+	}
+
+	if !valid {
+		if err := api.db.RecordMFAFailure(ctx, &mfa.UserID, mfaLockout); err != nil {
+			return err
+		}
+		return apiErr.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// decodeMFACode - reads the code of the request, the error is written when there is none
+func decodeMFACode(w http.ResponseWriter, r *http.Request, logger *logrus.Entry, parameters *requests.MFACodeParameters) bool {
+
+	if err := parameters.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return false
+	}
+	if err := parameters.Verify(); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Not all fields were found", map[string]string{
+			"error": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// writeMFAError - the API errors are written as they are, the others are logged
+func writeMFAError(w http.ResponseWriter, logger *logrus.Entry, err error) {
+
+	if apiError, ok := err.(apiErr.APIError); ok {
+		logger.WithError(err).Info("MFA refused")
+		utils.WriteError(w, apiError.Code, apiError, nil)
+		return
+	}
+	logger.WithError(err).Warn("Error checking the MFA")
+	utils.WriteError(w, http.StatusInternalServerError, "Currently unable to check the code", nil)
+}
+
+// newRecoveryCodes - random codes grouped in two halves to be written down
+func newRecoveryCodes() ([]string, error) {
+
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		random := make([]byte, 8)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(recoveryEncoding.EncodeToString(random))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode - the codes are kept without the dash and in lower case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func normalizeRecoveryCodes(codes []string) []string {
+	normalized := make([]string, len(codes))
+	for i, code := range codes {
+		normalized[i] = normalizeRecoveryCode(code)
+	}
+	return normalized
+}
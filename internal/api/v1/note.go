@@ -240,6 +240,11 @@ func (api *NotesAPI) Delete(w http.ResponseWriter, r *http.Request) {
 
 func (api *NotesAPI) getNoteProps(ctx context.Context, note *model.Note)(err error) {
 
+	// notes added by the system, escalations and mail replies, have no author
+	if note.UserID == model.NilUserID {
+		return nil
+	}
+
 	note.CreatedBy, err = api.db.GetUserByID(ctx, &note.UserID)
 	if err != nil {
 		logrus.WithError(err).Warn("Error fetching the user who created the ticket")
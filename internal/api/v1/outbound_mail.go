@@ -0,0 +1,223 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/env"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+	"github.com/sirupsen/logrus"
+)
+
+// OutboundMailAPI - structure holds handlers for the SMTP servers the notifications are sent through
+type OutboundMailAPI struct {
+	db  database.Database
+	env *env.Env
+}
+
+// Load help create a subrouter for the SMTP servers
+func loadOutboundMail(router *mux.Router, env *env.Env, authorizer *middlewares.Authorizer) {
+
+	api := &OutboundMailAPI{env: env, db: env.DB}
+
+	apiEndpoint := []apiEndpoint{
+
+		newAPIEndpoint("POST", "/outbound_mails", api.Create, authorizer.ObjAuthorize("outbound_mail", "create")),
+		newAPIEndpoint("GET", "/outbound_mails/{outboundMailID}", api.Get, authorizer.ObjAuthorize("outbound_mail", "view")), //retrieves a mail server using its ID
+		newAPIEndpoint("GET", "/outbound_mails", api.List, authorizer.ObjAuthorize("outbound_mail", "list")),                 //retrieves all the mail servers
+
+		newAPIEndpoint("PATCH", "/outbound_mails/{outboundMailID}", api.Update, authorizer.ObjAuthorize("outbound_mail", "update")),  //updates a mail server using its ID
+		newAPIEndpoint("DELETE", "/outbound_mails/{outboundMailID}", api.Delete, authorizer.ObjAuthorize("outbound_mail", "delete")), //delete a mail server using its ID
+
+	}
+
+	for _, api := range apiEndpoint {
+
+		router.HandleFunc(api.Path, api.Func).Methods(api.Method)
+	}
+
+}
+
+// Create - Creates a new mail server, the primary one sends the notifications
+// POST - /outbound_mails
+func (api *OutboundMailAPI) Create(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	principal := middlewares.GetPrincipal(r)
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "outbound_mail.go -> OutboundMailAPI.Create()")
+
+	var outboundMail model.OutboundMail
+
+	if err := outboundMail.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := outboundMail.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	outboundMail.UserID = principal.UserID
+	logger = logger.WithField("outbound mail", *outboundMail.Name)
+	if err := api.db.CreateOutboundMail(ctx, &outboundMail); err != nil {
+		logger.WithError(err).Warn(err.Error())
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	createdOutboundMail, err := api.db.GetOutboundMailByID(ctx, &outboundMail.ID)
+	if err != nil {
+		logger.WithError(err).Warn(err.Error())
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, createdOutboundMail)
+}
+
+// Get -  retreives a mail server, the secret is not returned
+// GET - /outbound_mails/{outboundMailID}
+func (api *OutboundMailAPI) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := logrus.WithField("func", "outbound_mail.go -> OutboundMailAPI.Get()")
+
+	vars := mux.Vars(r)
+	outboundMailID := model.OutboundMailID(vars["outboundMailID"])
+
+	outboundMail, err := api.db.GetOutboundMailByID(ctx, &outboundMailID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching outbound mail ID: %v", outboundMailID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	logger.WithField("OutboundMailID", outboundMailID).Debug("Get Outbound Mail Complete")
+
+	utils.WriteJSON(w, http.StatusOK, outboundMail)
+}
+
+// Update - Updates a mail server, the next mails are sent with the new settings
+// PATCH - /outbound_mails/{outboundMailID}
+func (api *OutboundMailAPI) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "outbound_mail.go -> OutboundMailAPI.Update()")
+
+	vars := mux.Vars(r)
+	outboundMailID := model.OutboundMailID(vars["outboundMailID"])
+
+	principal := middlewares.GetPrincipal(r)
+
+	logger = logger.WithFields(logrus.Fields{
+		"OutboundMailID": outboundMailID,
+		"pricipal":       principal,
+	})
+
+	var outboundMail model.OutboundMail
+	if err := outboundMail.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	storedOutboundMail, err := api.db.GetOutboundMailByID(ctx, &outboundMailID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching outbound mail ID: %v", outboundMailID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	storedOutboundMail.UpdateValues(&outboundMail)
+	if err := storedOutboundMail.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.UpdateOutboundMail(ctx, storedOutboundMail); err != nil {
+		logger.WithError(err).Warn("Error updating outbound mail.")
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Outbound Mail Updated")
+
+	utils.WriteJSON(w, http.StatusOK, storedOutboundMail)
+}
+
+// List - List all the mail servers
+// GET - /outbound_mails
+func (api *OutboundMailAPI) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "outbound_mail.go -> OutboundMailAPI.List()")
+
+	outboundMails, err := api.db.ListOutboundMails(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Retreiving all outbound mails")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Outbound Mails Returned")
+
+	utils.WriteJSON(w, http.StatusOK, &outboundMails)
+}
+
+// Delete - Deletes a mail server, the queued mails wait for the next one
+// DELETE - /outbound_mails/{outboundMailID}
+func (api *OutboundMailAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "outbound_mail.go -> OutboundMailAPI.Delete()")
+
+	vars := mux.Vars(r)
+	outboundMailID := model.OutboundMailID(vars["outboundMailID"])
+
+	logger = logger.WithField("OutboundMailID", outboundMailID)
+
+	deleted, err := api.db.DeleteOutboundMail(ctx, &outboundMailID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error deleting outbound mail: %v", outboundMailID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.WithField("Outbound Mail Deleted", deleted).Info()
+
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/auth"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Logout - ends the session of the device, its refresh token can no longer be used
+// POST - /logout
+func (api *UserAPI) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> session.go -> UserApi.Logout()")
+
+	principal := middlewares.GetPrincipal(r)
+
+	var sessionData model.SessionData
+	if err := sessionData.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := sessionData.Verify(); err != nil {
+		logger.WithError(err).Warn("Not all fields found")
+		utils.WriteError(w, http.StatusBadRequest, "Not all fields were found", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"userID":   principal.UserID,
+		"deviceID": sessionData.DeviceID,
+	})
+
+	deleted, err := api.db.DeleteSession(ctx, &principal.UserID, &sessionData.DeviceID)
+	if err != nil {
+		logger.WithError(err).Warn("Error ending the session")
+		utils.WriteError(w, http.StatusInternalServerError, "Currently unable to logout", nil)
+		return
+	}
+	clearRefreshCookie(w)
+
+	logger.Info("User logged out")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
+
+// ListSessions - the devices the user is signed in on
+// GET - /users/me/sessions
+func (api *UserAPI) ListSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> session.go -> UserApi.ListSessions()")
+
+	principal := middlewares.GetPrincipal(r)
+	logger = logger.WithField("userID", principal.UserID)
+
+	sessions, err := api.db.ListUserSessions(ctx, &principal.UserID)
+	if err != nil {
+		logger.WithError(err).Warn("Error retrieving the sessions")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the sessions", nil)
+		return
+	}
+
+	logger.Info("Sessions Returned")
+	utils.WriteJSON(w, http.StatusOK, &sessions)
+}
+
+// DeleteSession - signs the user out of one of their devices
+// DELETE - /users/me/sessions/{deviceID}
+func (api *UserAPI) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> session.go -> UserApi.DeleteSession()")
+
+	principal := middlewares.GetPrincipal(r)
+	deviceID := model.DeviceID(mux.Vars(r)["deviceID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"userID":   principal.UserID,
+		"deviceID": deviceID,
+	})
+
+	deleted, err := api.db.DeleteSession(ctx, &principal.UserID, &deviceID)
+	if err != nil {
+		logger.WithError(err).Warn("Error ending the session")
+		utils.WriteError(w, http.StatusInternalServerError, "Error ending the session", nil)
+		return
+	}
+
+	logger.Info("Session Deleted")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
+
+// RevokeSessions - signs a user out of all their devices, their access tokens are revoked as well
+// DELETE - /users/{userID}/sessions
+// Permission Admin
+func (api *UserAPI) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> session.go -> UserApi.RevokeSessions()")
+
+	userID := model.UserID(mux.Vars(r)["userID"])
+	principal := middlewares.GetPrincipal(r)
+
+	logger = logger.WithFields(logrus.Fields{
+		"UserID":   userID,
+		"pricipal": principal,
+	})
+
+	// the access tokens still valid are revoked with the sessions
+	if err := auth.RevokeTokens(ctx, userID); err != nil {
+		logger.WithError(err).Warn("Error revoking the tokens")
+		utils.WriteError(w, http.StatusInternalServerError, "Error revoking the sessions", nil)
+		return
+	}
+	if err := api.db.RevokeUserSessions(ctx, &userID); err != nil {
+		logger.WithError(err).Warn("Error revoking the sessions")
+		utils.WriteError(w, http.StatusInternalServerError, "Error revoking the sessions", nil)
+		return
+	}
+
+	logger.Info("Sessions Revoked")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActRevoked{
+		Revoked: true,
+	})
+}
@@ -0,0 +1,215 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/env"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+	"github.com/sirupsen/logrus"
+)
+
+// EscalationAPI - structure holds handlers for the SLA escalation steps
+type EscalationAPI struct {
+	db  database.Database
+	env *env.Env
+}
+
+// Load help create a subrouter for the escalation steps
+func loadSLAEscalation(router *mux.Router, env *env.Env, authorizer *middlewares.Authorizer) {
+
+	api := &EscalationAPI{env: env, db: env.DB}
+
+	// escalation steps are managed with the SLAs
+	apiEndpoint := []apiEndpoint{
+
+		newAPIEndpoint("POST", "/sla_escalations", api.Create, authorizer.ObjAuthorize("ticket_sla", "create")),
+		newAPIEndpoint("GET", "/sla_escalations/{escalationID}", api.Get, authorizer.ObjAuthorize("ticket_sla", "view")), //retrieves an escalation step using its ID
+		newAPIEndpoint("GET", "/sla_escalations", api.List, authorizer.ObjAuthorize("ticket_sla", "list")),               //retrieves all the escalation steps
+
+		newAPIEndpoint("PATCH", "/sla_escalations/{escalationID}", api.Update, authorizer.ObjAuthorize("ticket_sla", "update")),  //updates an escalation step using its ID
+		newAPIEndpoint("DELETE", "/sla_escalations/{escalationID}", api.Delete, authorizer.ObjAuthorize("ticket_sla", "delete")), //delete an escalation step using its ID
+
+	}
+
+	for _, api := range apiEndpoint {
+
+		router.HandleFunc(api.Path, api.Func).Methods(api.Method)
+	}
+
+}
+
+// Create - Creates a new escalation step
+// POST - /sla_escalations
+func (api *EscalationAPI) Create(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	principal := middlewares.GetPrincipal(r)
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "sla_escalation.go -> EscalationAPI.Create()")
+
+	var escalation model.Escalation
+
+	if err := escalation.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := escalation.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	escalation.UserID = principal.UserID
+	logger = logger.WithField("escalation", *escalation.Name)
+	if err := api.db.CreateEscalation(ctx, &escalation); err != nil {
+		logger.WithError(err).Warn(err.Error())
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	createdEscalation, err := api.db.GetEscalationByID(ctx, &escalation.ID)
+	if err != nil {
+		logger.WithError(err).Warn(err.Error())
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, createdEscalation)
+}
+
+// Get -  retreives an escalation step
+// GET - /sla_escalations/{escalationID}
+func (api *EscalationAPI) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := logrus.WithField("func", "sla_escalation.go -> EscalationAPI.Get()")
+
+	vars := mux.Vars(r)
+	escalationID := model.EscalationID(vars["escalationID"])
+
+	escalation, err := api.db.GetEscalationByID(ctx, &escalationID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching escalation ID: %v", escalationID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	logger.WithField("EscalationID", escalationID).Debug("Get Escalation Complete")
+
+	utils.WriteJSON(w, http.StatusOK, escalation)
+}
+
+// Update - Updates an escalation step
+// PATCH - /sla_escalations/{escalationID}
+func (api *EscalationAPI) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "sla_escalation.go -> EscalationAPI.Update()")
+
+	vars := mux.Vars(r)
+	escalationID := model.EscalationID(vars["escalationID"])
+
+	principal := middlewares.GetPrincipal(r)
+
+	logger = logger.WithFields(logrus.Fields{
+		"EscalationID": escalationID,
+		"pricipal":     principal,
+	})
+
+	var escalation model.Escalation
+	if err := escalation.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	storedEscalation, err := api.db.GetEscalationByID(ctx, &escalationID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching escalation ID: %v", escalationID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	storedEscalation.UpdateValues(&escalation)
+	if err := storedEscalation.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.UpdateEscalation(ctx, storedEscalation); err != nil {
+		logger.WithError(err).Warn("Error updating escalation.")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Escalation Updated")
+
+	utils.WriteJSON(w, http.StatusOK, storedEscalation)
+}
+
+// List - List all the escalation steps
+// GET - /sla_escalations
+func (api *EscalationAPI) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "sla_escalation.go -> EscalationAPI.List()")
+
+	escalations, err := api.db.ListAllEscalations(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Retreiving all escalations")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Escalations Returned")
+
+	utils.WriteJSON(w, http.StatusOK, &escalations)
+}
+
+// Delete - Deletes an escalation step, the levels already reached by tickets are kept
+// DELETE - /sla_escalations/{escalationID}
+func (api *EscalationAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "sla_escalation.go -> EscalationAPI.Delete()")
+
+	vars := mux.Vars(r)
+	escalationID := model.EscalationID(vars["escalationID"])
+
+	logger = logger.WithField("EscalationID", escalationID)
+
+	deleted, err := api.db.DeleteEscalation(ctx, &escalationID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error deleting escalation: %v", escalationID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.WithField("Escalation Deleted", deleted).Info()
+
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
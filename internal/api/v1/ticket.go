@@ -4,15 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/mux"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/requests"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/env"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/email"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/sla"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
 	"github.com/sirupsen/logrus"
@@ -40,10 +43,24 @@ func loadTicketAPI(router *mux.Router, env *env.Env, authorizer *middlewares.Aut
 		newAPIEndpoint("PATCH", "/tickets/{ticketID}", ticketsAPI.Update, authorizer.ObjAuthorize("ticket", "update")),  //updates a ticket using its ID
 		newAPIEndpoint("DELETE", "/tickets/{ticketID}", ticketsAPI.Delete, authorizer.ObjAuthorize("ticket", "delete")), //delete a ticket using its ID
 
-		newAPIEndpoint("POST", "/tickets/{ticketID}/close", ticketsAPI.Close, authorizer.ObjAuthorize("ticket", "update")),               //adds a note to a ticket
-		newAPIEndpoint("GET", "/tickets/{ticketID}/notes", ticketsAPI.ListNotes, authorizer.ObjAuthorize("ticket", "update")),              //retrieves all the notes for a ticket
-		newAPIEndpoint("POST", "/tickets/{ticketID}/notes", ticketsAPI.AddNote, authorizer.ObjAuthorize("ticket", "update")),               //adds a note to a ticket
-		newAPIEndpoint("DELETE", "/tickets/{ticketID}/notes/{noteID}", ticketsAPI.DeleteNote, authorizer.ObjAuthorize("ticket", "update")), //deletes a note for a ticket
+		newAPIEndpoint("POST", "/tickets/{ticketID}/close", ticketsAPI.Close, authorizer.ObjAuthorize("ticket", "update")),                                //adds a note to a ticket
+		newAPIEndpoint("POST", "/tickets/{ticketID}/reopen", ticketsAPI.Reopen, authorizer.ObjAuthorize("ticket", "update")),                              //reopens a closed ticket
+		newAPIEndpoint("POST", "/tickets/{ticketID}/assign", ticketsAPI.Assign, authorizer.ObjAuthorize("ticket", "update")),                              //assigns a ticket to a user or the caller
+		newAPIEndpoint("POST", "/tickets/{ticketID}/unassign", ticketsAPI.Unassign, authorizer.ObjAuthorize("ticket", "update")),                          //removes the assignee of a ticket
+		newAPIEndpoint("GET", "/tickets/{ticketID}/assignments", ticketsAPI.ListAssignments, authorizer.ObjAuthorize("ticket", "view")),                   //retrieves the assignment history of a ticket
+		newAPIEndpoint("GET", "/tickets/{ticketID}/transitions", ticketsAPI.ListTransitions, authorizer.ObjAuthorize("ticket", "view")),                   //retrieves the statuses the caller can move the ticket to
+		newAPIEndpoint("GET", "/tickets/{ticketID}/activity", ticketsAPI.ListActivity, authorizer.ObjAuthorize("ticket", "view")),                         //retrieves the timeline of a ticket
+		newAPIEndpoint("GET", "/tickets/{ticketID}/users", ticketsAPI.ListUsers, authorizer.ObjAuthorize("ticket", "view")),                               //retrieves the people involved in a ticket
+		newAPIEndpoint("POST", "/tickets/{ticketID}/users", ticketsAPI.AddUser, authorizer.ObjAuthorize("ticket", "update")),                              //adds a collaborator or watcher to a ticket
+		newAPIEndpoint("DELETE", "/tickets/{ticketID}/users/{userID}", ticketsAPI.RemoveUser, authorizer.ObjAuthorize("ticket", "update")),                //removes a collaborator or watcher from a ticket
+		newAPIEndpoint("GET", "/users/{userID}/tickets", ticketsAPI.ListUserTickets, authorizer.ObjAuthorize("ticket", "list")),                           //retrieves the tickets a user is involved in
+		newAPIEndpoint("GET", "/tickets/{ticketID}/notes", ticketsAPI.ListNotes, authorizer.ObjAuthorize("ticket", "update")),                             //retrieves all the notes for a ticket
+		newAPIEndpoint("POST", "/tickets/{ticketID}/notes", ticketsAPI.AddNote, authorizer.ObjAuthorize("ticket", "update")),                              //adds a note to a ticket
+		newAPIEndpoint("DELETE", "/tickets/{ticketID}/notes/{noteID}", ticketsAPI.DeleteNote, authorizer.ObjAuthorize("ticket", "update")),                //deletes a note for a ticket
+		newAPIEndpoint("GET", "/tickets/{ticketID}/files", ticketsAPI.ListFiles, authorizer.ObjAuthorize("ticket", "update")),                             //retrieves the files of a ticket and of its notes
+		newAPIEndpoint("GET", "/tickets/{ticketID}/files/{fileID}", ticketsAPI.DownloadFile, authorizer.ObjAuthorize("ticket", "update")),                 //downloads a file of a ticket
+		newAPIEndpoint("GET", "/tickets/{ticketID}/notes/{noteID}/files/{fileID}", ticketsAPI.DownloadFile, authorizer.ObjAuthorize("ticket", "update")),  //downloads a file of a note
+		newAPIEndpoint("DELETE", "/tickets/{ticketID}/notes/{noteID}/files/{fileID}", ticketsAPI.DeleteFile, authorizer.ObjAuthorize("ticket", "update")), //deletes a file of a note
 	
 	
 		/* 
@@ -84,6 +101,13 @@ func (api *TicketAPI) Create(w http.ResponseWriter, r *http.Request) {
 	// Get the userID from the Token
 	ticket.UserID = principal.UserID
 
+	// new tickets start in the initial status of the workflow when none is sent
+	if ticket.StatusID == model.NilStatusID {
+		if initialStatus, err := api.db.GetInitialStatus(ctx); err == nil {
+			ticket.StatusID = initialStatus.ID
+		}
+	}
+
 	if err := ticket.Verify(); err != nil {
 		logger.WithError(err).Warn("Error with submitted values")
 		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
@@ -99,17 +123,12 @@ func (api *TicketAPI) Create(w http.ResponseWriter, r *http.Request) {
 		"Ticket Description": *ticket.Description,
 	})
 
-	// Get the SLA
-	sla, err := api.db.GetSLAByID(ctx, &ticket.SLAID)
-
-	if err != nil {
+	// set the first response and resolution deadlines using the SLA targets for the priority
+	if err := api.applySLA(ctx, &ticket, model.NilStatusID, time.Now()); err != nil {
 		logger.WithError(err).Warn("")
 		utils.WriteError(w, http.StatusConflict, err, nil)
 		return
 	}
-
-	// set the due date using the grace period in the SLA
-	ticket.DueDate = func() *time.Time { t := time.Now(); t = t.Add(time.Duration(*sla.GracePeriod) * time.Hour); return &t }()
 	// set the userID
 	ticket.UserID = principal.UserID
 	if err := api.db.CreateTicket(ctx, &ticket); err != nil {
@@ -117,19 +136,16 @@ func (api *TicketAPI) Create(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusConflict, err, nil)
 		return
 	}
+	api.notify(ctx, logger, email.EventCreated, ticket.ID, email.Details{ActorID: principal.UserID})
 
-	createdTicket, err := api.db.GetTicketByID(ctx, &ticket.ID)
+	createdTicket, err := api.db.GetTicketDetails(ctx, &ticket.ID)
 	if err != nil {
 		logger.WithError(err).Error()
 		utils.WriteError(w, http.StatusConflict, err, nil)
 		return
 	}
 
-	if err := api.getTicketProps(ctx, createdTicket); err != nil {
-		logger.WithError(err).Error()
-		utils.WriteError(w, http.StatusNotFound, err, nil)
-		return
-	}
+	trimTicketProps(createdTicket)
 	utils.WriteJSON(w, http.StatusCreated, &createdTicket)
 }
 
@@ -142,16 +158,31 @@ func (api *TicketAPI) Get(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	ticket, err := api.db.GetTicketByID(ctx, &ticketID)
+	ticket, err := api.db.GetTicketDetails(ctx, &ticketID)
 	if err != nil {
 		errMessage := fmt.Sprintf("Error fetching ticket TicketID: %v", ticketID)
 		logger.WithError(err).Warn(errMessage)
 		utils.WriteError(w, http.StatusNotFound, err.Error(), nil)
 		return
 	}
-	if err := api.getTicketProps(ctx, ticket); err != nil {
-		logger.WithError(err).Error()
-		utils.WriteError(w, http.StatusNotFound, err, nil)
+	trimTicketProps(ticket)
+
+	// the time left is counted in the working hours of the SLA calendar
+	if ticket.SLA != nil {
+		calendar, err := sla.LoadCalendar(ctx, api.db, ticket.SLA.CalendarID)
+		if err != nil {
+			logger.WithError(err).Warn("Loading the SLA calendar")
+		}
+		if remaining := sla.Remaining(ticket, time.Now(), calendar); remaining != nil {
+			minutes := int(remaining.Minutes())
+			ticket.SLARemainingMinutes = &minutes
+		}
+	}
+
+	ticket.Users, err = api.db.ListTicketUsers(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket users")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the ticket users", nil)
 		return
 	}
 	logger.WithField("TicketID", ticketID).Debug("Get Ticket Complete")
@@ -159,8 +190,8 @@ func (api *TicketAPI) Get(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusOK, ticket)
 }
 
-// List - List all the tickets
-// GET - /tickets
+// List - List the tickets matching the query filters
+// GET - /tickets?status_id=&priority_id=&state=open&sort=deadline&order=asc&limit=50&offset=0
 // Permission Admin
 func (api *TicketAPI) List(w http.ResponseWriter, r *http.Request) {
 	// Show function name in error logs to track errors faster
@@ -171,10 +202,18 @@ func (api *TicketAPI) List(w http.ResponseWriter, r *http.Request) {
 	logger = logger.WithFields(logrus.Fields{
 		"pricipal": principal,
 	})
-	log.Printf("Query => %+v\n", r.URL.Query())
 	ctx := r.Context()
 
-	tickets, err := api.db.ListAllTickets(ctx)
+	filter, err := requests.TicketFilter(r.URL.Query())
+	if err != nil {
+		logger.WithError(err).Warn("Error with submitted query")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted query", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tickets, total, err := api.db.ListTickets(ctx, filter)
 	if err != nil {
 		errMessage := fmt.Sprintf("Error retreiving all the tickets")
 		logger.WithError(err).Warn(errMessage)
@@ -182,17 +221,17 @@ func (api *TicketAPI) List(w http.ResponseWriter, r *http.Request) {
 		return
 
 	}
-	// add all the properties for the tickets
+	// remove the ids already present in the ticket properties
 	for index := range tickets {
-		if err := api.getTicketProps(ctx, tickets[index]); err != nil {
-			logger.WithError(err).Error()
-			utils.WriteError(w, http.StatusNotFound, err, nil)
-			return
-		}
+		trimTicketProps(tickets[index])
 	}
 	logger.Info("Tickets List Returned")
 
-	utils.WriteJSON(w, http.StatusOK, &tickets)
+	page := responses.Page{Data: tickets, Total: total}
+	if next := filter.Offset + len(tickets); next < total {
+		page.NextOffset = next
+	}
+	utils.WriteJSON(w, http.StatusOK, &page)
 
 }
 
@@ -232,18 +271,79 @@ func (api *TicketAPI) Update(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusNotFound, err, nil)
 		return
 	}
+	// the status workflow decides which statuses the ticket can move to
+	var transition *model.Transition
+	if ticket.StatusID != model.NilStatusID && ticket.StatusID != storedticket.StatusID {
+		if transition, err = api.checkTransition(ctx, principal, storedticket, &ticket); err != nil {
+			logger.WithError(err).Warn("Changing ticket status")
+			if apiError, ok := err.(apiErr.APIError); ok {
+				utils.WriteError(w, apiError.Code, apiError, nil)
+				return
+			}
+			utils.WriteError(w, http.StatusInternalServerError, "Error checking the status workflow", nil)
+			return
+		}
+	}
+
+	previous := *storedticket
 	storedticket.UpdateValues(&ticket)
 	logger = logger.WithField("TicketID", ticketID)
 
-	err = api.db.UpdateTicket(ctx, storedticket)
+	// the deadlines depend on the SLA, the priority and the time spent in statuses that pause the SLA
+	if storedticket.SLAID != previous.SLAID || storedticket.PriorityID != previous.PriorityID || storedticket.StatusID != previous.StatusID {
+		if err := api.applySLA(ctx, storedticket, previous.StatusID, time.Now()); err != nil {
+			logger.WithError(err).Warn("Retrieving the SLA targets")
+			utils.WriteError(w, http.StatusNotFound, err, nil)
+			return
+		}
+	}
+
+	// the status change and the closing of the ticket are saved together
+	changes := previous.Changes(storedticket, principal.UserID)
+	closes := transition != nil && transition.Closes() && storedticket.ClosedAt == nil
+	if closes {
+		closingRemark := model.ClosingRemark{TicketID: ticketID, UserID: principal.UserID, Remark: ticket.Note}
+		err = api.db.UpdateAndCloseTicket(ctx, storedticket, &closingRemark, changes...)
+	} else {
+		err = api.db.UpdateTicket(ctx, storedticket, changes...)
+	}
 	if err != nil {
 		errMessage := fmt.Sprintf("Error updating ticket TicketID: %v", ticketID)
 		logger.WithError(err).Warn(errMessage)
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
 		utils.WriteError(w, http.StatusConflict, err.Error(), nil)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, storedticket)
+	if ticket.Note != nil && len(*ticket.Note) != 0 {
+		note := model.Note{Note: ticket.Note, TicketID: ticketID, UserID: principal.UserID}
+		if err := api.db.CreateNote(ctx, &note); err != nil {
+			logger.WithError(err).Warn("Saving the status change note")
+		} else if model.IsAgentType(principal.Type) {
+			if _, err := api.db.MarkFirstResponse(ctx, &ticketID); err != nil {
+				logger.WithError(err).Warn("Marking the first response")
+			}
+		}
+	}
+
+	if closes {
+		logger.Info("Ticket Closed by the status workflow")
+		api.notify(ctx, logger, email.EventClosed, ticketID, email.Details{ActorID: principal.UserID, Remark: stringValue(ticket.Note)})
+	} else if storedticket.StatusID != previous.StatusID {
+		api.notify(ctx, logger, email.EventStatusChanged, ticketID, email.Details{ActorID: principal.UserID})
+	}
+
+	updatedTicket, err := api.db.GetTicketByID(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving the updated ticket")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the updated ticket", nil)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, updatedTicket)
 
 }
 
@@ -264,7 +364,7 @@ func (api *TicketAPI) Delete(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	deleted, err := api.db.DeleteTicket(ctx, &ticketID)
+	deleted, err := api.db.DeleteTicket(ctx, &ticketID, model.NewActivity(ticketID, principal.UserID, model.ActivityDeleted))
 	if err != nil {
 		errMessage := fmt.Sprintf("Error deleting ticket: %v", ticketID)
 		logger.WithError(err).Warn(errMessage)
@@ -273,7 +373,6 @@ func (api *TicketAPI) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if deleted {
-
 		logger.Info("Ticket Deleted")
 	}
 
@@ -310,6 +409,17 @@ func (api *TicketAPI) ListNotes(w http.ResponseWriter, r *http.Request) {
 
 	}
 
+	files, err := api.db.ListTicketFiles(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket files")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the ticket files", nil)
+		return
+	}
+	noteFiles := map[model.NoteID][]*model.NoteFile{}
+	for _, file := range files {
+		noteFiles[file.NoteID] = append(noteFiles[file.NoteID], file)
+	}
+
 	for index := range notes {
 		err = api.getNoteProps(ctx, notes[index])
 		if err != nil {
@@ -317,6 +427,7 @@ func (api *TicketAPI) ListNotes(w http.ResponseWriter, r *http.Request) {
 			utils.WriteError(w, http.StatusInternalServerError, "Error retreiving all the tickets", nil)
 			return
 		}
+		notes[index].Files = noteFiles[notes[index].ID]
 	}
 
 	logger.Info("Ticket Notes Returned")
@@ -339,8 +450,9 @@ func (api *TicketAPI) AddNote(w http.ResponseWriter, r *http.Request) {
 		"TicketID": ticketID,
 	})
 
-	var note model.Note
-	if err := note.Decode(r.Body); err != nil {
+	// the files are sent with the note as a multipart form
+	note, uploads, err := api.readNote(w, r)
+	if err != nil {
 		logger.WithError(err).Warn("could not decode parameters")
 		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
 			"error": err.Error(),
@@ -361,7 +473,19 @@ func (api *TicketAPI) AddNote(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	if err := api.db.CreateNote(ctx, &note); err != nil {
+	files, err := api.saveUploads(ctx, uploads, principal.UserID)
+	if err != nil {
+		logger.WithError(err).Warn("Saving the note files")
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	if err := api.db.CreateNoteWithFiles(ctx, note, files); err != nil {
+		api.deleteFiles(files)
 
 		logger.WithError(err).Warn("Error creating note")
 
@@ -369,6 +493,18 @@ func (api *TicketAPI) AddNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// public notes are mailed to the customer
+	if note.IsPublic() {
+		api.notify(ctx, logger, email.EventReply, ticketID, email.Details{ActorID: principal.UserID, NoteID: note.ID, Note: *note.Note})
+	}
+
+	// the first note from an agent is the first response of the SLA
+	if model.IsAgentType(principal.Type) {
+		if _, err := api.db.MarkFirstResponse(ctx, &ticketID); err != nil {
+			logger.WithError(err).Warn("Marking the first response")
+		}
+	}
+
 	createdNote, err := api.db.GetNoteByID(ctx, &note.ID)
 	if err != nil {
 		logger.WithError(err).Warn("Error creating note")
@@ -382,14 +518,16 @@ func (api *TicketAPI) AddNote(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving all the tickets", nil)
 		return
 	}
+	createdNote.Files = files
 	logger.Info("Note created")
 	utils.WriteJSON(w, http.StatusCreated, &createdNote)
 }
 
 // Close - closes a ticket
+// POST - /tickets/{ticketID}/close
 func (api *TicketAPI) Close(w http.ResponseWriter, r *http.Request) {
 
-	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.DeleteNote()")
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.Close()")
 
 	principal := middlewares.GetPrincipal(r)
 
@@ -423,11 +561,16 @@ func (api *TicketAPI) Close(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	
-	err := api.db.CreateClosingRemark(ctx, &closingRemark)
-	if err != nil {
-		logger.WithError(err).Error("Creating closingRemark")
-		utils.WriteError(w, http.StatusConflict, err.Error(), nil)
+	if _, err := api.db.GetTicketByID(ctx, &ticketID); err != nil {
+		logger.WithError(err).Warn("Retrieving ticket")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	// the remark and closed_at are saved together
+	if err := api.db.CloseTicket(ctx, &closingRemark); err != nil {
+		logger.WithError(err).Error("Closing ticket")
+		utils.WriteError(w, http.StatusConflict, err, nil)
 		return
 	}
 
@@ -437,15 +580,76 @@ func (api *TicketAPI) Close(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusConflict, err.Error(), nil)
 		return
 	}
-	// update the closed value on the database
-	_, err = api.db.CloseTicket(ctx, &ticketID)
+
+	details := email.Details{ActorID: principal.UserID, Remark: stringValue(createdClosingRemark.Remark)}
+	if createdClosingRemark.CauseID != model.NilCauseID {
+		if cause, err := api.db.GetCauseByID(ctx, &createdClosingRemark.CauseID); err == nil {
+			details.Cause = stringValue(cause.Name)
+		}
+	}
+	api.notify(ctx, logger, email.EventClosed, ticketID, details)
+
+	logger.Info("Ticket Closed")
+	utils.WriteJSON(w, http.StatusCreated, &createdClosingRemark)
+
+}
+
+// Reopen - opens a closed ticket again, a reason is required
+// POST - /tickets/{ticketID}/reopen
+func (api *TicketAPI) Reopen(w http.ResponseWriter, r *http.Request) {
+
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.Reopen()")
+
+	principal := middlewares.GetPrincipal(r)
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"pricipal": principal,
+		"TicketID": ticketID,
+	})
+
+	ctx := r.Context()
+
+	var reopening model.Reopening
+	if err := reopening.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	reopening.UserID = principal.UserID
+	reopening.TicketID = ticketID
+	if err := reopening.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.ReopenTicket(ctx, &reopening); err != nil {
+		logger.WithError(err).Warn("Reopening ticket")
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	reopenedTicket, err := api.db.GetTicketDetails(ctx, &ticketID)
 	if err != nil {
-		logger.WithError(err).Warn("Updating closed at to now")
-		utils.WriteError(w, http.StatusConflict, err.Error(), nil)
+		logger.WithError(err).Warn("Retrieving reopened ticket")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
 		return
 	}
-	utils.WriteJSON(w, http.StatusCreated, &createdClosingRemark)
+	trimTicketProps(reopenedTicket)
 
+	logger.Info("Ticket Reopened")
+	utils.WriteJSON(w, http.StatusOK, reopenedTicket)
 }
 
 // DeleteNote - deletes note from a ticekt
@@ -467,7 +671,9 @@ func (api *TicketAPI) DeleteNote(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	deleted, err := api.db.DeleteTicketNote(ctx, &ticketID, &noteID)
+	noteActivity := model.NewActivity(ticketID, principal.UserID, model.ActivityNoteDeleted)
+	noteActivity.NoteID = noteID
+	deleted, err := api.db.DeleteTicketNote(ctx, &ticketID, &noteID, noteActivity)
 	if err != nil {
 		errMessage := fmt.Sprintf("Error deleting ticket note: %v", noteID)
 		logger.WithError(err).Warn(errMessage)
@@ -476,7 +682,6 @@ func (api *TicketAPI) DeleteNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if deleted {
-
 		logger.Info("Note Deleted")
 	}
 
@@ -485,94 +690,100 @@ func (api *TicketAPI) DeleteNote(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (api *TicketAPI) getTicketProps(ctx context.Context, ticket *model.Ticket) (err error) {
+// trimTicketProps - removes the ids that are already present in the joined ticket properties
+func trimTicketProps(ticket *model.Ticket) {
 
-	ticket.Category, err = api.env.DB.GetCategoryByID(ctx, &ticket.CategoryID)
-	if err != nil {
-		logrus.WithError(err).Warn("Error category of ticket")
-		return
+	if ticket.Category != nil {
+		ticket.CategoryID = model.NilCategoryID
 	}
-	ticket.Priority, err = api.db.GetPriorityByID(ctx, &ticket.PriorityID)
-	if err != nil {
-		logrus.WithError(err).Warn("Error priority of ticket")
-		return
+	if ticket.Priority != nil {
+		ticket.PriorityID = model.NilPriorityID
 	}
-	ticket.Status, err = api.db.GetStatusByID(ctx, &ticket.StatusID)
-	if err != nil {
-		logrus.WithError(err).Warn("Error status of ticket")
-		return
+	if ticket.Status != nil {
+		ticket.StatusID = model.NilStatusID
+	}
+	if ticket.SLA != nil {
+		ticket.SLAID = model.NilSLAID
+	}
+	if ticket.Source != nil {
+		ticket.SourceID = model.NilSourceID
+	}
+	if ticket.CreatedBy != nil {
+		ticket.UserID = model.NilUserID
+	}
+	if ticket.AssignedTo != nil {
+		ticket.AssignedID = model.NilUserID
+	}
+}
+
+// applySLA - sets the deadlines of the ticket from the SLA targets of its priority and the SLA calendar,
+// the time spent in statuses that pause the SLA is added back. held is the status the ticket was in until now.
+func (api *TicketAPI) applySLA(ctx context.Context, ticket *model.Ticket, held model.StatusID, now time.Time) error {
+
+	start := now
+	if ticket.CreatedAt != nil {
+		start = *ticket.CreatedAt
 	}
-	ticket.SLA, err = api.db.GetSLAByID(ctx, &ticket.SLAID)
+
+	target, err := api.db.GetSLATarget(ctx, &ticket.SLAID, &ticket.PriorityID)
 	if err != nil {
-		logrus.WithError(err).Warn("Error SLA of ticket")
-		return
+		return err
 	}
-	ticket.Source, err = api.db.GetSourceByID(ctx, &ticket.SourceID)
+	calendar, err := sla.LoadCalendar(ctx, api.db, target.CalendarID)
 	if err != nil {
-		logrus.WithError(err).Warn("Error source of ticket")
-		return
+		return err
 	}
+	sla.Apply(ticket, start, target, calendar)
 
-	ticket.CreatedBy, err = api.db.GetUserByID(ctx, &ticket.UserID)
+	statuses, err := api.db.ListAllStatus(ctx)
 	if err != nil {
-		logrus.WithError(err).Warn("Error user id of the person who created the ticket")
-		return
-	}
-	// Remove all the unecessary
-	// category
-	ticket.CategoryID = model.NilCategoryID
-	ticket.Category.Description = nil
-	ticket.Category.Weight = nil
-	ticket.Category.CreatedAt = nil
-	ticket.Category.UpdatedAt = nil
-	ticket.Category.DeletedAt = nil
-
-	ticket.PriorityID = model.NilPriorityID
-	ticket.Priority.Weight = nil
-	ticket.Priority.CreatedAt = nil
-	ticket.Priority.UpdatedAt = nil
-	ticket.Priority.DeletedAt = nil
-
-	ticket.StatusID = model.NilStatusID
-	ticket.Status.Weight = nil
-	ticket.Status.CreatedAt = nil
-	ticket.Status.UpdatedAt = nil
-	ticket.Status.DeletedAt = nil
-
-	ticket.SLAID = model.NilSLAID
-	ticket.SLA.Weight = nil
-	ticket.SLA.CreatedAt = nil
-	ticket.SLA.UpdatedAt = nil
-	ticket.SLA.DeletedAt = nil
-
-	ticket.SourceID = model.NilSourceID
-	ticket.Source.Weight = nil
-	ticket.Source.CreatedAt = nil
-	ticket.Source.UpdatedAt = nil
-	ticket.Source.DeletedAt = nil
-
-	ticket.UserID = model.NilUserID
-	ticket.CreatedBy.Name = func() *string {
-		s := fmt.Sprintf("%s %s", *ticket.CreatedBy.Firstname, *ticket.CreatedBy.Lastname)
-		return &s
-	}()
-	ticket.CreatedBy.Firstname = nil
-	ticket.CreatedBy.Lastname = nil
-	ticket.CreatedBy.Email = nil
-	ticket.CreatedBy.Type = nil
-	ticket.CreatedBy.RoleID = model.NilRoleID
-	ticket.CreatedBy.PasswordHash = nil
-	ticket.CreatedBy.IsActive = nil
-	ticket.CreatedBy.IsSystem = nil
-	ticket.CreatedBy.CreatedAt = nil
-	ticket.CreatedBy.UpdatedAt = nil
-	ticket.CreatedBy.DeletedAt = nil
-
-	return
+		return err
+	}
+	pausing := map[model.StatusID]bool{}
+	for _, status := range statuses {
+		pausing[status.ID] = status.Pauses()
+	}
+
+	// the paused time is worked out again from the whole status history
+	history := []*model.Activity{}
+	if ticket.ID != model.NilTicketID {
+		if history, err = api.db.ListTicketActivities(ctx, &ticket.ID); err != nil {
+			return err
+		}
+	}
+	// while the ticket stays paused the deadlines are kept as they were when the pause started
+	until := now
+	if pausing[ticket.StatusID] && ticket.SLAPausedAt != nil {
+		until = *ticket.SLAPausedAt
+	}
+	paused := sla.Paused(start, until, held, history, pausing, calendar)
+	sla.Extend(ticket, paused, calendar)
+
+	minutes := int(paused.Minutes())
+	ticket.SLAPausedMinutes = &minutes
+	if !pausing[ticket.StatusID] {
+		ticket.SLAPausedAt = nil
+	} else if ticket.SLAPausedAt == nil {
+		ticket.SLAPausedAt = &now
+	}
+	return nil
+}
+
+// notify - queues the mails of a ticket event, the request does not fail when they cannot be queued
+func (api *TicketAPI) notify(ctx context.Context, logger *logrus.Entry, event string, ticketID model.TicketID, details email.Details) {
+	if err := api.env.Outbound.Notify(ctx, event, ticketID, details); err != nil {
+		logger.WithError(err).WithField("Event", event).Warn("Queueing the ticket mails")
+	}
 }
 
 func (api *TicketAPI) getNoteProps(ctx context.Context, note *model.Note) (err error) {
 
+	// notes added by the system, escalations and mail replies, have no author
+	if note.UserID == model.NilUserID {
+		note.TicketID = model.NilTicketID
+		return nil
+	}
+
 	note.CreatedBy, err = api.db.GetUserByID(ctx, &note.UserID)
 	if err != nil {
 		logrus.WithError(err).Warn("Error fetching the user who created the ticket")
@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ListActivity - returns the timeline of a ticket with the notes in between the changes
+// GET - /tickets/{ticketID}/activity
+func (api *TicketAPI) ListActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.ListActivity()")
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+
+	logger = logger.WithField("TicketID", ticketID)
+
+	activities, err := api.db.ListTicketActivities(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket activities")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the ticket activity", nil)
+		return
+	}
+
+	logger.Info("Ticket Activity Returned")
+	utils.WriteJSON(w, http.StatusOK, &activities)
+}
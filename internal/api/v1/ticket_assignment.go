@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/email"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Assign - assigns a ticket to the user in the body, or to the caller when no user is sent
+// POST - /tickets/{ticketID}/assign
+func (api *TicketAPI) Assign(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.Assign()")
+
+	principal := middlewares.GetPrincipal(r)
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"pricipal": principal,
+		"TicketID": ticketID,
+	})
+
+	var assignment model.Assignment
+	if err := assignment.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// take it - the caller becomes the assignee
+	if assignment.AssignedID == model.NilUserID {
+		assignment.AssignedID = principal.UserID
+	}
+	assignment.TicketID = ticketID
+	assignment.UserID = principal.UserID
+	if err := assignment.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	assignee, err := api.db.GetUserByID(ctx, &assignment.AssignedID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving assignee")
+		utils.WriteError(w, http.StatusNotFound, apiErr.ErrNotExist("Assignee"), nil)
+		return
+	}
+	if !assignee.CanBeAssigned() {
+		utils.WriteError(w, http.StatusBadRequest, apiErr.ErrInvalidAssignee, nil)
+		return
+	}
+
+	ticket, err := api.db.GetTicketByID(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+	if ticket.AssignedID == assignment.AssignedID {
+		utils.WriteError(w, http.StatusConflict, apiErr.ErrTicketAlreadyAssigned, nil)
+		return
+	}
+
+	if err := api.db.AssignTicket(ctx, &assignment); err != nil {
+		logger.WithError(err).Warn("Assigning ticket")
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	assignedTicket, err := api.db.GetTicketDetails(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving assigned ticket")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+	trimTicketProps(assignedTicket)
+
+	api.notify(ctx, logger, email.EventAssigned, ticketID, email.Details{ActorID: principal.UserID})
+
+	logger.WithField("Assignee", assignment.AssignedID).Info("Ticket Assigned")
+	utils.WriteJSON(w, http.StatusOK, assignedTicket)
+}
+
+// Unassign - removes the assignee of a ticket
+// POST - /tickets/{ticketID}/unassign
+func (api *TicketAPI) Unassign(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.Unassign()")
+
+	principal := middlewares.GetPrincipal(r)
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"pricipal": principal,
+		"TicketID": ticketID,
+	})
+
+	ticket, err := api.db.GetTicketByID(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+	if ticket.AssignedID == model.NilUserID {
+		utils.WriteError(w, http.StatusConflict, apiErr.ErrTicketNotAssigned, nil)
+		return
+	}
+
+	assignment := model.Assignment{
+		TicketID: ticketID,
+		UserID:   principal.UserID,
+	}
+	if err := api.db.AssignTicket(ctx, &assignment); err != nil {
+		logger.WithError(err).Warn("Unassigning ticket")
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	unassignedTicket, err := api.db.GetTicketDetails(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving unassigned ticket")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+	trimTicketProps(unassignedTicket)
+
+	logger.Info("Ticket Unassigned")
+	utils.WriteJSON(w, http.StatusOK, unassignedTicket)
+}
+
+// ListAssignments - returns the assignment history of a ticket
+// GET - /tickets/{ticketID}/assignments
+func (api *TicketAPI) ListAssignments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.ListAssignments()")
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+
+	logger = logger.WithField("TicketID", ticketID)
+
+	assignments, err := api.db.ListTicketAssignments(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket assignments")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the ticket assignments", nil)
+		return
+	}
+
+	logger.Info("Ticket Assignments Returned")
+	utils.WriteJSON(w, http.StatusOK, &assignments)
+}
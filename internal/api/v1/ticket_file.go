@@ -0,0 +1,283 @@
+package v1
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/blob"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxUploadFiles - the files a note can be created with
+	maxUploadFiles = 10
+	// uploadMemory - the part of an upload kept in memory, the rest goes to temporary files
+	uploadMemory = 8 << 20
+)
+
+// readNote - the note of a JSON body, or of a multipart form with its files under "files"
+func (api *TicketAPI) readNote(w http.ResponseWriter, r *http.Request) (*model.Note, []*multipart.FileHeader, error) {
+
+	var note model.Note
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if err := note.Decode(r.Body); err != nil {
+			return nil, nil, err
+		}
+		return &note, nil, nil
+	}
+
+	if maxSize := api.env.FileLimits.MaxSize; maxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxUploadFiles*maxSize+1<<20)
+	}
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		return nil, nil, err
+	}
+
+	text := r.FormValue("note")
+	note.Note = &text
+	if value := r.FormValue("public"); value != "" {
+		public, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		note.Public = &public
+	}
+	return &note, r.MultipartForm.File["files"], nil
+}
+
+// saveUploads - checks the uploaded files against the limits and writes them to the file store
+func (api *TicketAPI) saveUploads(ctx context.Context, uploads []*multipart.FileHeader, userID model.UserID) ([]*model.NoteFile, error) {
+
+	if len(uploads) == 0 {
+		return nil, nil
+	}
+	if api.env.Files == nil {
+		return nil, apiErr.ErrNoFileStore
+	}
+	if len(uploads) > maxUploadFiles {
+		return nil, apiErr.ErrTooManyFiles
+	}
+
+	files := []*model.NoteFile{}
+	for _, upload := range uploads {
+		file, err := api.saveUpload(ctx, upload, userID)
+		if err != nil {
+			api.deleteFiles(files)
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func (api *TicketAPI) saveUpload(ctx context.Context, upload *multipart.FileHeader, userID model.UserID) (*model.NoteFile, error) {
+
+	content, err := upload.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	// the type sent by the browser is only a guess, the generic one is sniffed from the content
+	mimeType := upload.Header.Get("Content-Type")
+	if mimeType == "" || strings.HasPrefix(mimeType, "application/octet-stream") {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(content, head)
+		mimeType = http.DetectContentType(head[:n])
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+
+	switch api.env.FileLimits.Check(mimeType, upload.Size) {
+	case blob.ErrTooLarge:
+		return nil, apiErr.ErrFileTooLarge
+	case blob.ErrType:
+		return nil, apiErr.ErrFileType
+	}
+
+	key := blob.NewKey("tickets")
+	if err := api.env.Files.Put(ctx, key, content, upload.Size); err != nil {
+		return nil, err
+	}
+	return &model.NoteFile{
+		Filename:   uploadName(upload.Filename),
+		MimeType:   mimeType,
+		Size:       upload.Size,
+		StorageKey: key,
+		UserID:     userID,
+	}, nil
+}
+
+// deleteFiles - removes the contents of files that were not recorded
+func (api *TicketAPI) deleteFiles(files []*model.NoteFile) {
+	for _, file := range files {
+		if err := api.env.Files.Delete(context.Background(), file.StorageKey); err != nil {
+			logrus.WithError(err).WithField("Key", file.StorageKey).Warn("Deleting a ticket file")
+		}
+	}
+}
+
+// uploadName - the name of an uploaded file without the directories some browsers send
+func uploadName(name string) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		return "attachment"
+	}
+	return name
+}
+
+// ListFiles - returns the files of a ticket and of its notes
+// GET - /tickets/{ticketID}/files
+func (api *TicketAPI) ListFiles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.ListFiles()")
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+
+	logger = logger.WithField("TicketID", ticketID)
+
+	files, err := api.db.ListTicketFiles(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket files")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the ticket files", nil)
+		return
+	}
+
+	logger.Info("Ticket Files Returned")
+	utils.WriteJSON(w, http.StatusOK, &files)
+}
+
+// DownloadFile - sends the content of a file, the browsers save it instead of showing it
+// GET - /tickets/{ticketID}/files/{fileID}
+// GET - /tickets/{ticketID}/notes/{noteID}/files/{fileID}
+func (api *TicketAPI) DownloadFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.DownloadFile()")
+
+	file, ok := api.ticketFile(w, r, logger)
+	if !ok {
+		return
+	}
+	if api.env.Files == nil {
+		utils.WriteError(w, http.StatusServiceUnavailable, apiErr.ErrNoFileStore, nil)
+		return
+	}
+
+	content, err := api.env.Files.Get(ctx, file.StorageKey)
+	if err == blob.ErrNotExist {
+		logger.WithField("FileID", file.ID).Warn("The content of the file is missing")
+		utils.WriteError(w, http.StatusNotFound, apiErr.ErrNotExist("File"), nil)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Reading the file")
+		utils.WriteError(w, http.StatusInternalServerError, "Error reading the file", nil)
+		return
+	}
+	defer content.Close()
+
+	mimeType := file.MimeType
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	w.Header().Set("Content-Disposition", contentDisposition(file.Filename))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		logger.WithError(err).Info("Sending the file")
+	}
+}
+
+// DeleteFile - removes a file of a note, its content is deleted from the file store
+// DELETE - /tickets/{ticketID}/notes/{noteID}/files/{fileID}
+func (api *TicketAPI) DeleteFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.DeleteFile()")
+
+	file, ok := api.ticketFile(w, r, logger)
+	if !ok {
+		return
+	}
+
+	deleted, err := api.db.DeleteTicketFile(ctx, &file.TicketID, &file.ID)
+	if err != nil {
+		logger.WithError(err).Warn("Deleting the ticket file")
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "Error deleting the file", nil)
+		return
+	}
+	if deleted && api.env.Files != nil {
+		api.deleteFiles([]*model.NoteFile{file})
+	}
+
+	logger.Info("File Deleted")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
+
+// ticketFile - the file of the route, it has to belong to the note when the route has one
+func (api *TicketAPI) ticketFile(w http.ResponseWriter, r *http.Request, logger *logrus.Entry) (*model.NoteFile, bool) {
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+	noteID := model.NoteID(vars["noteID"])
+	fileID := model.NoteFileID(vars["fileID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"TicketID": ticketID,
+		"NoteID":   noteID,
+		"FileID":   fileID,
+	})
+
+	file, err := api.db.GetTicketFile(r.Context(), &ticketID, &fileID)
+	if err == nil && noteID != model.NilNoteID && file.NoteID != noteID {
+		err = apiErr.ErrNotExist("File")
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving the ticket file")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return nil, false
+	}
+	return file, true
+}
+
+// contentDisposition - the attachment header with the file name, the names that are not ASCII are
+// sent encoded
+func contentDisposition(filename string) string {
+	if disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); disposition != "" {
+		return disposition
+	}
+	ascii := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, filename)
+	return `attachment; filename="` + ascii + `"`
+}
@@ -34,6 +34,10 @@ func loadSLA(router *mux.Router, env *env.Env, authorizer *middlewares.Authorize
 		newAPIEndpoint("PATCH", "/ticket_slas/{SLAID}", api.Update, authorizer.ObjAuthorize("ticket_sla", "update")),  //updates a sla using its ID
 		newAPIEndpoint("DELETE", "/ticket_slas/{SLAID}", api.Delete, authorizer.ObjAuthorize("ticket_sla", "delete")), //delete a sla using its ID
 
+		newAPIEndpoint("GET", "/ticket_slas/{SLAID}/targets", api.ListTargets, authorizer.ObjAuthorize("ticket_sla", "view")),                    //retrieves the per priority targets of a sla
+		newAPIEndpoint("PUT", "/ticket_slas/{SLAID}/targets/{priorityID}", api.SaveTarget, authorizer.ObjAuthorize("ticket_sla", "update")),      //sets the targets of a sla for a priority
+		newAPIEndpoint("DELETE", "/ticket_slas/{SLAID}/targets/{priorityID}", api.DeleteTarget, authorizer.ObjAuthorize("ticket_sla", "update")), //removes the targets of a sla for a priority
+
 	}
 
 	for _, api := range apiEndpoint {
@@ -103,6 +107,13 @@ func (api *SLAAPI) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sla.Targets, err = api.db.ListSLATargets(ctx, &SLAID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving sla targets")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
 	logger.WithField("SLAID", SLAID).Debug("Get SLA Complete")
 
 	utils.WriteJSON(w, http.StatusOK, sla)
@@ -234,3 +245,100 @@ func (api *SLAAPI) Delete(w http.ResponseWriter, r *http.Request) {
 	})
 
 }
+
+// ListTargets - returns the per priority targets of an SLA
+// GET - /ticket_slas/{SLAID}/targets
+func (api *SLAAPI) ListTargets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := logrus.WithField("func", "sla.go -> SLAAPI.ListTargets()")
+
+	vars := mux.Vars(r)
+	SLAID := model.SLAID(vars["SLAID"])
+
+	targets, err := api.db.ListSLATargets(ctx, &SLAID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving sla targets")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.WithField("SLAID", SLAID).Info("SLA Targets Returned")
+
+	utils.WriteJSON(w, http.StatusOK, &targets)
+}
+
+// SaveTarget - sets the first response and resolution times of an SLA for a priority
+// PUT - /ticket_slas/{SLAID}/targets/{priorityID}
+func (api *SLAAPI) SaveTarget(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := logrus.WithField("func", "sla.go -> SLAAPI.SaveTarget()")
+
+	vars := mux.Vars(r)
+	SLAID := model.SLAID(vars["SLAID"])
+	priorityID := model.PriorityID(vars["priorityID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"SLAID":      SLAID,
+		"PriorityID": priorityID,
+	})
+
+	var target model.SLATarget
+	if err := target.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	target.SLAID = SLAID
+	target.PriorityID = priorityID
+	if err := target.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.SaveSLATarget(ctx, &target); err != nil {
+		logger.WithError(err).Warn("Saving sla target")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("SLA Target Saved")
+
+	utils.WriteJSON(w, http.StatusOK, &target)
+}
+
+// DeleteTarget - removes the targets of an SLA for a priority, the SLA defaults apply again
+// DELETE - /ticket_slas/{SLAID}/targets/{priorityID}
+func (api *SLAAPI) DeleteTarget(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := logrus.WithField("func", "sla.go -> SLAAPI.DeleteTarget()")
+
+	vars := mux.Vars(r)
+	SLAID := model.SLAID(vars["SLAID"])
+	priorityID := model.PriorityID(vars["priorityID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"SLAID":      SLAID,
+		"PriorityID": priorityID,
+	})
+
+	deleted, err := api.db.DeleteSLATarget(ctx, &SLAID, &priorityID)
+	if err != nil {
+		logger.WithError(err).Warn("Deleting sla target")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("SLA Target Deleted")
+
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
@@ -158,6 +159,10 @@ func (api *TicketStatusAPI) Update(w http.ResponseWriter, r *http.Request) {
 
 	if err := api.db.UpdateStatus(ctx, storedStatus); err != nil {
 		logger.WithError(err).Warn("Error updating status.")
+		if err == apiErr.ErrInitialStatusExists {
+			utils.WriteError(w, http.StatusConflict, err, nil)
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, "Error updating status.", map[string]string{
 			"requestID": ctx.Value("correlationid").(string),
 		})
@@ -0,0 +1,217 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/env"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+	"github.com/sirupsen/logrus"
+)
+
+// TicketStatusTransitionAPI - structure holds handlers for the ticket status workflow
+type TicketStatusTransitionAPI struct {
+	env *env.Env
+	db  database.Database
+}
+
+// Load help create a subrouter for the status transitions
+func loadTicketStatusTransition(router *mux.Router, env *env.Env, authorizer *middlewares.Authorizer) {
+
+	api := &TicketStatusTransitionAPI{env: env, db: env.DB}
+
+	apiEndpoint := []apiEndpoint{
+
+		newAPIEndpoint("POST", "/ticket_status_transitions", api.Create, authorizer.ObjAuthorize("ticket_status", "create")),
+		newAPIEndpoint("GET", "/ticket_status_transitions/{transitionID}", api.Get, authorizer.ObjAuthorize("ticket_status", "view")), //retrieves a transition using its ID
+		newAPIEndpoint("GET", "/ticket_status_transitions", api.List, authorizer.ObjAuthorize("ticket_status", "list")),               //retrieves all the transitions
+
+		newAPIEndpoint("PATCH", "/ticket_status_transitions/{transitionID}", api.Update, authorizer.ObjAuthorize("ticket_status", "update")),  //updates the rules of a transition
+		newAPIEndpoint("DELETE", "/ticket_status_transitions/{transitionID}", api.Delete, authorizer.ObjAuthorize("ticket_status", "delete")), //delete a transition using its ID
+
+	}
+
+	for _, api := range apiEndpoint {
+
+		router.HandleFunc(api.Path, api.Func).Methods(api.Method)
+	}
+
+}
+
+// Create - Creates a new transition between two statuses
+func (api *TicketStatusTransitionAPI) Create(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "ticket_status_transition.go -> TransitionApi.Create()")
+
+	principal := middlewares.GetPrincipal(r)
+
+	//Load parameters
+	var transition model.Transition
+
+	if err := transition.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	transition.UserID = principal.UserID
+	if err := transition.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := api.db.CreateStatusTransition(ctx, &transition); err != nil {
+		logger.WithError(err).Warn(err.Error())
+		utils.WriteError(w, http.StatusConflict, err, nil)
+		return
+	}
+	createdTransition, err := api.db.GetStatusTransitionByID(ctx, &transition.ID)
+	if err != nil {
+		logger.WithError(err).Warn(err.Error())
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, &createdTransition)
+}
+
+// Get -  retreives transition information
+func (api *TicketStatusTransitionAPI) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	logger := logrus.WithField("func", "ticket_status_transition.go -> TransitionApi.Get()")
+
+	vars := mux.Vars(r)
+	transitionID := model.TransitionID(vars["transitionID"])
+
+	transition, err := api.db.GetStatusTransitionByID(ctx, &transitionID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching transition TransitionID: %v", transitionID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	logger.WithField("transitionID", transitionID).Debug("Get Transition Complete")
+
+	utils.WriteJSON(w, http.StatusOK, transition)
+}
+
+// Update - Updates the rules of a transition
+// PATCH - /ticket_status_transitions/{transitionID}
+func (api *TicketStatusTransitionAPI) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "ticket_status_transition.go -> TransitionApi.Update()")
+
+	vars := mux.Vars(r)
+	transitionID := model.TransitionID(vars["transitionID"])
+
+	principal := middlewares.GetPrincipal(r)
+
+	logger = logger.WithFields(logrus.Fields{
+		"TransitionID": transitionID,
+		"pricipal":     principal,
+	})
+
+	var transition model.Transition
+
+	// Decode Parameters
+	if err := transition.Decode(r.Body); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	storedTransition, err := api.db.GetStatusTransitionByID(ctx, &transitionID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error fetching transition TransitionID: %v", transitionID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	storedTransition.UpdateValues(&transition)
+
+	if err := api.db.UpdateStatusTransition(ctx, storedTransition); err != nil {
+		logger.WithError(err).Warn("Error updating transition.")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Transition Updated")
+
+	utils.WriteJSON(w, http.StatusOK, storedTransition)
+}
+
+// List - List all the transitions of the workflow
+// GET - /ticket_status_transitions
+// Permission Admin
+func (api *TicketStatusTransitionAPI) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "ticket_status_transition.go -> TransitionApi.List()")
+
+	principal := middlewares.GetPrincipal(r)
+
+	logger = logger.WithFields(logrus.Fields{
+		"pricipal": principal,
+	})
+
+	transitions, err := api.db.ListStatusTransitions(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Retreiving all transitions")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Transitions Returned")
+
+	utils.WriteJSON(w, http.StatusOK, &transitions)
+}
+
+// Delete - Deletes a transition
+// DELETE - /ticket_status_transitions/{transitionID}
+func (api *TicketStatusTransitionAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "ticket_status_transition.go -> TransitionApi.Delete()")
+
+	vars := mux.Vars(r)
+	transitionID := model.TransitionID(vars["transitionID"])
+	principal := middlewares.GetPrincipal(r)
+
+	logger = logger.WithFields(logrus.Fields{
+		"TransitionID": transitionID,
+		"pricipal":     principal,
+	})
+
+	deleted, err := api.db.DeleteStatusTransition(ctx, &transitionID)
+	if err != nil {
+		errMessage := fmt.Sprintf("Error deleting transition: %v", transitionID)
+		logger.WithError(err).Warn(errMessage)
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	logger.Info("Transition Deleted")
+
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: deleted,
+	})
+}
@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ListTransitions - returns the statuses the caller can move the ticket to
+// GET - /tickets/{ticketID}/transitions
+func (api *TicketAPI) ListTransitions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.ListTransitions()")
+
+	principal := middlewares.GetPrincipal(r)
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"pricipal": principal,
+		"TicketID": ticketID,
+	})
+
+	ticket, err := api.db.GetTicketByID(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	transitions, err := api.availableTransitions(ctx, principal, ticket.StatusID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving transitions")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the ticket transitions", nil)
+		return
+	}
+
+	logger.Info("Ticket Transitions Returned")
+	utils.WriteJSON(w, http.StatusOK, &transitions)
+}
+
+// availableTransitions - lists the moves open to the principal from a status,
+// every other status is open while no workflow has been defined
+func (api *TicketAPI) availableTransitions(ctx context.Context, principal model.Principal, statusID model.StatusID) ([]*model.Transition, error) {
+
+	enforced, err := api.db.HasStatusWorkflow(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions := []*model.Transition{}
+	if !enforced {
+		statuses, err := api.db.ListAllStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			if status.ID == statusID {
+				continue
+			}
+			transitions = append(transitions, &model.Transition{FromStatusID: statusID, ToStatusID: status.ID, ToStatus: status})
+		}
+		return transitions, nil
+	}
+
+	allTransitions, err := api.db.ListTransitionsFrom(ctx, &statusID)
+	if err != nil {
+		return nil, err
+	}
+	for _, transition := range allTransitions {
+		if transition.AllowedFor(principal.Role) {
+			transitions = append(transitions, transition)
+		}
+	}
+	return transitions, nil
+}
+
+// checkTransition - ensures the workflow allows the ticket to move to the new status,
+// no transition is returned while no workflow has been defined
+func (api *TicketAPI) checkTransition(ctx context.Context, principal model.Principal, ticket, nv *model.Ticket) (*model.Transition, error) {
+
+	enforced, err := api.db.HasStatusWorkflow(ctx)
+	if err != nil || !enforced {
+		return nil, err
+	}
+
+	transition, err := api.db.GetStatusTransition(ctx, &ticket.StatusID, &nv.StatusID)
+	if err != nil {
+		return nil, err
+	}
+	if !transition.AllowedFor(principal.Role) {
+		return nil, apiErr.ErrTransitionRoleRequired
+	}
+	if transition.NeedsNote() && (nv.Note == nil || len(*nv.Note) == 0) {
+		return nil, apiErr.ErrTransitionNoteRequired
+	}
+	return transition, nil
+}
@@ -0,0 +1,158 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ListUsers - returns the assignee, collaborators and watchers of a ticket
+// GET - /tickets/{ticketID}/users
+func (api *TicketAPI) ListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.ListUsers()")
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+
+	logger = logger.WithField("TicketID", ticketID)
+
+	ticketUsers, err := api.db.ListTicketUsers(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket users")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the ticket users", nil)
+		return
+	}
+
+	logger.Info("Ticket Users Returned")
+	utils.WriteJSON(w, http.StatusOK, &ticketUsers)
+}
+
+// AddUser - adds a collaborator or watcher to a ticket
+// POST - /tickets/{ticketID}/users
+func (api *TicketAPI) AddUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.AddUser()")
+
+	principal := middlewares.GetPrincipal(r)
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"pricipal": principal,
+		"TicketID": ticketID,
+	})
+
+	var ticketUser model.TicketUser
+	if err := ticketUser.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ticketUser.TicketID = ticketID
+	ticketUser.AddedByID = principal.UserID
+	if err := ticketUser.Verify(); err != nil {
+		logger.WithError(err).Warn("Error with submitted values")
+		utils.WriteError(w, http.StatusBadRequest, "Error with submitted values", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if _, err := api.db.GetTicketByID(ctx, &ticketID); err != nil {
+		logger.WithError(err).Warn("Retrieving ticket")
+		utils.WriteError(w, http.StatusNotFound, err, nil)
+		return
+	}
+
+	if err := api.db.AddTicketUser(ctx, &ticketUser); err != nil {
+		logger.WithError(err).Warn("Adding ticket user")
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	ticketUsers, err := api.db.ListTicketUsers(ctx, &ticketID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving ticket users")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the ticket users", nil)
+		return
+	}
+
+	logger.WithField("UserID", ticketUser.UserID).Info("Ticket User Added")
+	utils.WriteJSON(w, http.StatusCreated, &ticketUsers)
+}
+
+// RemoveUser - removes a collaborator or watcher from a ticket
+// DELETE - /tickets/{ticketID}/users/{userID}
+func (api *TicketAPI) RemoveUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.RemoveUser()")
+
+	principal := middlewares.GetPrincipal(r)
+
+	vars := mux.Vars(r)
+	ticketID := model.TicketID(vars["ticketID"])
+	userID := model.UserID(vars["userID"])
+
+	logger = logger.WithFields(logrus.Fields{
+		"pricipal": principal,
+		"TicketID": ticketID,
+		"UserID":   userID,
+	})
+
+	removed, err := api.db.RemoveTicketUser(ctx, &ticketID, &userID)
+	if err != nil {
+		logger.WithError(err).Warn("Removing ticket user")
+		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+		return
+	}
+	if !removed {
+		utils.WriteError(w, http.StatusNotFound, apiErr.ErrNotExist("Ticket User"), nil)
+		return
+	}
+
+	logger.Info("Ticket User Removed")
+	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
+		Deleted: removed,
+	})
+}
+
+// ListUserTickets - returns the tickets a user is assigned to, collaborates on or watches
+// GET - /users/{userID}/tickets
+func (api *TicketAPI) ListUserTickets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "[API-Gateway] -> TicketsApi.ListUserTickets()")
+
+	vars := mux.Vars(r)
+	userID := model.UserID(vars["userID"])
+
+	logger = logger.WithField("UserID", userID)
+
+	userTickets, err := api.db.ListUserTickets(ctx, &userID)
+	if err != nil {
+		logger.WithError(err).Warn("Retrieving user tickets")
+		utils.WriteError(w, http.StatusInternalServerError, "Error retreiving the user tickets", nil)
+		return
+	}
+
+	logger.Info("User Tickets Returned")
+	utils.WriteJSON(w, http.StatusOK, &userTickets)
+}
@@ -2,11 +2,14 @@ package v1
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/auth"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/middlewares"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/requests"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/responses"
@@ -14,19 +17,28 @@ import (
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/env"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+	"github.com/pkg/errors"
 
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// refreshCookieName - the cookie holding the refresh token
+	refreshCookieName = "refresh_token"
+	// refreshCookiePath - the cookie is only sent to the refresh endpoint
+	refreshCookiePath = "/api/v1/refresh"
+)
+
 // UserAPI - structure holds privies rest for users
 type UserAPI struct {
-	db database.Database
+	db  database.Database
+	env *env.Env
 }
 
 // Load help create a subrouter for the users
 func loadUserAPI(router *mux.Router, env *env.Env, authorizer *middlewares.Authorizer) {
 
-	userAPI := &UserAPI{db: env.DB}
+	userAPI := &UserAPI{db: env.DB, env: env}
 
 	apiEndpoint := []apiEndpoint{
 
@@ -38,8 +50,30 @@ func loadUserAPI(router *mux.Router, env *env.Env, authorizer *middlewares.Autho
 		newAPIEndpoint("DELETE", "/users/{userID}", userAPI.Delete, authorizer.ObjAuthorize("user", "delete")), //delete a user using its ID
 		// ----- AUTHORIZATION -----
 		newAPIEndpoint("POST", "/login", userAPI.Login),
+		newAPIEndpoint("POST", "/login/mfa", userAPI.LoginMFA),
+		newAPIEndpoint("POST", "/login/mfa/enroll", userAPI.EnrollMFAAtLogin),
+		newAPIEndpoint("POST", "/logout", userAPI.Logout, authorizer.Authenticate),
 		// ----- TOKENS -----
 		newAPIEndpoint("POST", "/refresh", userAPI.RefreshToken),
+		// ----- ACCOUNT -----
+		newAPIEndpoint("POST", "/password/forgot", userAPI.ForgotPassword),
+		newAPIEndpoint("POST", "/password/reset", userAPI.ResetPassword),
+		newAPIEndpoint("POST", "/email/verify", userAPI.VerifyEmail),
+		newAPIEndpoint("POST", "/email/verify/resend", userAPI.ResendVerification),
+		// ----- SESSIONS -----
+		newAPIEndpoint("GET", "/users/me/sessions", userAPI.ListSessions, authorizer.Authenticate),
+		newAPIEndpoint("DELETE", "/users/me/sessions/{deviceID}", userAPI.DeleteSession, authorizer.Authenticate),
+		newAPIEndpoint("DELETE", "/users/{userID}/sessions", userAPI.RevokeSessions, authorizer.ObjAuthorize("user", "update")), //signs the user out of all the devices
+		// ----- MFA -----
+		newAPIEndpoint("GET", "/users/me/mfa", userAPI.GetMFA, authorizer.Authenticate),
+		newAPIEndpoint("POST", "/users/me/mfa/totp", userAPI.EnrollMFA, authorizer.Authenticate),
+		newAPIEndpoint("POST", "/users/me/mfa/totp/confirm", userAPI.ConfirmMFA, authorizer.Authenticate),
+		newAPIEndpoint("DELETE", "/users/me/mfa/totp", userAPI.DisableMFA, authorizer.Authenticate),
+		newAPIEndpoint("POST", "/users/me/mfa/recovery_codes", userAPI.RegenerateRecoveryCodes, authorizer.Authenticate),
+		newAPIEndpoint("DELETE", "/users/{userID}/mfa", userAPI.ResetMFA, authorizer.ObjAuthorize("user", "update")), //removes the lost authenticator app of a user
+		newAPIEndpoint("POST", "/mfa_requirements", userAPI.CreateMFARequirement, authorizer.ObjAuthorize("mfa_requirement", "create")),
+		newAPIEndpoint("GET", "/mfa_requirements", userAPI.ListMFARequirements, authorizer.ObjAuthorize("mfa_requirement", "list")),
+		newAPIEndpoint("DELETE", "/mfa_requirements/{requirementID}", userAPI.DeleteMFARequirement, authorizer.ObjAuthorize("mfa_requirement", "delete")),
 	}
 	for _, api := range apiEndpoint {
 
@@ -48,7 +82,7 @@ func loadUserAPI(router *mux.Router, env *env.Env, authorizer *middlewares.Autho
 
 }
 
-// Create - Creates a new User
+// Create - Creates a new User, the new account is verified through the mailed link
 func (api *UserAPI) Create(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
@@ -103,33 +137,28 @@ func (api *UserAPI) Create(w http.ResponseWriter, r *http.Request) {
 		PasswordHash: &hashed,
 	}
 
-	ctx = r.Context()
-
+	// the answer is the same whether the email has an account or not, its owner is told by mail instead
 	err = api.db.CreateUser(ctx, newUser)
-	if err != nil {
+	switch {
+	case err == apiErr.ErrEmailAlreadyExists:
+		logger.Info("Sign up with the email of an existing account")
+		go api.mailExistingAccount(*newUser.Email)
+	case err != nil:
 		logger.WithError(err).Error()
+		if apiError, ok := err.(apiErr.APIError); ok {
+			utils.WriteError(w, apiError.Code, apiError, nil)
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, err, nil)
 		return
+	default:
+		// the user signs in once the email is verified
+		go api.mailVerification(newUser)
 	}
-	createdUser, err := api.db.GetUserByID(ctx, &newUser.ID)
-	if err != nil {
-		logger.WithError(err).Warn("Error fetching newly created user")
-		utils.WriteError(w, http.StatusInternalServerError, err, nil)
-		return
-	}
-
-	// get the user's role
-	role, err := api.db.GetRoleByID(ctx, &createdUser.RoleID)
-	if err != nil {
-		logger.WithError(err).Warn("Error fetching the roles of newly created user")
-		utils.WriteError(w, http.StatusInternalServerError, err, nil)
-		return
-	}
-	createdUser.Role = role
-	// remove all that is not needed from the endpoint
-	createdUser.RoleID = model.NilRoleID
 
-	api.writeToTokenResponse(ctx, w, http.StatusCreated, createdUser, userParameters.DeviceID, true)
+	utils.WriteJSON(w, http.StatusAccepted, &responses.ActSent{
+		Sent: true,
+	})
 
 }
 
@@ -176,38 +205,38 @@ func (api *UserAPI) Login(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, "Invalid email or password", nil)
 		return
 	}
+	if user.IsActive != nil && !*user.IsActive {
+		logger.Warn("The user is deactivated")
+		utils.WriteError(w, http.StatusUnauthorized, "The account is deactivated", nil)
+		return
+	}
+	if user.EmailVerifiedAt == nil {
+		logger.Warn("The email of the user is not verified")
+		utils.WriteError(w, http.StatusForbidden, apiErr.ErrEmailNotVerified, nil)
+		return
+	}
 
-	// Add the User permitted Actions on objects
-	objActions, err := api.db.ListPermitedObjectActions(ctx, &user.ID)
+	// the users signing in with a code get their tokens from the second step
+	challenge, err := api.mfaChallenge(ctx, user.ID)
 	if err != nil {
-		logger.WithError(err).Warn("Error fetching object actions")
+		logger.WithError(err).Warn("Error checking the MFA of the user")
 		utils.WriteError(w, http.StatusInternalServerError, "Currently unable to login", nil)
 		return
 	}
-	// Add the User permitted Actions on system objects
-	sysActions, err := api.db.ListPermitedSystemActions(ctx, &user.ID)
-	if err != nil {
-		logger.WithError(err).Warn("Error fetching system actions")
-		utils.WriteError(w, http.StatusInternalServerError, "Currently unable to login", nil)
+	if challenge != nil {
+		logger.WithField("userID", user.ID).Debug("MFA challenge issued")
+		utils.WriteJSON(w, http.StatusOK, challenge)
 		return
 	}
-	actions := append(sysActions, objActions...)
-	user.Actions = actions
-	logger.WithField("userID", user.ID).Debug("User logged in")
 
-	// get the user's role
-	role, err := api.db.GetRoleByID(ctx, &user.RoleID)
-	if err != nil {
-		logger.WithError(err).Warn("Error fetching the roles of newly created user")
-		utils.WriteError(w, http.StatusInternalServerError, err, nil)
+	if err := api.loadAccess(ctx, user); err != nil {
+		logger.WithError(err).Warn("Error fetching the actions and role of the user")
+		utils.WriteError(w, http.StatusInternalServerError, "Currently unable to login", nil)
 		return
 	}
-	user.Role = role
-
-	// remove all that is not needed from the endpoint
-	user.RoleID = model.NilRoleID
+	logger.WithField("userID", user.ID).Debug("User logged in")
 
-	api.writeToTokenResponse(ctx, w, http.StatusOK, user, credentials.DeviceID, true)
+	api.writeToTokenResponse(r, w, http.StatusOK, user, credentials.DeviceID, "", true)
 
 }
 
@@ -309,6 +338,12 @@ func (api *UserAPI) Update(w http.ResponseWriter, r *http.Request) {
 	}
 	storedUser.UpdateValues(&userRequest.User)
 
+	deactivated := false
+	if userRequest.IsActive != nil {
+		deactivated = !*userRequest.IsActive && (storedUser.IsActive == nil || *storedUser.IsActive)
+		storedUser.IsActive = userRequest.IsActive
+	}
+
 	// now update the database values
 	err = api.db.UpdateUser(ctx, storedUser)
 	if err != nil {
@@ -319,6 +354,11 @@ func (api *UserAPI) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the tokens issued with the old password or before the deactivation no longer work
+	if len(userRequest.Password) > 0 || deactivated {
+		api.revokeUser(ctx, logger, userID)
+	}
+
 	logger.Info("User Updated")
 	utils.WriteJSON(w, http.StatusOK, storedUser)
 
@@ -379,6 +419,10 @@ func (api *UserAPI) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if deleted {
+		api.revokeUser(ctx, logger, userID)
+	}
+
 	logger.Info("User Deleted")
 
 	utils.WriteJSON(w, http.StatusOK, &responses.ActDeleted{
@@ -388,15 +432,154 @@ func (api *UserAPI) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 // RefreshToken - accepts a refresh Token to return a new access token
-func (api *UserAPI) RefreshToken(w http.ResponseWriter, r *http.Request) {}
+// The refresh token is replaced by the new one, presenting a replaced token again revokes all the
+// sessions of the user because the token was stolen or the rotation was intercepted
+func (api *UserAPI) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Show function name in error logs to track errors faster
+	logger := logrus.WithField("func", "user -> user.go -> UserApi.RefreshToken()")
+
+	var parameters requests.RefreshParameters
+	if err := parameters.Decode(r.Body); err != nil {
+		logger.WithError(err).Warn("Could not decode parameters")
+		utils.WriteError(w, http.StatusBadRequest, "could not decode parameters", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if parameters.RefreshToken == "" {
+		if cookie, err := r.Cookie(refreshCookieName); err == nil {
+			parameters.RefreshToken = cookie.Value
+		}
+	}
+	if err := parameters.Verify(); err != nil {
+		logger.WithError(err).Warn("Not all fields found")
+		utils.WriteError(w, http.StatusBadRequest, "Not all fields were found", map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	principal, err := auth.VerifyRefreshToken(parameters.RefreshToken)
+	if err == nil {
+		err = auth.CheckRevoked(ctx, principal)
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Invalid refresh token")
+		utils.WriteError(w, http.StatusUnauthorized, apiErr.ErrInvalidRefreshToken, nil)
+		return
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"userID":   principal.UserID,
+		"deviceID": parameters.DeviceID,
+	})
+
+	_, err = api.db.GetSession(ctx, &model.Session{
+		UserID:       principal.UserID,
+		DeviceID:     parameters.DeviceID,
+		RefreshToken: parameters.RefreshToken,
+	})
+	if err == sql.ErrNoRows {
+		api.rejectRefreshToken(ctx, w, logger, principal.UserID, parameters.RefreshToken)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Error retrieving the session")
+		utils.WriteError(w, http.StatusInternalServerError, "Currently unable to refresh the token", nil)
+		return
+	}
+
+	user, err := api.db.GetUserByID(ctx, &principal.UserID)
+	if err != nil {
+		logger.WithError(err).Warn("Error retrieving the user")
+		utils.WriteError(w, http.StatusUnauthorized, apiErr.ErrInvalidRefreshToken, nil)
+		return
+	}
+	if user.DeletedAt != nil || (user.IsActive != nil && !*user.IsActive) {
+		logger.Warn("The user can no longer sign in")
+		utils.WriteError(w, http.StatusUnauthorized, apiErr.ErrInvalidRefreshToken, nil)
+		return
+	}
+	if err := api.loadAccess(ctx, user); err != nil {
+		logger.WithError(err).Warn("Error fetching the actions and role of the user")
+		utils.WriteError(w, http.StatusInternalServerError, "Currently unable to refresh the token", nil)
+		return
+	}
+
+	logger.Debug("Token refreshed")
+	api.writeToTokenResponse(r, w, http.StatusOK, user, parameters.DeviceID, parameters.RefreshToken, true)
+}
+
+// rejectRefreshToken - answers a refresh token that is not the one of the session, the sessions of
+// the user are revoked when it is a replaced token
+func (api *UserAPI) rejectRefreshToken(ctx context.Context, w http.ResponseWriter, logger *logrus.Entry, userID model.UserID, refreshToken string) {
+
+	rotated, err := api.db.IsRotatedRefreshToken(ctx, &userID, refreshToken)
+	if err != nil {
+		logger.WithError(err).Warn("Error checking the rotated refresh tokens")
+	}
+	if !rotated {
+		logger.Warn("No session for the refresh token")
+		utils.WriteError(w, http.StatusUnauthorized, apiErr.ErrInvalidRefreshToken, nil)
+		return
+	}
+
+	// the access tokens issued with the stolen refresh token are refused too
+	logger.Warn("A rotated refresh token was presented, revoking the tokens and sessions of the user")
+	api.revokeUser(ctx, logger, userID)
+	clearRefreshCookie(w)
+	utils.WriteError(w, http.StatusUnauthorized, apiErr.ErrRefreshTokenReused, nil)
+}
+
+// revokeUser - revokes the tokens of the user and ends their sessions on all the devices
+func (api *UserAPI) revokeUser(ctx context.Context, logger *logrus.Entry, userID model.UserID) {
+
+	if err := auth.RevokeTokens(ctx, userID); err != nil {
+		logger.WithError(err).Error("Error revoking the tokens of the user")
+	}
+	if err := api.db.RevokeUserSessions(ctx, &userID); err != nil {
+		logger.WithError(err).Error("Error revoking the sessions of the user")
+	}
+}
+
+// loadAccess - adds the permitted actions and the role to the user sent with the tokens
+func (api *UserAPI) loadAccess(ctx context.Context, user *model.User) error {
+
+	// Add the User permitted Actions on objects
+	objActions, err := api.db.ListPermitedObjectActions(ctx, &user.ID)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch object actions")
+	}
+	// Add the User permitted Actions on system objects
+	sysActions, err := api.db.ListPermitedSystemActions(ctx, &user.ID)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch system actions")
+	}
+	user.Actions = append(sysActions, objActions...)
+
+	// get the user's role
+	role, err := api.db.GetRoleByID(ctx, &user.RoleID)
+	if err != nil {
+		return errors.Wrap(err, "could not fetch the role")
+	}
+	user.Role = role
+
+	// remove all that is not needed from the endpoint
+	user.RoleID = model.NilRoleID
+	return nil
+}
 
 // writeToTokenResponse - generates access and refresh tokens, returnes them to user, Refresh token is stored in database as session
-func (api *UserAPI) writeToTokenResponse(ctx context.Context, w http.ResponseWriter, status int, user *model.User, deviceID model.DeviceID, cookie bool) {
+// The refresh token of the session is replaced when refreshToken is the one being rotated
+func (api *UserAPI) writeToTokenResponse(r *http.Request, w http.ResponseWriter, status int, user *model.User, deviceID model.DeviceID, refreshToken string, cookie bool) {
+	ctx := r.Context()
 
 	// Issue the toke
 	fullName := fmt.Sprintf("%s %s", *user.Firstname, *user.Lastname)
 	principal := model.Principal{UserID: user.ID, Name: fullName, Role: *user.Role.Name, Type: *user.Type}
-	tokens, err := auth.IssueToken(principal)
+	tokens, err := auth.IssueToken(ctx, principal)
 	if err != nil || tokens == nil {
 		logrus.WithError(err).Warn("Error issuing token")
 		utils.WriteError(w, http.StatusUnauthorized, "Error Issuing Token", nil)
@@ -408,9 +591,21 @@ func (api *UserAPI) writeToTokenResponse(ctx context.Context, w http.ResponseWri
 		DeviceID:     deviceID,
 		RefreshToken: tokens.RefreshToken,
 		ExpiresAt:    tokens.RefreshTokenExpiresAt,
+		IPAddress:    clientIP(r),
 	}
 
-	if err := api.db.SaveRefreshToken(ctx, session); err != nil {
+	if refreshToken == "" {
+		err = api.db.SaveRefreshToken(ctx, session)
+	} else {
+		err = api.db.RotateRefreshToken(ctx, session, refreshToken)
+	}
+	if err == apiErr.ErrInvalidRefreshToken {
+		// another request rotated the token first
+		logrus.WithField("userID", user.ID).Warn("The refresh token was already rotated")
+		utils.WriteError(w, http.StatusUnauthorized, err, nil)
+		return
+	}
+	if err != nil {
 		logrus.WithError(err).Warn("Error issuing token")
 		utils.WriteError(w, http.StatusUnauthorized, "Error Issuing Token", nil)
 		return
@@ -422,9 +617,36 @@ func (api *UserAPI) writeToTokenResponse(ctx context.Context, w http.ResponseWri
 		Tokens: tokens,
 	}
 	if cookie {
-		// TODO:
+		setRefreshCookie(w, tokens)
 	}
 
 	utils.WriteJSON(w, status, tokenResponse)
 
 }
+
+// setRefreshCookie - sends the refresh token in a cookie the scripts of the page cannot read, it is
+// only sent back to the refresh endpoint
+func setRefreshCookie(w http.ResponseWriter, tokens *auth.Tokens) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    tokens.RefreshToken,
+		Path:     refreshCookiePath,
+		Expires:  time.Unix(tokens.RefreshTokenExpiresAt, 0),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// clearRefreshCookie - removes the refresh cookie from the browser
+func clearRefreshCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		Path:     refreshCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
@@ -23,18 +23,26 @@ func LoadRoutes(router *mux.Router, env *env.Env, authorizer *middlewares.Author
 	
 	// Tickets
 	loadTicketAPI(v1Router, env, authorizer)
+	loadClosedTicketAPI(v1Router, env, authorizer)
 	loadTicketCause(v1Router, env, authorizer)
 	loadTicketCategory(v1Router, env, authorizer)
 	loadTicketPriority(v1Router, env, authorizer)
 	loadTicketSource(v1Router, env, authorizer)
 	loadTicketStatus(v1Router, env, authorizer)
+	loadTicketStatusTransition(v1Router, env, authorizer)
 	loadUserAPI(v1Router, env, authorizer)
 
 	loadSLA(v1Router, env, authorizer)
+	loadBusinessCalendar(v1Router, env, authorizer)
+	loadSLAEscalation(v1Router, env, authorizer)
 	// (v1Router, env, authorizer)
 	// (v1Router, env, authorizer)
 	// (v1Router, env, authorizer)
 
 	//Contacts
 	loadContactAPI(v1Router, env, authorizer)
+
+	//Mailboxes
+	loadInboundMail(v1Router, env, authorizer)
+	loadOutboundMail(v1Router, env, authorizer)
 }
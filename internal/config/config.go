@@ -1,6 +1,8 @@
 // Package info reads the application settings.
 package config
 
+import "strings"
+
 // *****************************************************************************
 // Application Settings
 // *****************************************************************************
@@ -37,9 +39,66 @@ func LoadConfig() *Info {
 		Authorizer: authorizer{
 			CacheExpiration: vCfg.GetInt("authorizer.cache_exp"),
 		},
+		Escalation: escalation{
+			Interval: vCfg.GetInt("escalation.interval"),
+			Batch:    vCfg.GetInt("escalation.batch"),
+		},
+		Outbound: outbound{
+			Interval: vCfg.GetInt("outbound.interval"),
+			Batch:    vCfg.GetInt("outbound.batch"),
+		},
+		Mail: mail{
+			SecretKey: vCfg.GetString("mail.secret_key"),
+		},
+		Attachments: attachments{
+			MaxSize: vCfg.GetInt64("attachments.max_size"),
+			Types:   splitList(vCfg.GetString("attachments.types")),
+		},
+		Files: files{
+			Backend:     vCfg.GetString("files.backend"),
+			S3Endpoint:  vCfg.GetString("files.s3.endpoint"),
+			S3Region:    vCfg.GetString("files.s3.region"),
+			S3Bucket:    vCfg.GetString("files.s3.bucket"),
+			S3AccessKey: vCfg.GetString("files.s3.access_key"),
+			S3SecretKey: vCfg.GetString("files.s3.secret_key"),
+			S3PathStyle: vCfg.GetBool("files.s3.path_style"),
+		},
+		Cache: cache{
+			Addr:     vCfg.GetString("redis.addr"),
+			Password: vCfg.GetString("redis.secret"),
+			DB:       vCfg.GetInt("redis.db"),
+			Timeout:  vCfg.GetInt64("redis.timeout"),
+		},
+		JWT: jwt{
+			Keys:            splitList(vCfg.GetString("jwt.keys")),
+			SigningKey:      vCfg.GetString("jwt.signing_key"),
+			Secret:          vCfg.GetString("jwt.secret"),
+			AccessLifetime:  vCfg.GetInt("jwt.access_lifetime"),
+			RefreshLifetime: vCfg.GetInt("jwt.refresh_lifetime"),
+		},
+		Accounts: accounts{
+			WebURL:               strings.TrimRight(vCfg.GetString("accounts.web_url"), "/"),
+			ResetLifetime:        vCfg.GetInt("accounts.reset_lifetime"),
+			VerificationLifetime: vCfg.GetInt("accounts.verification_lifetime"),
+		},
+		MFA: mfa{
+			Issuer:            vCfg.GetString("mfa.issuer"),
+			ChallengeLifetime: vCfg.GetInt("mfa.challenge_lifetime"),
+		},
 	}
 
 	// log.Printf("Config => %+v\n\n", config)
 	// Return the configuration
 	return config
 }
+
+// splitList - the values of a comma separated setting
+func splitList(list string) []string {
+	values := []string{}
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -47,6 +47,73 @@ func loadEnvConfig() (vCfg *viper.Viper) {
 	// authorizer
 	vCfg.BindEnv("authorizer.cache_exp", "AUTHORIZER_CACHE_EXP")
 	vCfg.SetDefault("authorizer.cache_exp", 180)
+
+	// SLA escalation monitor, an interval of 0 turns it off
+	vCfg.BindEnv("escalation.interval", "ESCALATION_INTERVAL")
+	vCfg.SetDefault("escalation.interval", 60)
+	vCfg.BindEnv("escalation.batch", "ESCALATION_BATCH")
+	vCfg.SetDefault("escalation.batch", 100)
+
+	// outbound mail queue, an interval of 0 keeps the mails queued
+	vCfg.BindEnv("outbound.interval", "OUTBOUND_INTERVAL")
+	vCfg.SetDefault("outbound.interval", 30)
+	vCfg.BindEnv("outbound.batch", "OUTBOUND_BATCH")
+	vCfg.SetDefault("outbound.batch", 50)
+
+	// the key the mailbox secrets are encrypted with
+	vCfg.BindEnv("mail.secret_key", "MAIL_SECRET_KEY")
+
+	// the files received with the mails, the types are separated by commas and all are allowed when empty
+	vCfg.BindEnv("attachments.max_size", "ATTACHMENT_MAX_SIZE")
+	vCfg.SetDefault("attachments.max_size", 10<<20)
+	vCfg.BindEnv("attachments.types", "ATTACHMENT_TYPES")
+	vCfg.SetDefault("attachments.types", "")
+
+	// where the ticket files are kept, local keeps them under the data directory and s3 in a bucket
+	vCfg.BindEnv("files.backend", "FILES_BACKEND")
+	vCfg.SetDefault("files.backend", "local")
+	vCfg.BindEnv("files.s3.endpoint", "S3_ENDPOINT")
+	vCfg.BindEnv("files.s3.region", "S3_REGION")
+	vCfg.SetDefault("files.s3.region", "us-east-1")
+	vCfg.BindEnv("files.s3.bucket", "S3_BUCKET")
+	vCfg.BindEnv("files.s3.access_key", "S3_ACCESS_KEY")
+	vCfg.BindEnv("files.s3.secret_key", "S3_SECRET_KEY")
+	vCfg.BindEnv("files.s3.path_style", "S3_PATH_STYLE")
+	vCfg.SetDefault("files.s3.path_style", true)
+
+	// redis keeps the token versions shared by the servers, they are kept in memory without an address
+	vCfg.BindEnv("redis.addr", "REDIS_ADDR")
+	vCfg.BindEnv("redis.secret", "REDIS_SECRET")
+	vCfg.BindEnv("redis.db", "REDIS_DB")
+	vCfg.SetDefault("redis.db", 0)
+	vCfg.BindEnv("redis.timeout", "REDIS_TIMEOUT")
+	vCfg.SetDefault("redis.timeout", 2000)
+
+	// the keys the tokens are signed with, separated by commas as kid:path, the new tokens are signed
+	// with the signing key and the others still verify the tokens issued before a rotation
+	vCfg.BindEnv("jwt.keys", "JWT_KEYS")
+	vCfg.SetDefault("jwt.keys", "")
+	vCfg.BindEnv("jwt.signing_key", "JWT_SIGNING_KEY")
+	vCfg.BindEnv("jwt.secret", "JWT_SECRET")
+	// the lifetimes of the tokens in seconds
+	vCfg.BindEnv("jwt.access_lifetime", "JWT_ACCESS_LIFETIME")
+	vCfg.SetDefault("jwt.access_lifetime", 60*60)
+	vCfg.BindEnv("jwt.refresh_lifetime", "JWT_REFRESH_LIFETIME")
+	vCfg.SetDefault("jwt.refresh_lifetime", 40*24*60*60)
+
+	// the links mailed to reset a password or verify an email open the web application, lifetimes in seconds
+	vCfg.BindEnv("accounts.web_url", "WEB_URL")
+	vCfg.SetDefault("accounts.web_url", "http://localhost:8080")
+	vCfg.BindEnv("accounts.reset_lifetime", "PASSWORD_RESET_LIFETIME")
+	vCfg.SetDefault("accounts.reset_lifetime", 60*60)
+	vCfg.BindEnv("accounts.verification_lifetime", "EMAIL_VERIFICATION_LIFETIME")
+	vCfg.SetDefault("accounts.verification_lifetime", 48*60*60)
+
+	// the name shown in the authenticator apps and the time to type their code after the password in seconds
+	vCfg.BindEnv("mfa.issuer", "MFA_ISSUER")
+	vCfg.SetDefault("mfa.issuer", "ASA Ticket")
+	vCfg.BindEnv("mfa.challenge_lifetime", "MFA_CHALLENGE_LIFETIME")
+	vCfg.SetDefault("mfa.challenge_lifetime", 5*60)
 	
 
 	return
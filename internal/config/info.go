@@ -2,12 +2,21 @@ package config
 
 // Info structures the application settings.
 type Info struct {
-	Database database
-	Casbin   casbin
-	Authorizer authorizer
-	AppVersion string
+	Database      database
+	Casbin        casbin
+	Authorizer    authorizer
+	Escalation    escalation
+	Outbound      outbound
+	Mail          mail
+	Attachments   attachments
+	Files         files
+	Cache         cache
+	JWT           jwt
+	Accounts      accounts
+	MFA           mfa
+	AppVersion    string
 	DataDirectory string
-	HTTPAddr string
+	HTTPAddr      string
 }
 
 
@@ -34,3 +43,67 @@ type authorizer struct {
 	CacheExpiration int
 }
 
+// escalation holds the settings of the SLA escalation monitor
+type escalation struct {
+	Interval int // seconds between the checks
+	Batch    int // tickets escalated per check
+}
+
+
+// outbound holds the settings of the outbound mail queue
+type outbound struct {
+	Interval int // seconds between the sends
+	Batch    int // mails sent per send
+}
+
+// mail holds the settings shared by the mailboxes
+type mail struct {
+	SecretKey string // encrypts the mailbox and the TOTP secrets
+}
+
+// files holds the settings of the file store
+type files struct {
+	Backend     string // local or s3
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+// cache holds the connection to redis, the token versions are kept in memory when Addr is empty
+type cache struct {
+	Addr     string
+	Password string
+	DB       int
+	Timeout  int64 // milliseconds to wait for the connection
+}
+
+// jwt holds the keys the tokens are signed with and their lifetimes
+type jwt struct {
+	Keys            []string // kid:path of the key files, PEM RSA or Ed25519 keys and HMAC secrets otherwise
+	SigningKey      string   // kid of the key the new tokens are signed with, the first private key when empty
+	Secret          string   // HMAC secret used without key files
+	AccessLifetime  int      // seconds
+	RefreshLifetime int      // seconds
+}
+
+// accounts holds the settings of the links mailed to reset the passwords and verify the emails
+type accounts struct {
+	WebURL               string // the web application the links open
+	ResetLifetime        int    // seconds
+	VerificationLifetime int    // seconds
+}
+
+// mfa holds the settings of the two-factor authentication
+type mfa struct {
+	Issuer            string // the name the authenticator apps show for the accounts
+	ChallengeLifetime int    // seconds
+}
+
+// attachments holds the limits of the files kept with the tickets
+type attachments struct {
+	MaxSize int64    // bytes
+	Types   []string // the allowed media types, all when empty
+}
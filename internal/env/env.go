@@ -1,12 +1,20 @@
 package env
 
 import (
+	"fmt"
+	"path/filepath"
+	"time"
+
 	"github.com/casbin/casbin/v2"
 	"github.com/go-pg/pg/v9"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/auth"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/config"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/email"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/enforcer"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/escalation"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/blob"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/cache"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
 	"github.com/sirupsen/logrus"
 )
@@ -20,7 +28,20 @@ type Env struct {
 	Storage  storage.Storage
 	Config   *config.Info
 	Enforcer *casbin.CachedEnforcer
-	inboundMail *email.InboundMail
+	monitor  *escalation.Monitor
+
+	// Files keeps the contents of the ticket files, nil when the store could not be created
+	Files      blob.Store
+	FileLimits blob.Limits
+
+	// Mailboxes polls the inbound mailboxes, it is reloaded when a mailbox changes
+	Mailboxes *email.Mailboxes
+
+	// Outbound queues the notifications of the tickets
+	Outbound *email.Outbound
+
+	// Cache keeps the token versions shared by the servers, nil when redis is not set
+	Cache cache.Cache
 
 	/* MISC */
 	casbinDB *pg.DB
@@ -38,31 +59,103 @@ func Boot() *Env {
 		logrus.Fatal(err.Error())
 	}
 
-/* 	inboundMail , err := email.Init(db)
-	if err != nil {
+	// Sign the tokens with the keys of the settings
+	if err := auth.Configure(cfg); err != nil {
 		logrus.Fatal(err.Error())
 	}
 
-	go inboundMail.Listen() */
+	// Share the revoked tokens with the other servers
+	var redis cache.Cache
+	if cfg.Cache.Addr != "" {
+		if redis, err = cache.New(cfg); err != nil {
+			logrus.WithError(err).Warn("Connecting to redis, the revoked tokens are only kept in memory")
+		} else {
+			auth.SetRevocationStore(redis)
+		}
+	}
+
+	// Keep the ticket files
+	files, err := newFileStore(cfg)
+	if err != nil {
+		logrus.WithError(err).Warn("Creating the file store, the ticket files are not kept")
+	}
+	limits := blob.Limits{MaxSize: cfg.Attachments.MaxSize, Types: cfg.Attachments.Types}
+
+	// Turn the mails of the active mailboxes into tickets
+	mailboxes := email.NewMailboxes(db, files, limits)
+	if err := mailboxes.Start(); err != nil {
+		logrus.WithError(err).Warn("Starting the mailbox pollers")
+	}
 	// Initialize the Enforcer => casbin
 	enforcer, casbinDB := enforcer.Init(cfg)
 
 	env := &Env{
-		DB:       db,
-		Enforcer: enforcer,
-		casbinDB: casbinDB,
-		Config:   cfg,
-		//inboundMail: inboundMail,
+		DB:         db,
+		Enforcer:   enforcer,
+		casbinDB:   casbinDB,
+		Config:     cfg,
+		Files:      files,
+		FileLimits: limits,
+		Mailboxes:  mailboxes,
+		Cache:      redis,
+	}
+
+	// Send the queued mails
+	env.Outbound = email.NewOutbound(db, time.Duration(cfg.Outbound.Interval)*time.Second, cfg.Outbound.Batch)
+	if cfg.Outbound.Interval > 0 {
+		env.Outbound.Start()
+	}
+
+	// Start the SLA escalation monitor, the agents are mailed about the escalated tickets
+	if cfg.Escalation.Interval > 0 {
+		notifier := email.NewEscalationNotifier(env.Outbound)
+		env.monitor = escalation.New(db, notifier, time.Duration(cfg.Escalation.Interval)*time.Second, cfg.Escalation.Batch)
+		env.monitor.Start()
 	}
 
 	return env
 }
 
+// newFileStore - the store of the ticket files chosen in the settings
+func newFileStore(cfg *config.Info) (blob.Store, error) {
+
+	// a failed store is returned as a nil interface, not as a nil pointer
+	switch cfg.Files.Backend {
+	case "s3":
+		store, err := blob.NewS3(blob.S3Config{
+			Endpoint:  cfg.Files.S3Endpoint,
+			Region:    cfg.Files.S3Region,
+			Bucket:    cfg.Files.S3Bucket,
+			AccessKey: cfg.Files.S3AccessKey,
+			SecretKey: cfg.Files.S3SecretKey,
+			PathStyle: cfg.Files.S3PathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "local", "":
+		store, err := blob.NewLocal(filepath.Join(cfg.DataDirectory, "files"))
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+	return nil, fmt.Errorf("unknown file store %q", cfg.Files.Backend)
+}
+
 // Close close all the necessary
 func (e *Env) Close() {
 	//e.Storage.Close()
+	if e.monitor != nil {
+		e.monitor.Stop()
+	}
+	e.Outbound.Stop()
 	e.casbinDB.Close()
-	e.inboundMail.Close()
+	e.Mailboxes.Close()
+	if e.Cache != nil {
+		e.Cache.Close()
+	}
 }
 
 // ReloadPolicies - reloads all the casbin policies stored into memory
@@ -0,0 +1,22 @@
+package email
+
+import (
+	"context"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// EscalationNotifier - mails the agents of the escalated tickets, the mails go through the queue of Outbound
+type EscalationNotifier struct {
+	outbound *Outbound
+}
+
+// NewEscalationNotifier - creates the notifier of the escalation monitor
+func NewEscalationNotifier(outbound *Outbound) *EscalationNotifier {
+	return &EscalationNotifier{outbound: outbound}
+}
+
+// Notify - queues the mails of the step reached by the ticket for its assignee and the agents of the step
+func (n *EscalationNotifier) Notify(ctx context.Context, escalation *model.Escalation, ticket *model.Ticket) error {
+	return n.outbound.Notify(ctx, EventEscalated, ticket.ID, Details{Escalation: escalation})
+}
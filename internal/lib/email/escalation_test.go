@@ -0,0 +1,81 @@
+package email
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+func (f *fakeDB) GetRoleUsers(ctx context.Context, roleID *model.RoleID) ([]*model.User, error) {
+	return f.roleUsers[*roleID], nil
+}
+
+func TestEscalationNotifier(t *testing.T) {
+
+	text := func(s string) *string { return &s }
+	number := 42
+	db := &fakeDB{
+		ticket:  &model.Ticket{ID: "ticket", Code: &number, Subject: text("Printer on fire"), ContactID: "contact", AssignedID: "agent"},
+		contact: &model.Contact{ID: "contact", Email: text("jane@example.com")},
+		users: map[model.UserID]*model.User{
+			"agent": {ID: "agent", Firstname: text("Bob"), Email: text("bob@example.com")},
+			"lead":  {ID: "lead", Firstname: text("Alice"), Lastname: text("Smith"), Email: text("alice@example.com")},
+		},
+		roleUsers: map[model.RoleID][]*model.User{
+			"supervisors": {
+				{ID: "lead", Email: text("Alice@example.com")},
+				{ID: "supervisor", Firstname: text("Carol"), Email: text("carol@example.com")},
+				{ID: "no-email"},
+			},
+		},
+	}
+	notifier := NewEscalationNotifier(NewOutbound(db, time.Minute, 10))
+
+	tests := []struct {
+		name       string
+		escalation *model.Escalation
+		wantTo     []string
+		wantBody   []string
+	}{
+		{
+			name:       "breached",
+			escalation: &model.Escalation{Name: text("Supervisor"), Level: func() *int { l := 2; return &l }(), TriggerOn: text(model.EscalateOnBreached), AssignTo: "lead", AssignRoleID: "supervisors", Note: text("Call the customer.")},
+			wantTo:     []string{`"Bob" <bob@example.com>`, `"Alice Smith" <alice@example.com>`, `"Carol" <carol@example.com>`},
+			wantBody:   []string{`Ticket #42 "Printer on fire" missed its deadline`, `level 2 "Supervisor"`, "Call the customer."},
+		},
+		{
+			name:       "at risk",
+			escalation: &model.Escalation{Name: text("Warning"), Level: func() *int { l := 1; return &l }(), TriggerOn: text(model.EscalateOnAtRisk)},
+			wantTo:     []string{`"Bob" <bob@example.com>`},
+			wantBody:   []string{"is about to miss its deadline", `level 1 "Warning"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db.queued = nil
+			if err := notifier.Notify(context.Background(), tt.escalation, db.ticket); err != nil {
+				t.Fatal(err)
+			}
+
+			// the contact is not told about the escalations
+			to := []string{}
+			for _, email := range db.queued {
+				to = append(to, email.To)
+				if email.Event != EventEscalated || email.Subject != "[#42] Escalated: Printer on fire" {
+					t.Errorf("event = %s, subject = %s", email.Event, email.Subject)
+				}
+				for _, want := range tt.wantBody {
+					if !strings.Contains(email.Body, want) {
+						t.Errorf("body = %q, want %q in it", email.Body, want)
+					}
+				}
+			}
+			if strings.Join(to, ", ") != strings.Join(tt.wantTo, ", ") {
+				t.Errorf("to = %v, want %v", to, tt.wantTo)
+			}
+		})
+	}
+}
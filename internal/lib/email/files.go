@@ -0,0 +1,67 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/blob"
+	"github.com/sirupsen/logrus"
+)
+
+// saveFiles - writes the files of a mail to the file store, the ones over the limits are left out
+// and the attachments among them are named in skipped
+func (i *InboundMail) saveFiles(ctx context.Context, message *Message) (files []*model.NoteFile, skipped []string, err error) {
+
+	for _, attachment := range message.Files {
+		size := int64(len(attachment.Content))
+		reason := ""
+		if i.files == nil {
+			reason = "no file store"
+		} else if err := i.limits.Check(attachment.ContentType, size); err != nil {
+			reason = err.Error()
+		}
+		if reason != "" {
+			logrus.WithFields(logrus.Fields{"InboundMailID": i.info.ID, "Filename": attachment.Filename, "Reason": reason}).Info("Leaving out a mail file")
+			if !attachment.Inline {
+				skipped = append(skipped, fmt.Sprintf("%s (%s)", attachment.Filename, reason))
+			}
+			continue
+		}
+
+		key := blob.NewKey("tickets")
+		if err := i.files.Put(ctx, key, bytes.NewReader(attachment.Content), size); err != nil {
+			i.deleteFiles(files)
+			return nil, nil, err
+		}
+		files = append(files, &model.NoteFile{
+			Filename:   attachment.Filename,
+			MimeType:   attachment.ContentType,
+			Size:       size,
+			StorageKey: key,
+			ContentID:  attachment.ContentID,
+			Inline:     attachment.Inline,
+			UserID:     i.info.UserID,
+		})
+	}
+	return files, skipped, nil
+}
+
+// deleteFiles - removes the contents of files that were not recorded
+func (i *InboundMail) deleteFiles(files []*model.NoteFile) {
+	for _, file := range files {
+		if err := i.files.Delete(context.Background(), file.StorageKey); err != nil {
+			logrus.WithError(err).WithField("Key", file.StorageKey).Warn("Deleting a mail file")
+		}
+	}
+}
+
+// skippedFiles - tells the agents about the attachments that were not kept
+func skippedFiles(skipped []string) string {
+	if len(skipped) == 0 {
+		return ""
+	}
+	return "\n\nAttachments not kept: " + strings.Join(skipped, ", ")
+}
@@ -0,0 +1,56 @@
+package email
+
+import (
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-imap/responses"
+)
+
+// idleCommand - the IDLE command of RFC 2177, the server pushes the changes of the selected
+// mailbox until DONE is sent
+type idleCommand struct{}
+
+func (cmd *idleCommand) Command() *imap.Command {
+	return &imap.Command{Name: "IDLE"}
+}
+
+// idleResponse - sends DONE once the server accepted the IDLE and stop is closed
+type idleResponse struct {
+	stop    <-chan struct{}
+	replies chan []byte
+	started bool
+}
+
+func (r *idleResponse) Replies() <-chan []byte {
+	return r.replies
+}
+
+func (r *idleResponse) Handle(resp imap.Resp) error {
+	// the server accepts the IDLE with a continuation request
+	if _, ok := resp.(*imap.ContinuationReq); ok && !r.started {
+		r.started = true
+		go func() {
+			<-r.stop
+			r.replies <- []byte("DONE\r\n")
+		}()
+		return nil
+	}
+	return responses.ErrUnhandled
+}
+
+// idle - runs the IDLE command until stop is closed, the updates of the mailbox are sent to the
+// Updates channel of the client
+func idle(c *client.Client, stop <-chan struct{}) error {
+
+	// the IDLE lasts longer than the timeout of the commands
+	timeout := c.Timeout
+	c.Timeout = 0
+	defer func() { c.Timeout = timeout }()
+
+	resp := &idleResponse{stop: stop, replies: make(chan []byte, 1)}
+	status, err := c.Execute(&idleCommand{}, resp)
+	if err != nil {
+		return err
+	}
+	return status.Err()
+}
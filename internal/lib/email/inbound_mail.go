@@ -3,183 +3,341 @@ package email
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/emersion/go-imap"
 	"github.com/emersion/go-imap/client"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/blob"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
 	"github.com/sirupsen/logrus"
 )
 
-// InboundMail - holds connections to all the inbound mail accounrts
+const (
+	// idleRefresh - the IDLE is restarted before the servers drop it, they may after 30 minutes
+	idleRefresh = 25 * time.Minute
+	// the wait before reconnecting doubles on every failure
+	minBackoff = 30 * time.Second
+	maxBackoff = 30 * time.Minute
+)
+
+// InboundMail - holds the connection to an inbound mail account
 type InboundMail struct {
 	client     *client.Client
-	loggedOut  bool
 	info       *model.InboudMail
 	updateChan chan client.Update
+	changed    chan struct{} // the server told about new mails
 	db         database.Database
-}
+	files      blob.Store // keeps the files of the mails
+	limits     blob.Limits
+	stop       chan struct{}
+	done       chan struct{}
+	failures   int // the connections that failed in a row
 
-// Init - create connection to the inbount mail
-func Init(db database.Database) (*InboundMail, error) {
+	mu        sync.Mutex
+	connected bool
+	idling    bool
+}
 
-	ctx := context.Background()
-	ctx, cancelFunc := context.WithDeadline(ctx, time.Now().Add(2*time.Second)) //Expires the context when is more than 2 Seconds
-	defer cancelFunc()
-	inboundMail := InboundMail{}
-	inboundMail.db = db
-	info, err := db.GetInboundMail(ctx)
-	if err != nil {
-		return nil, err
+// newInboundMail - create the poller of a mailbox, Listen connects to the IMAP server
+func newInboundMail(db database.Database, files blob.Store, limits blob.Limits, info *model.InboudMail) *InboundMail {
+	i := &InboundMail{
+		db:         db,
+		files:      files,
+		limits:     limits,
+		info:       info,
+		updateChan: make(chan client.Update, 15),
+		changed:    make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
 	}
-	inboundMail.updateChan = make(chan client.Update, 15)
-	inboundMail.info = info
-	// Attempt to connect to the IMAP Server
-
-	return &inboundMail, nil
-
+	go i.watch()
+	return i
 }
 
-// Close - logs the user out the email and closes all the channels
+// Close - waits for the running poll, logs the user out the email and closes all the channels
 func (i *InboundMail) Close() {
-	i.client.Logout()
+	close(i.stop)
+	<-i.done
+	if i.client != nil {
+		i.client.Logout()
+	}
 	close(i.updateChan)
 }
 
-// Listen - Connects to the IMAP server and listens for new mails
-func (i *InboundMail) Listen() {
-	logger := logrus.WithField("func", "InboundMail -> Listen()")
-	//Connect
-	err := i.connect()
-	if err != nil {
-		// Do not bother polling
-		return
+// watch - reads the updates of the client, which blocks when they are not read, and wakes the
+// poller up when the mailbox has new mails
+func (i *InboundMail) watch() {
+	for update := range i.updateChan {
+		if _, ok := update.(*client.MailboxUpdate); !ok {
+			continue
+		}
+		// the commands like SELECT send updates too, only the ones pushed during IDLE are new mails
+		if _, idling := i.State(); !idling {
+			continue
+		}
+		select {
+		case i.changed <- struct{}{}:
+		default:
+		}
 	}
-	//get message when client gets logged out
-	go func() {
-		for {
-			select {
-			case <-i.client.LoggedOut():
-				logger.Print("Email just logged out")
-				i.loggedOut = true
+}
+
+// State - whether the poller is logged in and waits on IDLE
+func (i *InboundMail) State() (connected, idling bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.connected, i.idling
+}
+
+// Listen - Connects to the IMAP server and fetches the new mails, the server tells about them
+// when it supports IDLE, otherwise they are polled
+func (i *InboundMail) Listen() {
+	logger := logrus.WithFields(logrus.Fields{"func": "InboundMail -> Listen()", "InboundMailID": i.info.ID})
+	defer close(i.done)
+
+	for {
+		wait := time.Duration(*i.info.PollPeriod) * time.Minute
+		connected, _ := i.State()
+		if !connected {
+			if err := i.connect(); err != nil {
+				wait = i.backoff()
+				logger.WithError(err).WithField("Retry", wait).Info("Reconnecting to the mail server")
 			}
 		}
+
+		if connected, _ := i.State(); connected {
+			i.FetchNewMails()
+			if ok, _ := i.client.Support("IDLE"); ok {
+				if err := i.idle(wait); err != nil {
+					logger.WithError(err).Info("Waiting for new mails")
+				}
+				select {
+				case <-i.stop:
+					logger.Info("Stopped listening for new mails")
+					return
+				default:
+				}
+				continue
+			}
+		}
+
+		select {
+		case <-i.stop:
+			logger.Info("Stopped listening for new mails")
+			return
+		case <-i.changed:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// idle - waits for new mails with IDLE, the poll period still fetches the mails in case the
+// server missed telling about some
+func (i *InboundMail) idle(wait time.Duration) error {
+
+	if wait <= 0 || wait > idleRefresh {
+		wait = idleRefresh
+	}
+	i.setIdling(true)
+	defer i.setIdling(false)
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- idle(i.client, stop)
 	}()
 
-	// listen for updates from the client
-	/*
-	   go func() {
-
-	   		for update := range i.updateChan {
-	   			switch update.(type) {
-	   			case *client.StatusUpdate:
-	   				statusUpdate := update.(*client.StatusUpdate)
-	   				log.Printf("just recevied a status update\n%+v \n", statusUpdate)
-	   				// log.Println("Just Recived => StatusUpdate Update")
-	   				break
-	   			case *client.MailboxUpdate:
-	   				mailboxUpdate := update.(*client.MailboxUpdate)
-	   				log.Printf("just recevied a mailbox update \n%+v \n", mailboxUpdate)
-	   				// log.Println("Just Recived => MailboxUpdate Update")
-	   				break
-	   			case *client.ExpungeUpdate:
-	   				expungeUpdate := update.(*client.ExpungeUpdate)
-	   				log.Printf("just recevied an expnge update \n%+v \n", expungeUpdate)
-	   				// log.Println("Just Recived => ExpungeUpdate Update")
-	   				break
-	   			case *client.MessageUpdate:
-	   				messageUpdate := update.(*client.MessageUpdate)
-	   				log.Printf("just recevied a Message update \n%+v \n", messageUpdate)
-	   				// log.Println("Just Recived => MessageUpdate Update")
-	   				break
-	   			}
-	   		}
-	   	}()
-	*/
-	// Now fetch mails for the poll period
-
-	// go func(){
-	for {
-		i.FetchNewMails()
-		time.Sleep(time.Duration(*i.info.PollPeriod) * time.Minute)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		close(stop)
+		return err
+	case <-i.changed:
+	case <-timer.C:
+	case <-i.stop:
 	}
-	// }()
+	close(stop)
+	return <-done
+}
 
+// backoff - the wait before the next connection
+func (i *InboundMail) backoff() time.Duration {
+	failures := i.failures
+	if failures > 10 {
+		failures = 10
+	}
+	delay := minBackoff << uint(failures-1)
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
 }
 
-// FetchNewMails - checks the imap server for new mails
+func (i *InboundMail) setIdling(idling bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.idling = idling
+}
+
+// FetchNewMails - checks the imap server for the mails after the last UID and turns them into tickets
 func (i *InboundMail) FetchNewMails() {
-	// Now update the last sequnce on the database
 	ctx := context.Background()
-	ctx, cancelFunc := context.WithDeadline(ctx, time.Now().Add(3*time.Second)) //Expires the context when is more than 2 Seconds
+	ctx, cancelFunc := context.WithDeadline(ctx, time.Now().Add(time.Minute)) //Expires the context when the mails take more than a minute
 	defer cancelFunc()
 
-	logger := logrus.WithField("func", "InboundMail -> FetchNewMails()")
-	// Select INBOX
+	logger := logrus.WithFields(logrus.Fields{"func": "InboundMail -> FetchNewMails()", "InboundMailID": i.info.ID})
 
-	//Check if the client is stil logged in
-	if i.loggedOut {
-		err := i.connect()
-		if err != nil {
-			logger.WithField("Err", err.Error()).Info("Reconnecting to the mail server")
-		}
-	}
 	mbox, err := i.client.Select(*i.info.Mailbox, false)
 	if err != nil {
-		errMessage := fmt.Sprintf("Selecting mailbox mails error: %s", err.Error())
-		i.info.Status = func() *string { s := errMessage; return &s }()
-		i.db.UpdateInboudMail(ctx, i.info)
 		logger.WithField("Err", err.Error()).Info("Selecting the mailbox")
+		i.fail(ctx, "Selecting mailbox mails error", err)
+		return
+	}
 
+	// the UIDs of the mailbox are only valid with its UIDVALIDITY
+	if mbox.UidValidity != i.info.UIDValidity {
+		lastUID := uint32(0)
+		if i.info.UIDValidity == 0 && i.info.LastSeq != nil && *i.info.LastSeq > 0 {
+			// the mailboxes synced by sequence number carry on after the last mail they read
+			lastUID, err = i.sequenceUID(uint32(*i.info.LastSeq), mbox.Messages)
+			if err != nil {
+				logger.WithField("Err", err.Error()).Info("Finding the UID of the last mail")
+				i.fail(ctx, "Fetcing mails error", err)
+				return
+			}
+		} else if i.info.UIDValidity != 0 {
+			// the mails received before are skipped by their Message-ID
+			logger.WithField("UIDValidity", mbox.UidValidity).Warn("The mailbox was recreated, reading all its mails")
+		}
+		i.info.UIDValidity = mbox.UidValidity
+		i.info.LastUID = lastUID
 	}
 
 	// check if there is a new mail after the last one
-	if mbox.Messages == uint32(*i.info.LastSeq) {
+	if mbox.Messages == 0 || (mbox.UidNext != 0 && mbox.UidNext <= i.info.LastUID+1) {
+		i.synced(ctx)
 		return
 	}
 
-	// LastSeq represents the seq  of the last mail the server is aware of + 1 because the sequence is zero indexed
-	from := uint32(*i.info.LastSeq + 1) //uint32(1)
-	to := mbox.Messages
-
 	seqset := new(imap.SeqSet)
-	seqset.AddRange(from, to)
+	seqset.AddRange(i.info.LastUID+1, 0)
+	// Peek keeps the mails unread for the other mail clients
+	section := &imap.BodySectionName{Peek: true}
 	messages := make(chan *imap.Message, 20)
 	done := make(chan error, 1)
 	go func() {
-		done <- i.client.Fetch(seqset, []imap.FetchItem{imap.FetchEnvelope}, messages)
+		done <- i.client.UidFetch(seqset, []imap.FetchItem{imap.FetchUid, section.FetchItem()}, messages)
 	}()
 
+	// the mails are read before creating the tickets so the connection is not held by the database
+	fetched := []*imap.Message{}
 	for msg := range messages {
-		// TODO: Create ticket using certain criteria (Mostyly using the mail subjects)
-		/* 		println("\n\n\n\n")
-		   		log.Println("* Subject		: " + msg.Envelope.Subject)
-		   		log.Println("* InReplyTo	: " + msg.Envelope.InReplyTo)
-		   		log.Println("* MessageId	: " + msg.Envelope.MessageId)
-		   		log.Printf("* Seq			: %+v\n", msg.SeqNum)
-		   		log.Printf("* Flags			: %+v\n", msg.Flags)
-		   		log.Printf("* UID			: %+v\n", msg.Uid)
-		   		log.Printf("* Size			: %+v\n", msg.Size)
-		   		log.Printf("* InternalDate	: %+v", msg.InternalDate)
-		*/
-		//log.Printf("\n\n* Envelope %v\n\n\n", msg.Envelope)
-		// update the new email seqID
-		if msg.SeqNum > uint32(*i.info.LastSeq) {
-			i.info.LastSeq = func() *int { u := int(msg.SeqNum); return &u }()
+		// the range ending with * always returns the last mail
+		if msg.Uid > i.info.LastUID {
+			fetched = append(fetched, msg)
 		}
 	}
+	if err := <-done; err != nil {
+		logger.WithField("Err", err.Error()).Info("Fetching new mails")
+		i.fail(ctx, "Fetcing mails error", err)
+		return
+	}
+	sort.Slice(fetched, func(a, b int) bool { return fetched[a].Uid < fetched[b].Uid })
 
+	received := new(imap.SeqSet)
+	for _, msg := range fetched {
+		logger := logger.WithField("UID", msg.Uid)
+		if body := msg.GetBody(section); body != nil {
+			err := i.receive(ctx, body)
+			if err == apiErr.ErrTicketEmailExists {
+				logger.Info("Mail was already received")
+			} else if rejected, ok := err.(rejectedError); ok {
+				// the mail is left on the server and skipped, it would keep the next ones from being received
+				logger.WithError(rejected.err).Warn("Skipping a mail that cannot be received")
+				i.fail(ctx, fmt.Sprintf("Skipped mail %d", msg.Uid), rejected.err)
+				i.info.LastUID = msg.Uid
+				continue
+			} else if err != nil {
+				// the database or the deadline failed, the mail is read again on the next poll
+				logger.WithError(err).Warn("Receiving a mail")
+				i.fail(ctx, "Receiving mail error", err)
+				break
+			} else {
+				processed := int64(1)
+				if i.info.MessagesProcessed != nil {
+					processed += *i.info.MessagesProcessed
+				}
+				i.info.MessagesProcessed = &processed
+			}
+		}
+		i.info.LastUID = msg.Uid
+		received.AddNum(msg.Uid)
+	}
+
+	if i.info.DeleteSeen != nil && *i.info.DeleteSeen && !received.Empty() {
+		if err := i.deleteMails(received); err != nil {
+			logger.WithError(err).Warn("Deleting the received mails")
+		}
+	}
+	i.synced(ctx)
+}
 
-	i.db.UpdateInboudMail(ctx, i.info)
+// sequenceUID - the UID of the mail at a sequence number, the mails may have been expunged since
+func (i *InboundMail) sequenceUID(seqNum, messages uint32) (uint32, error) {
 
-	if err := <-done; err != nil {
-		errMessage := fmt.Sprintf("Fetcing mails error: %s", err.Error())
-		i.info.Status = func() *string { s := errMessage; return &s }()
-		i.db.UpdateInboudMail(ctx, i.info)
-		logger.WithField("Err", err.Error()).Info("Fetching new mails")
+	if seqNum > messages {
+		seqNum = messages
 	}
+	if seqNum == 0 {
+		return 0, nil
+	}
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(seqNum)
+	messagesChan := make(chan *imap.Message, 1)
+	if err := i.client.Fetch(seqset, []imap.FetchItem{imap.FetchUid}, messagesChan); err != nil {
+		return 0, err
+	}
+	uid := uint32(0)
+	for msg := range messagesChan {
+		uid = msg.Uid
+	}
+	return uid, nil
+}
+
+// deleteMails - removes the received mails from the server
+func (i *InboundMail) deleteMails(uids *imap.SeqSet) error {
 
+	item := imap.FormatFlagsOp(imap.AddFlags, true)
+	if err := i.client.UidStore(uids, item, []interface{}{imap.DeletedFlag}, nil); err != nil {
+		return err
+	}
+	return i.client.Expunge(nil)
+}
+
+// synced - saves the position of the sync
+func (i *InboundMail) synced(ctx context.Context) {
+	i.info.LastSynced = func() *time.Time { t := time.Now(); return &t }()
+	i.saveStatus(ctx)
+}
+
+// fail - saves the error of the mailbox, it is shown in its health
+func (i *InboundMail) fail(ctx context.Context, message string, err error) {
+	errMessage := fmt.Sprintf("%s: %s", message, err.Error())
+	i.info.Status = &errMessage
+	i.info.LastError = &errMessage
+	i.info.LastErrorAt = func() *time.Time { t := time.Now(); return &t }()
+	i.saveStatus(ctx)
+}
+
+func (i *InboundMail) saveStatus(ctx context.Context) {
+	if err := i.db.UpdateInboundMailStatus(ctx, i.info); err != nil {
+		logrus.WithError(err).WithField("InboundMailID", i.info.ID).Warn("Saving the mailbox status")
+	}
 }
 
 func (i *InboundMail) connect() error {
@@ -188,40 +346,73 @@ func (i *InboundMail) connect() error {
 	ctx, cancelFunc := context.WithDeadline(ctx, time.Now().Add(3*time.Second)) //Expires the context when is more than 2 Seconds
 	defer cancelFunc()
 
-	logger := logrus.WithField("func", "InboundMail -> connect()")
+	logger := logrus.WithFields(logrus.Fields{"func": "InboundMail -> connect()", "InboundMailID": i.info.ID})
+	c, err := dialIMAP(i.info)
+	if err != nil {
+		logger.Error(err)
+		i.failures++
+		i.fail(ctx, "Connection error", err)
+		return err
+	}
+	i.failures = 0
+
+	//pass the update channel to the client connection
+	c.Updates = i.updateChan
+	i.mu.Lock()
+	i.client = c
+	i.connected = true
+	i.mu.Unlock()
+
+	//get message when client gets logged out
+	go func() {
+		<-c.LoggedOut()
+		logger.Print("Email just logged out")
+		i.mu.Lock()
+		if i.client == c {
+			i.connected = false
+		}
+		i.mu.Unlock()
+	}()
+
+	// Update the mail box status
+	i.info.Status = func() *string { s := "Connected"; return &s }()
+	i.saveStatus(ctx)
+	return nil
+}
+
+// dialIMAP - connects and logs in to the IMAP server of a mailbox
+func dialIMAP(info *model.InboudMail) (*client.Client, error) {
+
 	var c *client.Client
 	var err error
-	address := fmt.Sprintf("%s:%d", *i.info.Address, *i.info.Port)
-	if *i.info.Secured {
+	address := fmt.Sprintf("%s:%d", *info.Address, *info.Port)
+	if *info.Secured {
 		c, err = client.DialTLS(address, nil)
 	} else {
 		c, err = client.Dial(address)
 	}
 	if err != nil {
-		logger.Error(err)
-		errMessage := fmt.Sprintf("Connection error: %s", err.Error())
-		i.info.Status = func() *string { s := errMessage; return &s }()
-		i.db.UpdateInboudMail(ctx, i.info)
-		return err
+		return nil, err
 	}
+	c.Timeout = time.Minute
 
-	logger.Info("Mail Server Connected; Now logging in...")
 	// Login
-	if err := c.Login(*i.info.EmailUser, *i.info.EmailSecret); err != nil {
-		logger.Error(err)
-		errMessage := fmt.Sprintf("Connection error: %s", err.Error())
-		i.info.Status = func() *string { s := errMessage; return &s }()
-		i.db.UpdateInboudMail(ctx, i.info)
-		return err
+	if err := c.Login(*info.EmailUser, *info.EmailSecret); err != nil {
+		c.Logout()
+		return nil, err
 	}
-	i.client = c
-	//pass the update channel to the client connection
-	i.client.Updates = i.updateChan
-	i.loggedOut = false
+	return c, nil
+}
 
-	// Update the mail box status
+// TestConnection - checks that the mailbox can be opened with its settings
+func TestConnection(info *model.InboudMail) error {
 
-	i.info.Status = func() *string { s := "Connected"; return &s }()
-	i.db.UpdateInboudMail(ctx, i.info)
-	return nil
+	c, err := dialIMAP(info)
+	if err != nil {
+		return err
+	}
+	defer c.Logout()
+
+	_, err = c.Select(*info.Mailbox, true)
+	return err
 }
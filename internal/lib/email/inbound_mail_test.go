@@ -0,0 +1,407 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-imap/server"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+func (f *fakeDB) FindEmailTicket(ctx context.Context, messageIDs []string, number int, from string) (model.TicketID, error) {
+	if f.findTicket == nil {
+		return "ticket", nil
+	}
+	return f.findTicket(messageIDs, number, from)
+}
+
+func (f *fakeDB) CreateEmailNote(ctx context.Context, note *model.Note, email *model.TicketEmail, files []*model.NoteFile) error {
+	if err := f.noteErr; err != nil {
+		f.noteErr = nil
+		return err
+	}
+	for _, messageID := range f.received {
+		if messageID == email.MessageID {
+			return apiErr.ErrTicketEmailExists
+		}
+	}
+	f.received = append(f.received, email.MessageID)
+	f.notes = append(f.notes, note)
+	f.emails = append(f.emails, email)
+	return nil
+}
+
+func (f *fakeDB) UpdateInboundMailStatus(ctx context.Context, inboundMail *model.InboudMail) error {
+	return nil
+}
+
+// imapBackend - the memory backend of go-imap with a UIDVALIDITY that can change, its UIDs are
+// not reused once the mails are expunged
+type imapBackend struct {
+	*memory.Backend
+
+	mu          sync.Mutex
+	uidValidity uint32
+	uidNext     uint32
+}
+
+func (b *imapBackend) Login(connInfo *imap.ConnInfo, username, password string) (backend.User, error) {
+	user, err := b.Backend.Login(connInfo, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return &imapUser{User: user, backend: b}, nil
+}
+
+// recreate - the mailbox is deleted and created again, the UIDs seen before are no longer valid
+func (b *imapBackend) recreate(uidValidity uint32) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.uidValidity = uidValidity
+}
+
+type imapUser struct {
+	backend.User
+	backend *imapBackend
+}
+
+func (u *imapUser) GetMailbox(name string) (backend.Mailbox, error) {
+	mailbox, err := u.User.GetMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+	return &imapMailbox{Mailbox: mailbox, backend: u.backend}, nil
+}
+
+type imapMailbox struct {
+	backend.Mailbox
+	backend *imapBackend
+}
+
+func (m *imapMailbox) Status(items []imap.StatusItem) (*imap.MailboxStatus, error) {
+	status, err := m.Mailbox.Status(items)
+	if err != nil {
+		return nil, err
+	}
+	m.backend.mu.Lock()
+	defer m.backend.mu.Unlock()
+	status.UidValidity = m.backend.uidValidity
+	status.UidNext = m.backend.uidNext
+	return status, nil
+}
+
+func (m *imapMailbox) CreateMessage(flags []string, date time.Time, body imap.Literal) error {
+	if err := m.Mailbox.CreateMessage(flags, date, body); err != nil {
+		return err
+	}
+	m.backend.mu.Lock()
+	defer m.backend.mu.Unlock()
+	messages := m.Mailbox.(*memory.Mailbox).Messages
+	messages[len(messages)-1].Uid = m.backend.uidNext
+	m.backend.uidNext++
+	return nil
+}
+
+// newIMAPServer - an in-process IMAP server with an empty INBOX, the mails are delivered with
+// the returned client
+func newIMAPServer(t *testing.T) (*imapBackend, *model.InboudMail, *client.Client) {
+	t.Helper()
+
+	memoryBackend := memory.New()
+	user, err := memoryBackend.Login(nil, "username", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inbox, err := user.GetMailbox("INBOX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the memory backend starts with a mail
+	inbox.(*memory.Mailbox).Messages = nil
+
+	imapBackend := &imapBackend{Backend: memoryBackend, uidValidity: 1, uidNext: 1}
+	imapServer := server.New(imapBackend)
+	imapServer.AllowInsecureAuth = true
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go imapServer.Serve(listener)
+	t.Cleanup(func() { imapServer.Close() })
+
+	host, port, secured := "127.0.0.1", listener.Addr().(*net.TCPAddr).Port, false
+	username, password, mailbox := "username", "password", "INBOX"
+	info := &model.InboudMail{
+		ID:          "mailbox",
+		Address:     &host,
+		Port:        &port,
+		Secured:     &secured,
+		EmailUser:   &username,
+		EmailSecret: &password,
+		Mailbox:     &mailbox,
+	}
+
+	admin, err := dialIMAP(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Logout() })
+	return imapBackend, info, admin
+}
+
+// newTestInboundMail - the poller of the mailbox, logged in without the background goroutines
+func newTestInboundMail(t *testing.T, db *fakeDB, info *model.InboudMail) *InboundMail {
+	t.Helper()
+
+	c, err := dialIMAP(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Logout() })
+	return &InboundMail{client: c, db: db, info: info}
+}
+
+// deliver - appends the mails with these Message-IDs to the INBOX
+func deliver(t *testing.T, admin *client.Client, messageIDs ...string) {
+	t.Helper()
+
+	for _, messageID := range messageIDs {
+		raw := fmt.Sprintf("From: Jane Doe <jane@example.com>\r\n"+
+			"To: support@example.com\r\n"+
+			"Subject: Mail %s\r\n"+
+			"Message-ID: <%s>\r\n"+
+			"Content-Type: text/plain\r\n"+
+			"\r\n"+
+			"Hello from %s\r\n", messageID, messageID, messageID)
+		appendMail(t, admin, raw)
+	}
+}
+
+func appendMail(t *testing.T, admin *client.Client, raw string) {
+	t.Helper()
+	if err := admin.Append("INBOX", nil, time.Now(), bytes.NewBufferString(raw)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expunge - removes the mails with these UIDs from the INBOX
+func expunge(t *testing.T, admin *client.Client, uids ...uint32) {
+	t.Helper()
+
+	if _, err := admin.Select("INBOX", false); err != nil {
+		t.Fatal(err)
+	}
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+	item := imap.FormatFlagsOp(imap.AddFlags, true)
+	if err := admin.UidStore(seqset, item, []interface{}{imap.DeletedFlag}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.Expunge(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// inboxUIDs - the UIDs left in the INBOX
+func inboxUIDs(t *testing.T, admin *client.Client) []uint32 {
+	t.Helper()
+
+	if _, err := admin.Select("INBOX", true); err != nil {
+		t.Fatal(err)
+	}
+	uids, err := admin.UidSearch(imap.NewSearchCriteria())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uids
+}
+
+func TestFetchNewMails(t *testing.T) {
+
+	_, info, admin := newIMAPServer(t)
+	db := &fakeDB{}
+	inbound := newTestInboundMail(t, db, info)
+
+	deliver(t, admin, "1@example.com", "2@example.com", "3@example.com")
+	inbound.FetchNewMails()
+
+	checkReceived(t, db, "1@example.com", "2@example.com", "3@example.com")
+	checkSync(t, info, 1, 3, 3)
+	if info.LastSynced == nil || info.LastError != nil {
+		t.Errorf("last synced = %v, last error = %v", info.LastSynced, value(info.LastError))
+	}
+
+	// nothing new, the last mail is not read again
+	inbound.FetchNewMails()
+	checkReceived(t, db, "1@example.com", "2@example.com", "3@example.com")
+
+	deliver(t, admin, "4@example.com")
+	inbound.FetchNewMails()
+	checkReceived(t, db, "1@example.com", "2@example.com", "3@example.com", "4@example.com")
+	checkSync(t, info, 1, 4, 4)
+
+	// the mails are left on the server
+	if uids := inboxUIDs(t, admin); len(uids) != 4 {
+		t.Errorf("INBOX = %v, want the four mails", uids)
+	}
+}
+
+func TestFetchNewMailsUIDValidityChange(t *testing.T) {
+
+	imapBackend, info, admin := newIMAPServer(t)
+	db := &fakeDB{}
+	inbound := newTestInboundMail(t, db, info)
+
+	deliver(t, admin, "1@example.com", "2@example.com")
+	inbound.FetchNewMails()
+	checkSync(t, info, 1, 2, 2)
+
+	// the mailbox was recreated, its UIDs no longer match the ones seen before
+	imapBackend.recreate(7)
+	deliver(t, admin, "3@example.com")
+	inbound.FetchNewMails()
+
+	// all the mails are read again, the ones already received are recognized by their Message-ID
+	checkReceived(t, db, "1@example.com", "2@example.com", "3@example.com")
+	checkSync(t, info, 7, 3, 3)
+}
+
+func TestFetchNewMailsFromLastSeq(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		lastSeq int
+		want    []string
+		lastUID uint32
+	}{
+		// the mails with the UIDs 1 and 2 were expunged, the second mail of the mailbox has the UID 4
+		{"after the sequence number", 2, []string{"5@example.com"}, 5},
+		{"sequence number past the end", 10, nil, 5},
+		{"nothing read yet", 0, []string{"3@example.com", "4@example.com", "5@example.com"}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, info, admin := newIMAPServer(t)
+			deliver(t, admin, "1@example.com", "2@example.com", "3@example.com", "4@example.com", "5@example.com")
+			expunge(t, admin, 1, 2)
+
+			// a mailbox synced by sequence number before the UIDs were kept
+			info.LastSeq = &tt.lastSeq
+			db := &fakeDB{}
+			newTestInboundMail(t, db, info).FetchNewMails()
+
+			checkReceived(t, db, tt.want...)
+			checkSync(t, info, 1, tt.lastUID, int64(len(tt.want)))
+		})
+	}
+}
+
+func TestFetchNewMailsDeleteSeen(t *testing.T) {
+
+	_, info, admin := newIMAPServer(t)
+	deleteSeen := true
+	info.DeleteSeen = &deleteSeen
+	db := &fakeDB{}
+	inbound := newTestInboundMail(t, db, info)
+
+	deliver(t, admin, "1@example.com", "2@example.com")
+	inbound.FetchNewMails()
+	checkReceived(t, db, "1@example.com", "2@example.com")
+	if uids := inboxUIDs(t, admin); len(uids) != 0 {
+		t.Errorf("INBOX = %v, want the received mails expunged", uids)
+	}
+
+	// the UIDs are not reused after the expunge
+	deliver(t, admin, "3@example.com")
+	inbound.FetchNewMails()
+	checkReceived(t, db, "1@example.com", "2@example.com", "3@example.com")
+	checkSync(t, info, 1, 3, 3)
+	if uids := inboxUIDs(t, admin); len(uids) != 0 {
+		t.Errorf("INBOX = %v, want the received mails expunged", uids)
+	}
+}
+
+func TestFetchNewMailsRejected(t *testing.T) {
+
+	_, info, admin := newIMAPServer(t)
+	deleteSeen := true
+	info.DeleteSeen = &deleteSeen
+	db := &fakeDB{}
+	inbound := newTestInboundMail(t, db, info)
+
+	deliver(t, admin, "1@example.com")
+	// a mail without a sender cannot become a ticket
+	appendMail(t, admin, "Subject: No sender\r\nMessage-ID: <2@example.com>\r\n\r\nHello\r\n")
+	deliver(t, admin, "3@example.com")
+	inbound.FetchNewMails()
+
+	// the mails after it are received
+	checkReceived(t, db, "1@example.com", "3@example.com")
+	checkSync(t, info, 1, 3, 2)
+	if info.LastError == nil || !strings.Contains(*info.LastError, "Skipped mail 2") {
+		t.Errorf("last error = %s, want the skipped mail", value(info.LastError))
+	}
+	// the skipped mail is left on the server
+	if uids := inboxUIDs(t, admin); fmt.Sprint(uids) != "[2]" {
+		t.Errorf("INBOX = %v, want the skipped mail", uids)
+	}
+}
+
+func TestFetchNewMailsTransientError(t *testing.T) {
+
+	_, info, admin := newIMAPServer(t)
+	db := &fakeDB{}
+	inbound := newTestInboundMail(t, db, info)
+
+	deliver(t, admin, "1@example.com")
+	inbound.FetchNewMails()
+
+	deliver(t, admin, "2@example.com", "3@example.com")
+	db.noteErr = context.DeadlineExceeded
+	inbound.FetchNewMails()
+
+	// the sync stops at the failed mail
+	checkReceived(t, db, "1@example.com")
+	checkSync(t, info, 1, 1, 1)
+	if info.LastError == nil || !strings.Contains(*info.LastError, "Receiving mail error") {
+		t.Errorf("last error = %s, want the failure", value(info.LastError))
+	}
+
+	// and reads it again on the next poll
+	inbound.FetchNewMails()
+	checkReceived(t, db, "1@example.com", "2@example.com", "3@example.com")
+	checkSync(t, info, 1, 3, 3)
+}
+
+func checkReceived(t *testing.T, db *fakeDB, messageIDs ...string) {
+	t.Helper()
+	if fmt.Sprint(db.received) != fmt.Sprint(messageIDs) {
+		t.Errorf("received %v, want %v", db.received, messageIDs)
+	}
+}
+
+func checkSync(t *testing.T, info *model.InboudMail, uidValidity, lastUID uint32, processed int64) {
+	t.Helper()
+	if info.UIDValidity != uidValidity || info.LastUID != lastUID {
+		t.Errorf("UIDVALIDITY = %d, last UID = %d, want %d and %d", info.UIDValidity, info.LastUID, uidValidity, lastUID)
+	}
+	got := int64(0)
+	if info.MessagesProcessed != nil {
+		got = *info.MessagesProcessed
+	}
+	if got != processed {
+		t.Errorf("processed %d mails, want %d", got, processed)
+	}
+}
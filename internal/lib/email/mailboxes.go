@@ -0,0 +1,122 @@
+package email
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/blob"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+	"github.com/sirupsen/logrus"
+)
+
+// Mailboxes - runs one poller for every active mailbox
+type Mailboxes struct {
+	db      database.Database
+	files   blob.Store
+	limits  blob.Limits
+	mu      sync.Mutex
+	pollers map[model.InboudMailID]*InboundMail
+}
+
+// NewMailboxes - creates the pollers of the mailboxes, Start runs them, the files of the mails
+// are kept in files within the limits
+func NewMailboxes(db database.Database, files blob.Store, limits blob.Limits) *Mailboxes {
+	return &Mailboxes{
+		db:      db,
+		files:   files,
+		limits:  limits,
+		pollers: map[model.InboudMailID]*InboundMail{},
+	}
+}
+
+// Start - polls the active mailboxes
+func (m *Mailboxes) Start() error {
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	mailboxes, err := m.db.ListActiveInboundMails(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, mailbox := range mailboxes {
+		m.start(mailbox)
+	}
+	return nil
+}
+
+// Reload - restarts the poller of a mailbox after its settings changed, it is stopped when the
+// mailbox was deleted or is not active
+func (m *Mailboxes) Reload(ctx context.Context, inboundMailID model.InboudMailID) error {
+
+	// the mailbox is read under the lock so the latest settings win
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mailbox, err := m.db.GetInboundMailByID(ctx, &inboundMailID)
+	if err != nil {
+		if apiError, ok := err.(apiErr.APIError); !ok || apiError.Code != http.StatusNotFound {
+			return err
+		}
+		mailbox = nil
+	}
+	if poller, ok := m.pollers[inboundMailID]; ok {
+		poller.Close()
+		delete(m.pollers, inboundMailID)
+	}
+	if mailbox != nil && mailbox.IsActive() {
+		m.start(mailbox)
+	}
+	return nil
+}
+
+// Close - stops all the pollers
+func (m *Mailboxes) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, poller := range m.pollers {
+		poller.Close()
+		delete(m.pollers, id)
+	}
+}
+
+// Health - the state of a mailbox, the saved one with the one of its running poller
+func (m *Mailboxes) Health(mailbox *model.InboudMail) *model.MailboxHealth {
+
+	health := model.MailboxHealth{
+		ID:          mailbox.ID,
+		Name:        value(mailbox.Name),
+		Active:      mailbox.IsActive(),
+		Status:      value(mailbox.Status),
+		LastSynced:  mailbox.LastSynced,
+		LastError:   value(mailbox.LastError),
+		LastErrorAt: mailbox.LastErrorAt,
+		LastUID:     mailbox.LastUID,
+	}
+	if mailbox.MessagesProcessed != nil {
+		health.MessagesProcessed = *mailbox.MessagesProcessed
+	}
+
+	m.mu.Lock()
+	poller, ok := m.pollers[mailbox.ID]
+	m.mu.Unlock()
+	if ok {
+		health.Running = true
+		health.Connected, health.Idle = poller.State()
+	}
+	return &health
+}
+
+func (m *Mailboxes) start(mailbox *model.InboudMail) {
+	logrus.WithField("InboundMailID", mailbox.ID).Info("Polling mailbox")
+	poller := newInboundMail(m.db, m.files, m.limits, mailbox)
+	m.pollers[mailbox.ID] = poller
+	go poller.Listen()
+}
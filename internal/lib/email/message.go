@@ -0,0 +1,294 @@
+package email
+
+import (
+	"encoding/base64"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Message - the parts of an email used to create tickets and notes
+type Message struct {
+	MessageID  string
+	InReplyTo  string
+	References []string
+	Subject    string
+	From       *mail.Address
+	Date       time.Time
+	Text       string
+	HTML       string
+	Files      []*Attachment
+}
+
+// Attachment - a file of the mail, the html refers to the inline images by their Content-ID
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Inline      bool
+	Content     []byte
+}
+
+// maxFilename - the length of the file names
+const maxFilename = 255
+
+var headerDecoder = mime.WordDecoder{CharsetReader: charsetReader}
+
+// ParseMessage - reads the headers and the text and html bodies of a raw email
+func ParseMessage(r io.Reader) (*Message, error) {
+
+	raw, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &Message{
+		MessageID:  parseMessageIDs(raw.Header.Get("Message-Id")).first(),
+		InReplyTo:  parseMessageIDs(raw.Header.Get("In-Reply-To")).first(),
+		References: parseMessageIDs(raw.Header.Get("References")),
+	}
+	if message.Subject, err = headerDecoder.DecodeHeader(raw.Header.Get("Subject")); err != nil {
+		message.Subject = raw.Header.Get("Subject")
+	}
+	message.Subject = strings.TrimSpace(message.Subject)
+	if date, err := raw.Header.Date(); err == nil {
+		message.Date = date
+	} else {
+		message.Date = time.Now()
+	}
+
+	parser := mail.AddressParser{WordDecoder: &headerDecoder}
+	if message.From, err = parser.Parse(raw.Header.Get("From")); err != nil {
+		return nil, err
+	}
+
+	if err := message.readPart(textproto.MIMEHeader(raw.Header), raw.Body); err != nil {
+		return nil, err
+	}
+	if message.Text == "" && message.HTML != "" {
+		message.Text = htmlToText(message.HTML)
+	}
+	message.Text = strings.TrimSpace(message.Text)
+	return message, nil
+}
+
+// readPart - walks the multipart tree, keeps the first text and html bodies and the files
+func (m *Message) readPart(header textproto.MIMEHeader, body io.Reader) error {
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	encoding := header.Get("Content-Transfer-Encoding")
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := m.readPart(part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the parts that are not a text body are files, like the named ones and the attachments
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if disposition == "attachment" || filename != "" || (mediaType != "text/plain" && mediaType != "text/html") {
+		content, err := ioutil.ReadAll(decode(body, encoding))
+		if err != nil {
+			return err
+		}
+		contentID := strings.Trim(strings.TrimSpace(header.Get("Content-Id")), "<>")
+		m.Files = append(m.Files, &Attachment{
+			Filename:    fileName(filename, mediaType),
+			ContentType: mediaType,
+			ContentID:   contentID,
+			Inline:      disposition == "inline" || (disposition == "" && contentID != ""),
+			Content:     content,
+		})
+		return nil
+	}
+
+	switch mediaType {
+	case "text/plain":
+		if m.Text != "" {
+			return nil
+		}
+		text, err := decodeBody(body, encoding, params["charset"])
+		if err != nil {
+			return err
+		}
+		m.Text = text
+	case "text/html":
+		if m.HTML != "" {
+			return nil
+		}
+		text, err := decodeBody(body, encoding, params["charset"])
+		if err != nil {
+			return err
+		}
+		m.HTML = text
+	}
+	return nil
+}
+
+// decode - undoes the transfer encoding of a part
+func decode(body io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, newlineSkipper{body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// decodeBody - undoes the transfer encoding and converts the charset to utf-8
+func decodeBody(body io.Reader, encoding, charset string) (string, error) {
+
+	body = decode(body, encoding)
+	if charset != "" {
+		reader, err := charsetReader(charset, body)
+		if err != nil {
+			return "", err
+		}
+		body = reader
+	}
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// charsetReader - converts the charsets that can be handled without extra packages
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252":
+		content, err := ioutil.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(content))
+		for index, b := range content {
+			runes[index] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	// utf-8 and us-ascii, other charsets are read as they are
+	return input, nil
+}
+
+// fileName - the name of a file without its directories, the files without one are named after their type
+func fileName(name, mediaType string) string {
+
+	if decoded, err := headerDecoder.DecodeHeader(name); err == nil {
+		name = decoded
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		if mediaType == "message/rfc822" {
+			return "message.eml"
+		}
+		name = "attachment"
+		if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) != 0 {
+			name += extensions[0]
+		}
+	}
+	if utf8.RuneCountInString(name) > maxFilename {
+		name = string([]rune(name)[:maxFilename])
+	}
+	return name
+}
+
+// newlineSkipper - drops the line breaks of base64 bodies
+type newlineSkipper struct {
+	r io.Reader
+}
+
+func (s newlineSkipper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+var (
+	htmlBlocks = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlBreaks = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6])[^>]*>`)
+	htmlTags   = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText - a plain text version of an html body for mails without a text part
+func htmlToText(body string) string {
+	body = htmlBlocks.ReplaceAllString(body, "")
+	body = htmlBreaks.ReplaceAllString(body, "\n")
+	body = htmlTags.ReplaceAllString(body, "")
+	body = html.UnescapeString(body)
+	return blankLines.ReplaceAllString(strings.TrimSpace(body), "\n\n")
+}
+
+// messageIDs - the message ids listed in a header, without the angle brackets
+type messageIDs []string
+
+func (ids messageIDs) first() string {
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[0]
+}
+
+var messageIDPattern = regexp.MustCompile(`<([^<>\s]+)>`)
+
+func parseMessageIDs(header string) messageIDs {
+	ids := messageIDs{}
+	for _, match := range messageIDPattern.FindAllStringSubmatch(header, -1) {
+		ids = append(ids, match[1])
+	}
+	if len(ids) == 0 && strings.TrimSpace(header) != "" && !strings.ContainsAny(strings.TrimSpace(header), " \t") {
+		ids = append(ids, strings.Trim(strings.TrimSpace(header), "<>"))
+	}
+	return ids
+}
+
+// Body - the text of the message, tickets and notes cannot be empty
+func (m *Message) Body() string {
+	if m.Text != "" {
+		return m.Text
+	}
+	return "(no content)"
+}
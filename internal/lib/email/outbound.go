@@ -0,0 +1,345 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// lease - how long a server has to send the mails it claimed before another server tries them
+	lease = 10 * time.Minute
+	// maxReferences - the Message-IDs kept in the References header of long threads
+	maxReferences = 20
+)
+
+// Outbound - queues the notifications of the tickets and sends them through the SMTP server,
+// the mails stay in the queue until the server accepts them
+type Outbound struct {
+	db       database.Database
+	interval time.Duration
+	batch    int
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewOutbound - creates the sender, it sends the queued mails every interval once started
+func NewOutbound(db database.Database, interval time.Duration, batch int) *Outbound {
+	return &Outbound{
+		db:       db,
+		interval: interval,
+		batch:    batch,
+	}
+}
+
+// Start - sends the queued mails in the background until Stop is called
+func (o *Outbound) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancel = cancel
+	o.done = make(chan struct{})
+
+	go func() {
+		defer close(o.done)
+
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+		for {
+			o.Send(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop - stops the sender and waits for the running batch to finish
+func (o *Outbound) Stop() {
+	if o.cancel == nil {
+		return
+	}
+	o.cancel()
+	<-o.done
+}
+
+// recipient - an address the notification is sent to
+type recipient struct {
+	name    string
+	address string
+}
+
+// Notify - queues the mails of a ticket event for the contact and the agents concerned
+func (o *Outbound) Notify(ctx context.Context, event string, ticketID model.TicketID, details Details) error {
+
+	ticket, err := o.db.GetTicketDetails(ctx, &ticketID)
+	if err != nil {
+		return err
+	}
+	recipients := o.recipients(ctx, event, ticket, details)
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	from, domain := o.sender(ctx)
+
+	// the mail answers the latest mail of the ticket
+	messageIDs, err := o.db.ListTicketMessageIDs(ctx, &ticket.ID)
+	if err != nil {
+		return err
+	}
+	inReplyTo, references := "", []string{}
+	if len(messageIDs) != 0 {
+		inReplyTo = messageIDs[len(messageIDs)-1]
+		if len(messageIDs) > maxReferences {
+			messageIDs = append(messageIDs[:1], messageIDs[len(messageIDs)-maxReferences+1:]...)
+		}
+		for _, messageID := range messageIDs {
+			references = append(references, "<"+messageID+">")
+		}
+	}
+
+	data := templateData{Subject: value(ticket.Subject), Details: details}
+	if ticket.Code != nil {
+		data.Number = *ticket.Code
+	}
+	if ticket.Status != nil {
+		data.Status = value(ticket.Status.Name)
+	}
+	if ticket.Priority != nil {
+		data.Priority = value(ticket.Priority.Name)
+	}
+
+	emails := []*model.QueuedEmail{}
+	for _, to := range recipients {
+		data.Name = to.name
+		subject, body, err := render(event, &data)
+		if err != nil {
+			return err
+		}
+		address := mail.Address{Name: to.name, Address: to.address}
+		emails = append(emails, &model.QueuedEmail{
+			TicketID:   ticket.ID,
+			NoteID:     details.NoteID,
+			Event:      event,
+			To:         address.String(),
+			Subject:    subject,
+			Body:       body,
+			MessageID:  newMessageID(domain),
+			InReplyTo:  inReplyTo,
+			References: strings.Join(references, " "),
+		})
+	}
+	return o.db.QueueEmails(ctx, from, emails...)
+}
+
+// Account - queues a mail about the account of the user, the link carries the token they use
+// until expiresAt, zero when it carries none
+func (o *Outbound) Account(ctx context.Context, event string, user *model.User, link string, expiresAt time.Time) error {
+
+	name := strings.TrimSpace(value(user.Firstname) + " " + value(user.Lastname))
+	data := templateData{
+		Name:    name,
+		Link:    link,
+		Expires: expiresAt.UTC().Format("January 2, 2006 at 15:04 UTC"),
+	}
+	subject, body, err := render(event, &data)
+	if err != nil {
+		return err
+	}
+
+	from, domain := o.sender(ctx)
+	address := mail.Address{Name: name, Address: value(user.Email)}
+	email := model.QueuedEmail{
+		Event:     event,
+		To:        address.String(),
+		Subject:   subject,
+		Body:      body,
+		MessageID: newMessageID(domain),
+	}
+	if !expiresAt.IsZero() {
+		email.ExpiresAt = &expiresAt
+	}
+	return o.db.QueueEmails(ctx, from, &email)
+}
+
+// sender - the address the mails are sent from and the domain of their Message-IDs
+func (o *Outbound) sender(ctx context.Context) (from, domain string) {
+	from, domain = "", "localhost"
+	if settings, err := o.db.GetOutboundMail(ctx); err == nil && settings.FromAddress != nil {
+		from = *settings.FromAddress
+		if at := strings.LastIndex(from, "@"); at != -1 {
+			domain = from[at+1:]
+		}
+	}
+	return
+}
+
+// recipients - the contact, the collaborators and the watchers are told about the progress of the ticket,
+// the assignee about the work given to them and the agents the escalation steps point to about the
+// tickets missing their deadline
+func (o *Outbound) recipients(ctx context.Context, event string, ticket *model.Ticket, details Details) []recipient {
+	logger := logrus.WithField("func", "email.Outbound.recipients()")
+
+	recipients := []recipient{}
+	seen := map[string]bool{}
+	add := func(firstname, lastname, email *string) {
+		address := value(email)
+		if address == "" || seen[strings.ToLower(address)] {
+			return
+		}
+		seen[strings.ToLower(address)] = true
+		name := strings.TrimSpace(value(firstname) + " " + value(lastname))
+		recipients = append(recipients, recipient{name: name, address: address})
+	}
+	addUser := func(userID model.UserID, description string) {
+		if userID == model.NilUserID || userID == details.ActorID {
+			return
+		}
+		user, err := o.db.GetUserByID(ctx, &userID)
+		if err != nil {
+			logger.WithError(err).WithField("UserID", userID).Warn("Retrieving the " + description)
+			return
+		}
+		add(user.Firstname, user.Lastname, user.Email)
+	}
+
+	// the assignments and the escalations are internal
+	if event != EventAssigned && event != EventEscalated && ticket.ContactID != model.NilContactID {
+		contact, err := o.db.GetContactByID(ctx, &ticket.ContactID)
+		if err != nil {
+			logger.WithError(err).WithField("ContactID", ticket.ContactID).Warn("Retrieving the ticket contact")
+		} else {
+			add(contact.Firstname, contact.Lastname, contact.Email)
+		}
+	}
+
+	switch event {
+	case EventCreated, EventAssigned:
+		addUser(ticket.AssignedID, "ticket assignee")
+	case EventEscalated:
+		addUser(ticket.AssignedID, "ticket assignee")
+		if details.Escalation == nil {
+			break
+		}
+		addUser(details.Escalation.AssignTo, "escalation assignee")
+		if details.Escalation.AssignRoleID != model.NilRoleID {
+			users, err := o.db.GetRoleUsers(ctx, &details.Escalation.AssignRoleID)
+			if err != nil {
+				logger.WithError(err).WithField("RoleID", details.Escalation.AssignRoleID).Warn("Retrieving the escalation role users")
+			}
+			for _, user := range users {
+				if user.ID != details.ActorID {
+					add(user.Firstname, user.Lastname, user.Email)
+				}
+			}
+		}
+	}
+
+	// the users are added to the ticket after it is created, the assignments only concern the assignee
+	if event != EventCreated && event != EventAssigned {
+		ticketUsers, err := o.db.ListTicketUsers(ctx, &ticket.ID)
+		if err != nil {
+			logger.WithError(err).WithField("TicketID", ticket.ID).Warn("Retrieving the ticket users")
+		}
+		for _, ticketUser := range ticketUsers {
+			if ticketUser.User == nil || ticketUser.UserID == details.ActorID || value(ticketUser.Role) == model.TicketUserAssignee {
+				continue
+			}
+			add(ticketUser.User.Firstname, ticketUser.User.Lastname, ticketUser.User.Email)
+		}
+	}
+	return recipients
+}
+
+// Send - sends the mails due in the queue, the failed ones are tried again later
+func (o *Outbound) Send(ctx context.Context) {
+	logger := logrus.WithField("func", "email.Outbound.Send()")
+
+	// the tokens of these mails can no longer be used
+	if deleted, err := o.db.DeleteExpiredEmails(ctx); err != nil {
+		logger.WithError(err).Warn("Deleting the expired mails")
+	} else if deleted != 0 {
+		logger.WithField("Deleted", deleted).Info("Deleted the expired mails")
+	}
+
+	settings, err := o.db.GetOutboundMail(ctx)
+	if err != nil {
+		// the mails wait in the queue until a server is set up
+		logger.WithError(err).Debug("No outbound mail server")
+		return
+	}
+
+	emails, err := o.db.ClaimEmails(ctx, o.batch, lease)
+	if err != nil {
+		logger.WithError(err).Warn("Claiming the queued mails")
+		return
+	}
+	if len(emails) == 0 {
+		return
+	}
+
+	client, err := dial(settings)
+	if err != nil {
+		logger.WithError(err).Warn("Connecting to the mail server")
+		settings.Status = func() *string { s := fmt.Sprintf("Connection error: %s", err.Error()); return &s }()
+		o.db.UpdateOutboundMailStatus(ctx, settings)
+		for _, email := range emails {
+			o.failed(ctx, email, err)
+		}
+		return
+	}
+	defer client.Close()
+
+	from := mail.Address{Name: value(settings.FromName), Address: value(settings.FromAddress)}
+	for _, email := range emails {
+		if err := sendMail(client, &from, email, time.Now()); err != nil {
+			logger.WithError(err).WithField("QueueID", email.ID).Warn("Sending mail")
+			o.failed(ctx, email, err)
+			// the next mail starts a new transaction
+			client.Reset()
+			continue
+		}
+		if err := o.db.EmailSent(ctx, email.ID); err != nil {
+			logger.WithError(err).WithField("QueueID", email.ID).Warn("Removing the sent mail from the queue")
+		}
+		settings.LastSent = func() *time.Time { t := time.Now(); return &t }()
+	}
+	client.Quit()
+
+	settings.Status = func() *string { s := "Connected"; return &s }()
+	o.db.UpdateOutboundMailStatus(ctx, settings)
+}
+
+// failed - schedules the next attempt of a mail
+func (o *Outbound) failed(ctx context.Context, email *model.QueuedEmail, cause error) {
+	email.Attempts++
+	email.LastError = cause.Error()
+	email.NextAttemptAt = func() *time.Time { t := time.Now().Add(email.RetryDelay()); return &t }()
+	if err := o.db.EmailFailed(ctx, email); err != nil {
+		logrus.WithError(err).WithField("QueueID", email.ID).Warn("Saving the mail failure")
+	}
+}
+
+// newMessageID - a unique Message-ID, without the angle brackets
+func newMessageID(domain string) string {
+	random := make([]byte, 8)
+	rand.Read(random)
+	return fmt.Sprintf("%d.%s@%s", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
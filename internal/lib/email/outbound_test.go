@@ -0,0 +1,568 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+)
+
+// fakeDB - keeps in memory what the mail subsystem reads and writes, the other methods of the
+// interface are not implemented
+type fakeDB struct {
+	database.Database
+
+	outbound    *model.OutboundMail
+	claimable   []*model.QueuedEmail
+	sent        []model.QueuedEmailID
+	failed      []*model.QueuedEmail
+	purges      int // the times the expired mails were deleted
+	queued      []*model.QueuedEmail
+	ticket      *model.Ticket
+	contact     *model.Contact
+	users       map[model.UserID]*model.User
+	roleUsers   map[model.RoleID][]*model.User
+	ticketUsers []*model.TicketUser
+	messageIDs  []string
+	received    []string // the Message-IDs of the mails added to the tickets
+	noteErr     error    // the next note fails with it
+	notes       []*model.Note
+	created     []*model.Ticket
+	contacts    []*model.Contact
+	emails      []*model.TicketEmail // the records of the mails added to the tickets
+
+	// findTicket - the ticket of a received mail, every mail belongs to "ticket" when it is nil
+	findTicket func(messageIDs []string, number int, from string) (model.TicketID, error)
+}
+
+func (f *fakeDB) GetOutboundMail(ctx context.Context) (*model.OutboundMail, error) {
+	if f.outbound == nil {
+		return nil, errors.New("no outbound mail server")
+	}
+	settings := *f.outbound
+	return &settings, nil
+}
+
+func (f *fakeDB) UpdateOutboundMailStatus(ctx context.Context, outboundMail *model.OutboundMail) error {
+	f.outbound = outboundMail
+	return nil
+}
+
+func (f *fakeDB) ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]*model.QueuedEmail, error) {
+	claimed := f.claimable
+	f.claimable = nil
+	return claimed, nil
+}
+
+func (f *fakeDB) EmailSent(ctx context.Context, emailID model.QueuedEmailID) error {
+	f.sent = append(f.sent, emailID)
+	return nil
+}
+
+func (f *fakeDB) EmailFailed(ctx context.Context, email *model.QueuedEmail) error {
+	f.failed = append(f.failed, email)
+	return nil
+}
+
+func (f *fakeDB) DeleteExpiredEmails(ctx context.Context) (int64, error) {
+	f.purges++
+	return 0, nil
+}
+
+func (f *fakeDB) QueueEmails(ctx context.Context, from string, emails ...*model.QueuedEmail) error {
+	f.queued = append(f.queued, emails...)
+	return nil
+}
+
+func (f *fakeDB) GetTicketDetails(ctx context.Context, ticketID *model.TicketID) (*model.Ticket, error) {
+	if f.ticket == nil || f.ticket.ID != *ticketID {
+		return nil, errors.New("ticket not found")
+	}
+	return f.ticket, nil
+}
+
+func (f *fakeDB) ListTicketMessageIDs(ctx context.Context, ticketID *model.TicketID) ([]string, error) {
+	return f.messageIDs, nil
+}
+
+func (f *fakeDB) GetContactByID(ctx context.Context, contactID *model.ContactID) (*model.Contact, error) {
+	if f.contact == nil || f.contact.ID != *contactID {
+		return nil, errors.New("contact not found")
+	}
+	return f.contact, nil
+}
+
+func (f *fakeDB) ListTicketUsers(ctx context.Context, ticketID *model.TicketID) ([]*model.TicketUser, error) {
+	return f.ticketUsers, nil
+}
+
+func (f *fakeDB) GetUserByID(ctx context.Context, userID *model.UserID) (*model.User, error) {
+	if user, ok := f.users[*userID]; ok {
+		return user, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+// smtpMessage - a mail the fake server accepted
+type smtpMessage struct {
+	from, to string
+	message  *mail.Message
+	body     string
+}
+
+// smtpServer - a fake SMTP server without TLS nor authentication
+type smtpServer struct {
+	listener net.Listener
+	reject   map[string]bool // the recipients refused with a 550
+
+	mu       sync.Mutex
+	messages []*smtpMessage
+}
+
+func newSMTPServer(t *testing.T, reject ...string) *smtpServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &smtpServer{listener: listener, reject: map[string]bool{}}
+	for _, address := range reject {
+		server.reject[address] = true
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+// settings - the outbound mail server pointing to the fake server
+func (s *smtpServer) settings() *model.OutboundMail {
+	address := s.listener.Addr().(*net.TCPAddr)
+	host, port, from, name := "127.0.0.1", address.Port, "support@example.com", "Support"
+	return &model.OutboundMail{Address: &host, Port: &port, FromAddress: &from, FromName: &name}
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	text := textproto.NewConn(conn)
+	defer text.Close()
+
+	text.PrintfLine("220 localhost ESMTP")
+	var from, to string
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.Fields(line + " ")[0])
+		switch verb {
+		case "EHLO", "HELO":
+			text.PrintfLine("250-localhost")
+			text.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			from = pathOf(line)
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			to = pathOf(line)
+			if s.reject[to] {
+				text.PrintfLine("550 mailbox unavailable")
+				continue
+			}
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 end with .")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			message, err := mail.ReadMessage(strings.NewReader(string(data)))
+			if err != nil {
+				text.PrintfLine("554 %s", err)
+				continue
+			}
+			body, _ := ioutil.ReadAll(message.Body)
+			s.mu.Lock()
+			s.messages = append(s.messages, &smtpMessage{from: from, to: to, message: message, body: string(body)})
+			s.mu.Unlock()
+			text.PrintfLine("250 queued")
+		case "RSET":
+			from, to = "", ""
+			text.PrintfLine("250 OK")
+		case "NOOP":
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) received() []*smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages
+}
+
+// pathOf - the address between the angle brackets of MAIL FROM and RCPT TO
+func pathOf(line string) string {
+	start, end := strings.Index(line, "<"), strings.LastIndex(line, ">")
+	if start == -1 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+func TestSend(t *testing.T) {
+
+	server := newSMTPServer(t)
+	db := &fakeDB{outbound: server.settings()}
+	db.claimable = []*model.QueuedEmail{
+		{
+			ID:        "1",
+			Event:     EventCreated,
+			To:        `"Jane Doe" <jane@example.com>`,
+			Subject:   "[#42] Printer on fire",
+			Body:      "Hello Jane,\nwe received your request.",
+			MessageID: "first@example.com",
+		},
+		{
+			ID:         "2",
+			Event:      EventReply,
+			To:         "john@example.com",
+			Subject:    "Re: [#42] Imprimante en feu",
+			Body:       "Nous regardons ça.",
+			MessageID:  "second@example.com",
+			InReplyTo:  "question@customer.com",
+			References: "<first@example.com> <question@customer.com>",
+		},
+	}
+
+	NewOutbound(db, time.Minute, 10).Send(context.Background())
+
+	if len(db.sent) != 2 || len(db.failed) != 0 {
+		t.Fatalf("sent %v and failed %d mails, want both sent", db.sent, len(db.failed))
+	}
+	if value(db.outbound.Status) != "Connected" || db.outbound.LastSent == nil {
+		t.Errorf("status = %s, last sent %v", value(db.outbound.Status), db.outbound.LastSent)
+	}
+
+	messages := server.received()
+	if len(messages) != 2 {
+		t.Fatalf("the server received %d mails, want 2", len(messages))
+	}
+	created, reply := messages[0], messages[1]
+	if created.from != "support@example.com" || created.to != "jane@example.com" {
+		t.Errorf("envelope = %s -> %s", created.from, created.to)
+	}
+
+	headers := []struct {
+		message *smtpMessage
+		name    string
+		want    string
+	}{
+		{created, "From", `"Support" <support@example.com>`},
+		{created, "To", `"Jane Doe" <jane@example.com>`},
+		{created, "Message-Id", "<first@example.com>"},
+		{created, "In-Reply-To", ""},
+		{created, "References", ""},
+		{created, "Auto-Submitted", "auto-generated"},
+		{reply, "Message-Id", "<second@example.com>"},
+		{reply, "In-Reply-To", "<question@customer.com>"},
+		{reply, "References", "<first@example.com> <question@customer.com>"},
+		{reply, "Auto-Submitted", ""},
+	}
+	for _, tt := range headers {
+		if got := tt.message.message.Header.Get(tt.name); got != tt.want {
+			t.Errorf("%s of %s = %q, want %q", tt.name, tt.message.to, got, tt.want)
+		}
+	}
+
+	if subject, _ := headerDecoder.DecodeHeader(reply.message.Header.Get("Subject")); subject != "Re: [#42] Imprimante en feu" {
+		t.Errorf("Subject = %s", subject)
+	}
+	if !strings.Contains(reply.body, "Nous regardons =C3=A7a.") {
+		t.Errorf("body = %q, want it quoted-printable", reply.body)
+	}
+}
+
+func TestSendRetry(t *testing.T) {
+
+	server := newSMTPServer(t, "refused@example.com")
+	db := &fakeDB{outbound: server.settings()}
+	db.claimable = []*model.QueuedEmail{
+		{ID: "1", Event: EventCreated, To: "refused@example.com", Subject: "First", MessageID: "1@example.com", Attempts: 2},
+		{ID: "2", Event: EventCreated, To: "accepted@example.com", Subject: "Second", MessageID: "2@example.com"},
+	}
+
+	before := time.Now()
+	NewOutbound(db, time.Minute, 10).Send(context.Background())
+
+	// the refused recipient does not keep the next mail from being sent
+	if len(db.sent) != 1 || db.sent[0] != "2" {
+		t.Errorf("sent = %v, want the second mail", db.sent)
+	}
+	if messages := server.received(); len(messages) != 1 || messages[0].to != "accepted@example.com" {
+		t.Errorf("the server received %d mails, want the second one", len(messages))
+	}
+	if len(db.failed) != 1 {
+		t.Fatalf("failed = %d mails, want 1", len(db.failed))
+	}
+
+	failed := db.failed[0]
+	if failed.Attempts != 3 || !strings.Contains(failed.LastError, "550") {
+		t.Errorf("attempts = %d, last error %q", failed.Attempts, failed.LastError)
+	}
+	// the third attempt waits 2^3 minutes
+	checkNextAttempt(t, failed, before, 8*time.Minute)
+}
+
+func TestSendConnectionError(t *testing.T) {
+
+	server := newSMTPServer(t)
+	settings := server.settings()
+	server.listener.Close()
+
+	db := &fakeDB{outbound: settings}
+	db.claimable = []*model.QueuedEmail{
+		{ID: "1", Event: EventCreated, To: "jane@example.com", MessageID: "1@example.com"},
+		{ID: "2", Event: EventCreated, To: "john@example.com", MessageID: "2@example.com"},
+	}
+
+	before := time.Now()
+	NewOutbound(db, time.Minute, 10).Send(context.Background())
+
+	if len(db.sent) != 0 || len(db.failed) != 2 {
+		t.Fatalf("sent %d and failed %d mails, want both failed", len(db.sent), len(db.failed))
+	}
+	for _, email := range db.failed {
+		if email.Attempts != 1 {
+			t.Errorf("attempts of %s = %d, want 1", email.ID, email.Attempts)
+		}
+		checkNextAttempt(t, email, before, 2*time.Minute)
+	}
+	if !strings.HasPrefix(value(db.outbound.Status), "Connection error") {
+		t.Errorf("status = %s, want a connection error", value(db.outbound.Status))
+	}
+}
+
+func TestSendWithoutServer(t *testing.T) {
+
+	db := &fakeDB{claimable: []*model.QueuedEmail{{ID: "1", To: "jane@example.com"}}}
+	NewOutbound(db, time.Minute, 10).Send(context.Background())
+
+	// the mails wait in the queue, they are not even claimed
+	if len(db.claimable) != 1 || len(db.failed) != 0 {
+		t.Errorf("claimable = %d, failed = %d, want the mail left in the queue", len(db.claimable), len(db.failed))
+	}
+	// the expired tokens are not kept until a server is set up
+	if db.purges != 1 {
+		t.Errorf("purges = %d, want 1", db.purges)
+	}
+}
+
+func TestFailedBackoff(t *testing.T) {
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 2 * time.Minute},
+		{1, 4 * time.Minute},
+		{4, 32 * time.Minute},
+		{5, time.Hour},
+		{20, time.Hour},
+	}
+	outbound := NewOutbound(&fakeDB{}, time.Minute, 10)
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d attempts", tt.attempts), func(t *testing.T) {
+			email := &model.QueuedEmail{Attempts: tt.attempts}
+			before := time.Now()
+			outbound.failed(context.Background(), email, errors.New("refused"))
+			if email.Attempts != tt.attempts+1 || email.LastError != "refused" {
+				t.Errorf("attempts = %d, last error = %q", email.Attempts, email.LastError)
+			}
+			checkNextAttempt(t, email, before, tt.want)
+		})
+	}
+}
+
+func TestNotifyThreading(t *testing.T) {
+
+	number, subject := 42, "Printer on fire"
+	contactName, contactEmail := "Jane", "jane@example.com"
+	agentName, agentEmail := "Bob", "bob@example.com"
+	db := &fakeDB{
+		ticket:  &model.Ticket{ID: "ticket", Code: &number, Subject: &subject, ContactID: "contact", AssignedID: "agent"},
+		contact: &model.Contact{ID: "contact", Firstname: &contactName, Email: &contactEmail},
+		users:   map[model.UserID]*model.User{"agent": {ID: "agent", Firstname: &agentName, Email: &agentEmail}},
+	}
+	for i := 1; i <= maxReferences+5; i++ {
+		db.messageIDs = append(db.messageIDs, fmt.Sprintf("%d@example.com", i))
+	}
+
+	if err := NewOutbound(db, time.Minute, 10).Notify(context.Background(), EventCreated, "ticket", Details{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.queued) != 2 {
+		t.Fatalf("queued %d mails, want the contact and the assignee", len(db.queued))
+	}
+
+	for _, email := range db.queued {
+		if email.InReplyTo != fmt.Sprintf("%d@example.com", maxReferences+5) {
+			t.Errorf("In-Reply-To = %s, want the latest mail", email.InReplyTo)
+		}
+		references := strings.Fields(email.References)
+		if len(references) != maxReferences {
+			t.Fatalf("%d references, want %d", len(references), maxReferences)
+		}
+		// the first mail of the thread is kept, the oldest of the others are dropped
+		if references[0] != "<1@example.com>" || references[1] != "<7@example.com>" ||
+			references[maxReferences-1] != fmt.Sprintf("<%d@example.com>", maxReferences+5) {
+			t.Errorf("References = %s", email.References)
+		}
+		if email.TicketID != "ticket" || !strings.HasSuffix(email.MessageID, "@localhost") {
+			t.Errorf("ticket = %s, Message-ID = %s", email.TicketID, email.MessageID)
+		}
+	}
+}
+
+func TestNotifyFirstMail(t *testing.T) {
+
+	subject, contactEmail := "Printer on fire", "jane@example.com"
+	db := &fakeDB{
+		ticket:  &model.Ticket{ID: "ticket", Subject: &subject, ContactID: "contact"},
+		contact: &model.Contact{ID: "contact", Email: &contactEmail},
+	}
+	if err := NewOutbound(db, time.Minute, 10).Notify(context.Background(), EventCreated, "ticket", Details{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.queued) != 1 || db.queued[0].InReplyTo != "" || db.queued[0].References != "" {
+		t.Errorf("queued = %+v, want one mail starting the thread", db.queued)
+	}
+}
+
+func TestNotifyTicketUsers(t *testing.T) {
+
+	text := func(s string) *string { return &s }
+	ticketUser := func(userID model.UserID, role, firstname, email string) *model.TicketUser {
+		return &model.TicketUser{UserID: userID, Role: text(role), User: &model.User{ID: userID, Firstname: text(firstname), Email: text(email)}}
+	}
+	db := &fakeDB{
+		ticket:  &model.Ticket{ID: "ticket", Subject: text("Printer on fire"), ContactID: "contact", AssignedID: "agent"},
+		contact: &model.Contact{ID: "contact", Firstname: text("Jane"), Email: text("jane@example.com")},
+		users:   map[model.UserID]*model.User{"agent": {ID: "agent", Firstname: text("Bob"), Email: text("bob@example.com")}},
+		ticketUsers: []*model.TicketUser{
+			ticketUser("agent", model.TicketUserAssignee, "Bob", "bob@example.com"),
+			ticketUser("helper", model.TicketUserCollaborator, "Carol", "carol@example.com"),
+			ticketUser("manager", model.TicketUserWatcher, "Dave", "dave@example.com"),
+			// the watcher is the contact too, they get one mail about the public events
+			ticketUser("customer", model.TicketUserWatcher, "Jane", "JANE@example.com"),
+		},
+	}
+
+	tests := []struct {
+		event   string
+		details Details
+		want    []string
+	}{
+		{EventCreated, Details{}, []string{"jane@example.com", "bob@example.com"}},
+		{EventAssigned, Details{}, []string{"bob@example.com"}},
+		{EventReply, Details{ActorID: "agent", Note: "On it"}, []string{"jane@example.com", "carol@example.com", "dave@example.com"}},
+		{EventStatusChanged, Details{ActorID: "helper"}, []string{"jane@example.com", "dave@example.com"}},
+		{EventClosed, Details{ActorID: "agent"}, []string{"jane@example.com", "carol@example.com", "dave@example.com"}},
+		{EventEscalated, Details{Escalation: &model.Escalation{Name: text("Late"), Level: func() *int { l := 1; return &l }()}}, []string{"bob@example.com", "carol@example.com", "dave@example.com", "JANE@example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			db.queued = nil
+			if err := NewOutbound(db, time.Minute, 10).Notify(context.Background(), tt.event, "ticket", tt.details); err != nil {
+				t.Fatal(err)
+			}
+			to := []string{}
+			for _, email := range db.queued {
+				address, err := mail.ParseAddress(email.To)
+				if err != nil {
+					t.Fatal(err)
+				}
+				to = append(to, address.Address)
+			}
+			if fmt.Sprint(to) != fmt.Sprint(tt.want) {
+				t.Errorf("to = %v, want %v", to, tt.want)
+			}
+		})
+	}
+}
+
+func checkNextAttempt(t *testing.T, email *model.QueuedEmail, before time.Time, delay time.Duration) {
+	t.Helper()
+	if email.NextAttemptAt == nil {
+		t.Fatal("no next attempt")
+	}
+	if email.NextAttemptAt.Before(before.Add(delay)) || email.NextAttemptAt.After(time.Now().Add(delay)) {
+		t.Errorf("next attempt in %v, want %v", email.NextAttemptAt.Sub(before), delay)
+	}
+}
+
+func TestAccount(t *testing.T) {
+
+	firstname, address := "Jane", "jane@example.com"
+	user := &model.User{ID: "jane", Firstname: &firstname, Email: &address}
+	link := "https://tickets.example.com/password/forgot"
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		event       string
+		expiresAt   time.Time
+		wantSubject string
+		wantBody    []string
+	}{
+		{EventPasswordReset, expiresAt, "Reset your password", []string{"Hello Jane", link, "expires on"}},
+		{EventVerifyEmail, expiresAt, "Verify your email", []string{"Hello Jane", link, "expires on"}},
+		{EventAccountExists, time.Time{}, "Your account already exists", []string{"Hello Jane", link, "already have one"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			db := &fakeDB{}
+			if err := NewOutbound(db, time.Minute, 10).Account(context.Background(), tt.event, user, link, tt.expiresAt); err != nil {
+				t.Fatal(err)
+			}
+			if len(db.queued) != 1 {
+				t.Fatalf("queued %d mails, want 1", len(db.queued))
+			}
+			email := db.queued[0]
+			if email.To != `"Jane" <jane@example.com>` || email.Subject != tt.wantSubject || email.Event != tt.event {
+				t.Errorf("To = %s, Subject = %s, Event = %s", email.To, email.Subject, email.Event)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(email.Body, want) {
+					t.Errorf("the body does not contain %q:\n%s", want, email.Body)
+				}
+			}
+			// the mails carrying a token expire with it
+			if tt.expiresAt.IsZero() != (email.ExpiresAt == nil) || (email.ExpiresAt != nil && !email.ExpiresAt.Equal(tt.expiresAt)) {
+				t.Errorf("ExpiresAt = %v, want %v", email.ExpiresAt, tt.expiresAt)
+			}
+		})
+	}
+}
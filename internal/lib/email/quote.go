@@ -0,0 +1,65 @@
+package email
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// the lines mail clients put above the quoted history
+	quoteHeaders = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^On\s.+wrote:$`),
+		regexp.MustCompile(`(?i)^-+\s*Original Message\s*-+$`),
+		regexp.MustCompile(`^_{10,}$`),
+		regexp.MustCompile(`(?i)^From:\s.+$`),
+	}
+	// "On ... wrote:" is often wrapped on two lines
+	quoteHeaderStart = regexp.MustCompile(`(?i)^On\s.+`)
+
+	ticketNumberPattern = regexp.MustCompile(`\[#(\d+)\]`)
+)
+
+// StripQuoted - removes the quoted history of a reply, only the new text is kept
+func StripQuoted(text string) string {
+
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	kept := []string{}
+	for index, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if quoteHeader(trimmed) {
+			break
+		}
+		if index+1 < len(lines) && quoteHeaderStart.MatchString(trimmed) &&
+			quoteHeader(trimmed+" "+strings.TrimSpace(lines[index+1])) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+func quoteHeader(line string) bool {
+	for _, header := range quoteHeaders {
+		if header.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// TicketNumber - the ticket number of a [#10023] token in the subject, 0 when there is none
+func TicketNumber(subject string) int {
+	match := ticketNumberPattern.FindStringSubmatch(subject)
+	if match == nil {
+		return 0
+	}
+	number, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	return number
+}
@@ -0,0 +1,54 @@
+package email
+
+import "testing"
+
+func TestStripQuoted(t *testing.T) {
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"no history", "Thanks, it works now.", "Thanks, it works now."},
+		{"quoted lines", "Thanks.\n> the old text\n> more\nBye", "Thanks.\nBye"},
+		{"gmail header", "Fixed.\n\nOn Mon, 5 Oct 2020 at 10:00, Support <support@example.com> wrote:\n> old", "Fixed."},
+		{"wrapped header", "Fixed.\nOn Mon, 5 Oct 2020 at 10:00, Support\n<support@example.com> wrote:\nold", "Fixed."},
+		{"outlook header", "Fixed.\r\n-----Original Message-----\r\nFrom: Support\r\nold", "Fixed."},
+		{"underscores", "Fixed.\n__________\nold", "Fixed."},
+		{"from header", "Fixed.\nFrom: Support <support@example.com>\nold", "Fixed."},
+		{"only history", "> old\n> text", ""},
+		{"on without wrote", "On Monday I restarted it.\nStill broken.", "On Monday I restarted it.\nStill broken."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := StripQuoted(test.text); got != test.want {
+				t.Errorf("StripQuoted() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTicketNumber(t *testing.T) {
+
+	tests := []struct {
+		subject string
+		want    int
+	}{
+		{"Re: [#10023] Printer is down", 10023},
+		{"[#7]", 7},
+		{"Re: Printer is down", 0},
+		{"Re: #10023 Printer is down", 0},
+		{"Re: [#abc] Printer is down", 0},
+		{"Re: [#99999999999999999999] Printer is down", 0},
+		{"Fwd: [#12] Re: [#34]", 12},
+	}
+
+	for _, test := range tests {
+		t.Run(test.subject, func(t *testing.T) {
+			if got := TicketNumber(test.subject); got != test.want {
+				t.Errorf("TicketNumber() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// dialTimeout - how long to wait for the mail server
+const dialTimeout = 10 * time.Second
+
+// dial - connects and logs in to the SMTP server, STARTTLS is used when the server offers it,
+// the settings carry the secret decrypted by the database
+func dial(settings *model.OutboundMail) (*smtp.Client, error) {
+
+	host := value(settings.Address)
+	port := 587
+	if settings.Port != nil {
+		port = *settings.Port
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: host}
+
+	var conn net.Conn
+	var err error
+	if settings.Secured != nil && *settings.Secured {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", address, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", address, dialTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if user := value(settings.EmailUser); user != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(smtp.PlainAuth("", user, value(settings.EmailSecret), host)); err != nil {
+				client.Close()
+				return nil, err
+			}
+		}
+	}
+	return client, nil
+}
+
+// sendMail - sends one mail of the queue on the connection
+func sendMail(client *smtp.Client, from *mail.Address, email *model.QueuedEmail, now time.Time) error {
+
+	to, err := mail.ParseAddress(email.To)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(buildMessage(from, to, email, now)); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// buildMessage - the raw mail, the threading headers bring the replies back to the ticket
+func buildMessage(from, to *mail.Address, email *model.QueuedEmail, now time.Time) []byte {
+
+	var message bytes.Buffer
+	header := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&message, "%s: %s\r\n", name, value)
+		}
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+email.MessageID+">")
+	if email.InReplyTo != "" {
+		header("In-Reply-To", "<"+email.InReplyTo+">")
+	}
+	header("References", email.References)
+	// keeps the auto-responders of the customers from answering the notifications
+	if email.Event != EventReply {
+		header("Auto-Submitted", "auto-generated")
+	}
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	message.WriteString("\r\n")
+
+	// the writer ends the lines with CRLF
+	body := quotedprintable.NewWriter(&message)
+	body.Write([]byte(email.Body))
+	body.Close()
+	return message.Bytes()
+}
@@ -0,0 +1,162 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// The ticket events that send notifications
+const (
+	EventCreated       = "created"
+	EventAssigned      = "assigned"
+	EventReply         = "reply"
+	EventStatusChanged = "status_changed"
+	EventClosed        = "closed"
+	EventEscalated     = "escalated"
+)
+
+// The account events that send mails, they are not about a ticket
+const (
+	EventPasswordReset = "password_reset"
+	EventVerifyEmail   = "verify_email"
+	EventAccountExists = "account_exists"
+)
+
+// Details - what happened to the ticket, the fields used depend on the event
+type Details struct {
+	ActorID model.UserID // who changed the ticket, they are not notified
+	NoteID  model.NoteID
+	Note    string // the public reply
+	Remark  string // the closing remark
+	Cause   string // the cause of the closing
+
+	Escalation *model.Escalation // the step reached by an escalated ticket
+}
+
+// templateData - the values the templates can use
+type templateData struct {
+	Number   int
+	Subject  string
+	Name     string // the name of the person the mail is sent to
+	Status   string
+	Priority string
+	Details  Details
+
+	// the account mails
+	Link    string // carries the single use token
+	Expires string
+}
+
+// AtRisk - the escalated ticket is about to miss its deadline, it has not missed it yet
+func (d *templateData) AtRisk() bool {
+	escalation := d.Details.Escalation
+	return escalation != nil && escalation.TriggerOn != nil && *escalation.TriggerOn == model.EscalateOnAtRisk
+}
+
+type mailTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func newTemplate(subject, body string) mailTemplate {
+	return mailTemplate{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(body)),
+	}
+}
+
+// the [#number] token lets the replies find the ticket when the mail clients drop the threading headers
+var templates = map[string]mailTemplate{
+	EventCreated: newTemplate(`[#{{.Number}}] {{.Subject}}`, `Hello {{.Name}},
+
+Your request has been received, its ticket number is #{{.Number}}.
+{{if .Status}}Status: {{.Status}}
+{{end}}{{if .Priority}}Priority: {{.Priority}}
+{{end}}
+Reply to this mail to add more details to the ticket.
+`),
+	EventAssigned: newTemplate(`[#{{.Number}}] Assigned to you: {{.Subject}}`, `Hello {{.Name}},
+
+Ticket #{{.Number}} "{{.Subject}}" has been assigned to you.
+{{if .Status}}Status: {{.Status}}
+{{end}}{{if .Priority}}Priority: {{.Priority}}
+{{end}}`),
+	EventReply: newTemplate(`Re: [#{{.Number}}] {{.Subject}}`, `{{.Details.Note}}
+
+--
+Ticket #{{.Number}}, reply to this mail to answer.
+`),
+	EventStatusChanged: newTemplate(`[#{{.Number}}] Status changed to {{.Status}}: {{.Subject}}`, `Hello {{.Name}},
+
+The status of ticket #{{.Number}} "{{.Subject}}" is now {{.Status}}.
+`),
+	EventClosed: newTemplate(`[#{{.Number}}] Closed: {{.Subject}}`, `Hello {{.Name}},
+
+Ticket #{{.Number}} "{{.Subject}}" has been closed.
+{{if .Details.Cause}}Cause: {{.Details.Cause}}
+{{end}}{{if .Details.Remark}}
+{{.Details.Remark}}
+{{end}}
+Reply to this mail if the problem is not solved.
+`),
+	EventEscalated: newTemplate(`[#{{.Number}}] Escalated: {{.Subject}}`, `Hello {{.Name}},
+
+Ticket #{{.Number}} "{{.Subject}}" {{if .AtRisk}}is about to miss{{else}}missed{{end}} its deadline and reached the escalation level {{.Details.Escalation.Level}} "{{.Details.Escalation.Name}}".
+{{if .Status}}Status: {{.Status}}
+{{end}}{{if .Priority}}Priority: {{.Priority}}
+{{end}}{{with .Details.Escalation.Note}}
+{{.}}
+{{end}}`),
+	EventPasswordReset: newTemplate(`Reset your password`, `Hello {{.Name}},
+
+A new password was requested for your account. Follow this link to choose it:
+
+{{.Link}}
+
+The link can be used once and expires on {{.Expires}}. If you did not ask for it, ignore this mail,
+your password stays the same.
+`),
+	EventVerifyEmail: newTemplate(`Verify your email`, `Hello {{.Name}},
+
+Follow this link to verify your email and finish setting up your account:
+
+{{.Link}}
+
+The link can be used once and expires on {{.Expires}}.
+`),
+	EventAccountExists: newTemplate(`Your account already exists`, `Hello {{.Name}},
+
+Someone tried to create a new account with this email, but you already have one. If it was you,
+sign in or follow this link to choose a new password:
+
+{{.Link}}
+
+If it was not you, ignore this mail, your account stays the same.
+`),
+}
+
+// render - the subject and the body of the mail of an event
+func render(event string, data *templateData) (subject, body string, err error) {
+
+	mail, ok := templates[event]
+	if !ok {
+		return "", "", fmt.Errorf("no mail template for the %s event", event)
+	}
+
+	var buffer bytes.Buffer
+	if err := mail.subject.Execute(&buffer, data); err != nil {
+		return "", "", err
+	}
+	// the subject is a single header line
+	subject = strings.Join(strings.Fields(buffer.String()), " ")
+
+	buffer.Reset()
+	if err := mail.body.Execute(&buffer, data); err != nil {
+		return "", "", err
+	}
+	return subject, buffer.String(), nil
+}
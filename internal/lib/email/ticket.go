@@ -0,0 +1,168 @@
+package email
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/sla"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// maxSubject - the length of the ticket subjects
+const maxSubject = 150
+
+// rejectedError - the mail cannot become a ticket or a note, reading it again fails the same way
+type rejectedError struct {
+	err error
+}
+
+func (e rejectedError) Error() string {
+	return e.err.Error()
+}
+
+// receive - adds a reply to the ticket it belongs to, other mails become new tickets
+func (i *InboundMail) receive(ctx context.Context, body io.Reader) error {
+
+	message, err := ParseMessage(body)
+	if err != nil {
+		return rejectedError{err}
+	}
+
+	threadIDs := message.References
+	if message.InReplyTo != "" {
+		threadIDs = append([]string{message.InReplyTo}, threadIDs...)
+	}
+	ticketID, err := i.db.FindEmailTicket(ctx, threadIDs, TicketNumber(message.Subject), message.From.Address)
+	if err == nil {
+		return i.addNote(ctx, ticketID, message)
+	}
+	if apiError, ok := err.(apiErr.APIError); !ok || apiError.Code != http.StatusNotFound {
+		return err
+	}
+	return i.createTicket(ctx, message)
+}
+
+// addNote - adds the new text of a reply to the ticket as a note, the note has no author
+// and is linked to the sender through its mail
+func (i *InboundMail) addNote(ctx context.Context, ticketID model.TicketID, message *Message) error {
+
+	text := StripQuoted(message.Text)
+	if text == "" {
+		text = message.Body()
+	}
+
+	files, skipped, err := i.saveFiles(ctx, message)
+	if err != nil {
+		return err
+	}
+	text += skippedFiles(skipped)
+
+	note := model.Note{
+		Note:     &text,
+		TicketID: ticketID,
+		UserID:   model.NilUserID,
+	}
+	if err := i.db.CreateEmailNote(ctx, &note, i.ticketEmail(message), files); err != nil {
+		i.deleteFiles(files)
+		return err
+	}
+	return nil
+}
+
+// createTicket - turns a received mail into a ticket with the defaults of the mailbox,
+// the sender becomes the contact of the ticket
+func (i *InboundMail) createTicket(ctx context.Context, message *Message) error {
+
+	ticket := model.Ticket{
+		Subject:     func() *string { s := subject(message.Subject); return &s }(),
+		Description: func() *string { s := message.Body(); return &s }(),
+		UserID:      i.info.UserID,
+		CategoryID:  i.info.CategoryID,
+		PriorityID:  i.info.PriorityID,
+		SLAID:       i.info.SLAID,
+		SourceID:    i.info.SourceID,
+	}
+
+	status, err := i.db.GetInitialStatus(ctx)
+	if err != nil {
+		return err
+	}
+	ticket.StatusID = status.ID
+	if err := ticket.Verify(); err != nil {
+		return rejectedError{err}
+	}
+
+	now := time.Now()
+	target, err := i.db.GetSLATarget(ctx, &ticket.SLAID, &ticket.PriorityID)
+	if err != nil {
+		return err
+	}
+	calendar, err := sla.LoadCalendar(ctx, i.db, target.CalendarID)
+	if err != nil {
+		return err
+	}
+	sla.Apply(&ticket, now, target, calendar)
+	if status.Pauses() {
+		ticket.SLAPausedAt = &now
+	}
+
+	files, skipped, err := i.saveFiles(ctx, message)
+	if err != nil {
+		return err
+	}
+	*ticket.Description += skippedFiles(skipped)
+
+	contact := newContact(message.From, i.info.UserID)
+	if err := i.db.CreateEmailTicket(ctx, &ticket, contact, i.ticketEmail(message), files); err != nil {
+		i.deleteFiles(files)
+		return err
+	}
+	return nil
+}
+
+// ticketEmail - the record of a received mail, its Message-ID threads the next replies
+func (i *InboundMail) ticketEmail(message *Message) *model.TicketEmail {
+	return &model.TicketEmail{
+		InboundEmailID: i.info.ID,
+		Direction:      model.EmailInbound,
+		MessageID:      message.MessageID,
+		InReplyTo:      message.InReplyTo,
+		From:           message.From.Address,
+		Subject:        message.Subject,
+		HTMLBody:       message.HTML,
+	}
+}
+
+// subject - the subject of the ticket, cut to the length of the column
+func subject(s string) string {
+	if s == "" {
+		return "(no subject)"
+	}
+	if utf8.RuneCountInString(s) <= maxSubject {
+		return s
+	}
+	return string([]rune(s)[:maxSubject])
+}
+
+// newContact - the contact of a sender, the names are taken from the display name when there is one
+func newContact(from *mail.Address, userID model.UserID) *model.Contact {
+
+	names := strings.Fields(from.Name)
+	if len(names) == 0 {
+		names = []string{strings.Split(from.Address, "@")[0]}
+	}
+	firstname := names[0]
+	lastname := strings.Join(names[1:], " ")
+	return &model.Contact{
+		Firstname: &firstname,
+		Lastname:  &lastname,
+		Email:     &from.Address,
+		UserID:    userID,
+	}
+}
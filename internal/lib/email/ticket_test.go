@@ -0,0 +1,139 @@
+package email
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+func (f *fakeDB) CreateEmailTicket(ctx context.Context, ticket *model.Ticket, contact *model.Contact, email *model.TicketEmail, files []*model.NoteFile) error {
+	f.created = append(f.created, ticket)
+	f.contacts = append(f.contacts, contact)
+	f.emails = append(f.emails, email)
+	return nil
+}
+
+func (f *fakeDB) GetInitialStatus(ctx context.Context) (*model.Status, error) {
+	return &model.Status{ID: "open"}, nil
+}
+
+func (f *fakeDB) GetSLATarget(ctx context.Context, slaID *model.SLAID, priorityID *model.PriorityID) (*model.SLATarget, error) {
+	return &model.SLATarget{SLAID: *slaID, PriorityID: *priorityID}, nil
+}
+
+// lookup - the arguments FindEmailTicket was called with
+type lookup struct {
+	messageIDs []string
+	number     int
+	from       string
+}
+
+func TestReceiveThreading(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		headers string
+		lookup  lookup
+		ticket  model.TicketID // empty when the mail opens a new ticket
+	}{
+		{
+			name:    "in reply to",
+			headers: "From: jane@example.com\r\nSubject: Re: Printer\r\nIn-Reply-To: <b@example.com>\r\nReferences: <a@example.com> <b@example.com>\r\n",
+			lookup:  lookup{[]string{"b@example.com", "a@example.com", "b@example.com"}, 0, "jane@example.com"},
+			ticket:  "ticket-1",
+		},
+		{
+			name:    "references",
+			headers: "From: jane@example.com\r\nSubject: Re: Printer\r\nReferences: <x@example.com> <a@example.com>\r\n",
+			lookup:  lookup{[]string{"x@example.com", "a@example.com"}, 0, "jane@example.com"},
+			ticket:  "ticket-1",
+		},
+		{
+			name:    "number of the contact",
+			headers: "From: Jane Doe <jane@example.com>\r\nSubject: Re: [#42] Printer\r\n",
+			lookup:  lookup{[]string{}, 42, "jane@example.com"},
+			ticket:  "ticket-2",
+		},
+		{
+			name:    "number of a stranger",
+			headers: "From: mallory@example.com\r\nSubject: Re: [#42] Printer\r\n",
+			lookup:  lookup{[]string{}, 42, "mallory@example.com"},
+		},
+		{
+			name:    "unknown thread",
+			headers: "From: jane@example.com\r\nSubject: Printer\r\nIn-Reply-To: <y@example.com>\r\n",
+			lookup:  lookup{[]string{"y@example.com"}, 0, "jane@example.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			lookups := []lookup{}
+			db := &fakeDB{
+				findTicket: func(messageIDs []string, number int, from string) (model.TicketID, error) {
+					lookups = append(lookups, lookup{messageIDs, number, from})
+					for _, messageID := range messageIDs {
+						if messageID == "a@example.com" || messageID == "b@example.com" {
+							return "ticket-1", nil
+						}
+					}
+					if number == 42 && from == "jane@example.com" {
+						return "ticket-2", nil
+					}
+					return model.NilTicketID, apiErr.ErrNotExist("Ticket")
+				},
+			}
+			info := &model.InboudMail{
+				ID:         "mailbox",
+				UserID:     "admin",
+				CategoryID: "category",
+				PriorityID: "priority",
+				SLAID:      "sla",
+				SourceID:   "source",
+			}
+			i := &InboundMail{db: db, info: info}
+
+			raw := test.headers + "Message-ID: <new@example.com>\r\nContent-Type: text/plain\r\n\r\n" +
+				"It is still down.\r\n\r\nOn Mon, 5 Oct 2020, Support <support@example.com> wrote:\r\n> old\r\n"
+			if err := i.receive(context.Background(), strings.NewReader(raw)); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(lookups, []lookup{test.lookup}) {
+				t.Errorf("lookups = %+v, want %+v", lookups, []lookup{test.lookup})
+			}
+			if len(db.emails) != 1 || db.emails[0].From != test.lookup.from || db.emails[0].MessageID != "new@example.com" {
+				t.Fatalf("emails = %+v, want the mail from %s", db.emails, test.lookup.from)
+			}
+
+			if test.ticket == model.NilTicketID {
+				if len(db.notes) != 0 || len(db.created) != 1 {
+					t.Fatalf("%d notes and %d tickets, want a new ticket", len(db.notes), len(db.created))
+				}
+				if *db.contacts[0].Email != test.lookup.from {
+					t.Errorf("contact = %s, want %s", *db.contacts[0].Email, test.lookup.from)
+				}
+				return
+			}
+
+			if len(db.notes) != 1 || len(db.created) != 0 {
+				t.Fatalf("%d notes and %d tickets, want a note", len(db.notes), len(db.created))
+			}
+			note := db.notes[0]
+			if note.TicketID != test.ticket {
+				t.Errorf("note on %s, want %s", note.TicketID, test.ticket)
+			}
+			if note.UserID != model.NilUserID {
+				t.Errorf("note by %s, want no author", note.UserID)
+			}
+			if *note.Note != "It is still down." {
+				t.Errorf("note = %q, want the reply without the history", *note.Note)
+			}
+		})
+	}
+}
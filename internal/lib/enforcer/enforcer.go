@@ -144,8 +144,14 @@ func addDefaultPolicies(enforcer *casbin.CachedEnforcer) (saved bool, err error)
 	saved, err = addPolicyForAllAction("64e5b10d-7d23-4ee5-b386-8c65a99bbb78", "contact", enforcer)
 	// saved, err = addPolicyForAllAction("admin", "closing_remark", enforcer)
 	// closed_ticket
-	saved, err = addPolicyForAllAction("3b59d805-f72b-4704-b97c-6a3a53de7e81", "closed_ticket", enforcer)
+	saved, err = addPolicyForAllAction("64e5b10d-7d23-4ee5-b386-8c65a99bbb78", "closed_ticket", enforcer)
 	// saved, err = addPolicyForAllAction("admin", "closed_ticket", enforcer)
+	// inbound_mail
+	saved, err = addPolicyForAllAction("64e5b10d-7d23-4ee5-b386-8c65a99bbb78", "inbound_mail", enforcer)
+	// outbound_mail
+	saved, err = addPolicyForAllAction("64e5b10d-7d23-4ee5-b386-8c65a99bbb78", "outbound_mail", enforcer)
+	// mfa_requirement
+	saved, err = addPolicyForAllAction("64e5b10d-7d23-4ee5-b386-8c65a99bbb78", "mfa_requirement", enforcer)
 
 	
 	
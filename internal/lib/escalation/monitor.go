@@ -0,0 +1,136 @@
+// Package escalation runs the escalation steps on tickets that are about to miss, or missed, their deadline
+package escalation
+
+import (
+	"context"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+	"github.com/sirupsen/logrus"
+)
+
+// Notifier - sends the notification of an escalation step
+type Notifier interface {
+	Notify(ctx context.Context, escalation *model.Escalation, ticket *model.Ticket) error
+}
+
+// LogNotifier - writes the notifications to the logs, it is used when no other notifier is set
+type LogNotifier struct{}
+
+// Notify - logs the escalated ticket
+func (LogNotifier) Notify(ctx context.Context, escalation *model.Escalation, ticket *model.Ticket) error {
+	logrus.WithFields(logrus.Fields{
+		"TicketID": ticket.ID,
+		"Level":    *escalation.Level,
+		"Step":     *escalation.Name,
+	}).Warn("Ticket escalated")
+	return nil
+}
+
+// Monitor - checks the tickets periodically and escalates the ones that reached a step
+type Monitor struct {
+	db       database.Database
+	notifier Notifier
+	interval time.Duration
+	batch    int
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New - creates a monitor that checks the tickets every interval, batch limits the tickets escalated per check
+func New(db database.Database, notifier Notifier, interval time.Duration, batch int) *Monitor {
+	if notifier == nil {
+		notifier = LogNotifier{}
+	}
+	return &Monitor{
+		db:       db,
+		notifier: notifier,
+		interval: interval,
+		batch:    batch,
+	}
+}
+
+// Start - runs the monitor in the background until Stop is called
+func (m *Monitor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			m.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop - stops the monitor and waits for the running check to finish
+func (m *Monitor) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+}
+
+// Check - escalates the tickets that reached a step. Several servers can check at the same time,
+// the database makes sure each level runs once per ticket.
+func (m *Monitor) Check(ctx context.Context) {
+	logger := logrus.WithField("func", "escalation.Monitor.Check()")
+
+	ctx, cancelFunc := context.WithTimeout(ctx, m.interval)
+	defer cancelFunc()
+
+	due, err := m.db.ListDueEscalations(ctx, m.batch)
+	if err != nil {
+		logger.WithError(err).Warn("Listing the due escalations")
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	escalations, err := m.db.ListAllEscalations(ctx)
+	if err != nil {
+		logger.WithError(err).Warn("Listing the escalation steps")
+		return
+	}
+	steps := map[model.EscalationID]*model.Escalation{}
+	for _, escalation := range escalations {
+		steps[escalation.ID] = escalation
+	}
+
+	for _, ticketEscalation := range due {
+		escalation, ok := steps[ticketEscalation.EscalationID]
+		if !ok {
+			continue
+		}
+		escalated, err := m.db.EscalateTicket(ctx, ticketEscalation.TicketID, escalation)
+		if err != nil {
+			logger.WithError(err).WithField("TicketID", ticketEscalation.TicketID).Warn("Escalating ticket")
+			continue
+		}
+		if !escalated || !escalation.Notifies() {
+			continue
+		}
+
+		ticket, err := m.db.GetTicketDetails(ctx, &ticketEscalation.TicketID)
+		if err != nil {
+			logger.WithError(err).WithField("TicketID", ticketEscalation.TicketID).Warn("Retrieving the escalated ticket")
+			continue
+		}
+		if err := m.notifier.Notify(ctx, escalation, ticket); err != nil {
+			logger.WithError(err).WithField("TicketID", ticketEscalation.TicketID).Warn("Sending the escalation notification")
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package escalation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/storage/database"
+)
+
+// fakeDB - the due escalations and the steps, a level is claimed once per ticket as in the database.
+// The other methods of the interface are not implemented
+type fakeDB struct {
+	database.Database
+
+	due         []*model.TicketEscalation
+	escalations []*model.Escalation
+	claimed     map[string]bool
+	escalateErr map[model.TicketID]error
+}
+
+func (f *fakeDB) ListDueEscalations(ctx context.Context, limit int) ([]*model.TicketEscalation, error) {
+	if len(f.due) > limit {
+		return f.due[:limit], nil
+	}
+	return f.due, nil
+}
+
+func (f *fakeDB) ListAllEscalations(ctx context.Context) ([]*model.Escalation, error) {
+	return f.escalations, nil
+}
+
+func (f *fakeDB) EscalateTicket(ctx context.Context, ticketID model.TicketID, escalation *model.Escalation) (bool, error) {
+	if err := f.escalateErr[ticketID]; err != nil {
+		return false, err
+	}
+	key := fmt.Sprint(ticketID, *escalation.Level)
+	if f.claimed[key] {
+		return false, nil
+	}
+	f.claimed[key] = true
+	return true, nil
+}
+
+func (f *fakeDB) GetTicketDetails(ctx context.Context, ticketID *model.TicketID) (*model.Ticket, error) {
+	return &model.Ticket{ID: *ticketID}, nil
+}
+
+// fakeNotifier - records the tickets it was told about
+type fakeNotifier struct {
+	notified []string
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, escalation *model.Escalation, ticket *model.Ticket) error {
+	n.notified = append(n.notified, fmt.Sprintf("%s/%d", ticket.ID, *escalation.Level))
+	return nil
+}
+
+func newEscalation(id model.EscalationID, level int, notify bool) *model.Escalation {
+	name := string(id)
+	return &model.Escalation{ID: id, Name: &name, Level: &level, Notify: &notify}
+}
+
+func TestCheck(t *testing.T) {
+
+	db := &fakeDB{
+		due: []*model.TicketEscalation{
+			{TicketID: "a", EscalationID: "warn", Level: 1},
+			{TicketID: "a", EscalationID: "breach", Level: 2},
+			{TicketID: "b", EscalationID: "silent", Level: 1},
+			{TicketID: "c", EscalationID: "deleted", Level: 1},
+			{TicketID: "d", EscalationID: "warn", Level: 1},
+			{TicketID: "e", EscalationID: "warn", Level: 1},
+		},
+		escalations: []*model.Escalation{
+			newEscalation("warn", 1, true),
+			newEscalation("breach", 2, true),
+			newEscalation("silent", 1, false),
+		},
+		claimed:     map[string]bool{},
+		escalateErr: map[model.TicketID]error{"d": errors.New("connection reset")},
+	}
+	notifier := &fakeNotifier{}
+	New(db, notifier, time.Minute, 10).Check(context.Background())
+
+	// the steps that no longer exist are skipped, a failing ticket does not stop the others
+	want := []string{"a/1", "a/2", "e/1"}
+	if fmt.Sprint(notifier.notified) != fmt.Sprint(want) {
+		t.Errorf("notified = %v, want %v", notifier.notified, want)
+	}
+	if !db.claimed["b1"] || db.claimed["c1"] || db.claimed["d1"] {
+		t.Errorf("claimed = %v, want the silent step run and the others left", db.claimed)
+	}
+}
+
+func TestCheckOncePerLevel(t *testing.T) {
+
+	db := &fakeDB{
+		due: []*model.TicketEscalation{
+			{TicketID: "a", EscalationID: "warn", Level: 1},
+			{TicketID: "b", EscalationID: "warn", Level: 1},
+		},
+		escalations: []*model.Escalation{newEscalation("warn", 1, true)},
+		claimed:     map[string]bool{},
+	}
+
+	// two servers find the same tickets due, each level is run and notified once
+	notifier := &fakeNotifier{}
+	New(db, notifier, time.Minute, 10).Check(context.Background())
+	New(db, notifier, time.Minute, 10).Check(context.Background())
+
+	want := []string{"a/1", "b/1"}
+	if fmt.Sprint(notifier.notified) != fmt.Sprint(want) {
+		t.Errorf("notified = %v, want %v", notifier.notified, want)
+	}
+}
+
+func TestCheckBatch(t *testing.T) {
+
+	db := &fakeDB{
+		due: []*model.TicketEscalation{
+			{TicketID: "a", EscalationID: "warn", Level: 1},
+			{TicketID: "b", EscalationID: "warn", Level: 1},
+			{TicketID: "c", EscalationID: "warn", Level: 1},
+		},
+		escalations: []*model.Escalation{newEscalation("warn", 1, true)},
+		claimed:     map[string]bool{},
+	}
+	notifier := &fakeNotifier{}
+	New(db, notifier, time.Minute, 2).Check(context.Background())
+
+	if len(notifier.notified) != 2 {
+		t.Errorf("notified = %v, want the first 2 tickets", notifier.notified)
+	}
+}
@@ -0,0 +1,80 @@
+// Package secret encrypts the credentials kept in the database
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// prefix - marks the encrypted values, the values without it were saved before the encryption
+const prefix = "enc:v1:"
+
+// ErrNoKey - no key was configured
+var ErrNoKey = errors.New("no secret key is configured")
+
+// Box - encrypts and decrypts the secrets with AES-GCM, a nil Box has no key
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New - creates a box from the configured key, nil is returned when the key is empty
+func New(key string) (*Box, error) {
+	if key == "" {
+		return nil, nil
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal - encrypts a secret, empty secrets are kept empty
+func (b *Box) Seal(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	if b == nil {
+		return "", ErrNoKey
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open - decrypts a secret, the values saved before the encryption are returned as they are
+func (b *Box) Open(value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	if b == nil {
+		return "", ErrNoKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil {
+		return "", err
+	}
+	size := b.aead.NonceSize()
+	if len(sealed) < size {
+		return "", errors.New("the secret is too short")
+	}
+	plain, err := b.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,159 @@
+package sla
+
+import (
+	"context"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// maxDays - how far ahead the calendar looks for working hours before giving up
+const maxDays = 3660
+
+// Calendar - counts time in working hours only, a nil Calendar counts every hour
+type Calendar struct {
+	location *time.Location
+	hours    [7]*workingDay
+	holidays map[string]bool
+}
+
+// workingDay - the start and end of the working hours, as time since midnight
+type workingDay struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// NewCalendar - builds the calculator of a business calendar
+func NewCalendar(calendar *model.Calendar) (*Calendar, error) {
+
+	if err := calendar.Verify(); err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(*calendar.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Calendar{location: location, holidays: map[string]bool{}}
+	for _, hours := range calendar.Hours {
+		start, _ := model.ParseClock(hours.Start)
+		end, _ := model.ParseClock(hours.End)
+		c.hours[hours.Weekday] = &workingDay{start: start, end: end}
+	}
+	for _, holiday := range calendar.Holidays {
+		c.holidays[holiday.Date] = true
+	}
+	return c, nil
+}
+
+// CalendarStore - retrieves the business calendars
+type CalendarStore interface {
+	GetCalendarByID(ctx context.Context, calendarID *model.CalendarID) (*model.Calendar, error)
+}
+
+// LoadCalendar - loads the calculator of an SLA calendar, nil means the wall clock is used
+func LoadCalendar(ctx context.Context, store CalendarStore, calendarID model.CalendarID) (*Calendar, error) {
+
+	if calendarID == model.NilCalendarID {
+		return nil, nil
+	}
+	calendar, err := store.GetCalendarByID(ctx, &calendarID)
+	if err != nil {
+		return nil, err
+	}
+	return NewCalendar(calendar)
+}
+
+// Add - returns the time reached after working for d from start
+func (c *Calendar) Add(start time.Time, d time.Duration) time.Time {
+
+	if c == nil {
+		return start.Add(d)
+	}
+	if d <= 0 {
+		return start
+	}
+	// a calendar without working days would only be left after maxDays
+	if !c.hasWorkingDays() {
+		return start.Add(d)
+	}
+
+	t := start.In(c.location)
+	day := c.midnight(t)
+	for i := 0; i < maxDays; i++ {
+		if open, close, ok := c.window(day); ok {
+			if t.Before(open) {
+				t = open
+			}
+			if t.Before(close) {
+				left := close.Sub(t)
+				if d <= left {
+					return t.Add(d)
+				}
+				d -= left
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+		t = day
+	}
+	// the calendar has no working hours left, fall back to the wall clock
+	return t.Add(d)
+}
+
+// Between - returns the working time between from and to, negative when to is before from
+func (c *Calendar) Between(from, to time.Time) time.Duration {
+
+	if c == nil {
+		return to.Sub(from)
+	}
+	if to.Before(from) {
+		return -c.Between(to, from)
+	}
+
+	var total time.Duration
+	day := c.midnight(from.In(c.location))
+	for i := 0; i < maxDays && day.Before(to); i++ {
+		if open, close, ok := c.window(day); ok {
+			if open.Before(from) {
+				open = from
+			}
+			if close.After(to) {
+				close = to
+			}
+			if close.After(open) {
+				total += close.Sub(open)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return total
+}
+
+// window - returns the working hours of a day, ok is false for holidays and days off
+func (c *Calendar) window(day time.Time) (open, close time.Time, ok bool) {
+
+	hours := c.hours[day.Weekday()]
+	if hours == nil || c.holidays[day.Format("2006-01-02")] {
+		return
+	}
+	// the clock is built from the date so that daylight saving changes are respected
+	year, month, date := day.Date()
+	open = time.Date(year, month, date, 0, 0, int(hours.start/time.Second), 0, c.location)
+	close = time.Date(year, month, date, 0, 0, int(hours.end/time.Second), 0, c.location)
+	return open, close, true
+}
+
+// hasWorkingDays - checks a weekday at least has working hours
+func (c *Calendar) hasWorkingDays() bool {
+	for _, hours := range c.hours {
+		if hours != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Calendar) midnight(t time.Time) time.Time {
+	year, month, date := t.Date()
+	return time.Date(year, month, date, 0, 0, 0, 0, c.location)
+}
@@ -0,0 +1,200 @@
+package sla
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // the tests do not depend on the zoneinfo of the machine
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// newTestCalendar - a calendar working the same hours on the weekdays given
+func newTestCalendar(t *testing.T, timeZone, start, end string, weekdays []time.Weekday, holidays ...string) *Calendar {
+	t.Helper()
+
+	name := "test"
+	calendar := &model.Calendar{Name: &name, TimeZone: &timeZone}
+	for _, weekday := range weekdays {
+		calendar.Hours = append(calendar.Hours, &model.WorkingHours{Weekday: weekday, Start: start, End: end})
+	}
+	for _, date := range holidays {
+		calendar.Holidays = append(calendar.Holidays, &model.Holiday{Date: date})
+	}
+	c, err := NewCalendar(calendar)
+	if err != nil {
+		t.Fatalf("NewCalendar() error = %v", err)
+	}
+	return c
+}
+
+var workWeek = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+func TestCalendarAdd(t *testing.T) {
+
+	utc := newTestCalendar(t, "UTC", "09:00", "17:00", workWeek, "2021-03-09")
+	// 2021-03-08 is a Monday, the Tuesday after it is a holiday
+	at := func(day, hour, minute int) time.Time { return time.Date(2021, 3, day, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		start time.Time
+		add   time.Duration
+		want  time.Time
+	}{
+		{"inside working hours", at(1, 10, 0), 2 * time.Hour, at(1, 12, 0)},
+		{"ends at closing time", at(1, 9, 0), 8 * time.Hour, at(1, 17, 0)},
+		{"before working hours", at(1, 7, 30), time.Hour, at(1, 10, 0)},
+		{"after working hours", at(1, 18, 0), time.Hour, at(2, 10, 0)},
+		{"overnight gap", at(1, 16, 0), 2 * time.Hour, at(2, 10, 0)},
+		{"several days", at(1, 9, 0), 20 * time.Hour, at(3, 13, 0)},
+		{"weekend gap", at(5, 16, 0), 2 * time.Hour, at(8, 10, 0)},
+		{"start on the weekend", at(6, 12, 0), time.Hour, at(8, 10, 0)},
+		{"holiday skipped", at(8, 16, 0), 2 * time.Hour, at(10, 10, 0)},
+		{"start on a holiday", at(9, 11, 0), 30 * time.Minute, at(10, 9, 30)},
+		{"nothing to add", at(6, 12, 0), 0, at(6, 12, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utc.Add(tt.start, tt.add); !got.Equal(tt.want) {
+				t.Errorf("Add(%v, %v) = %v, want %v", tt.start, tt.add, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarAddDaylightSaving(t *testing.T) {
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2021, month, day, hour, 0, 0, 0, newYork)
+	}
+
+	office := newTestCalendar(t, "America/New_York", "09:00", "17:00", workWeek)
+	// the clocks change on these Sundays between 01:00 and 05:00
+	night := newTestCalendar(t, "America/New_York", "01:00", "05:00", []time.Weekday{time.Sunday, time.Monday})
+
+	tests := []struct {
+		name     string
+		calendar *Calendar
+		start    time.Time
+		add      time.Duration
+		want     time.Time
+	}{
+		// the working hours stay at 9 o'clock local time once the offset changed
+		{"office hours across spring forward", office, at(time.March, 12, 16), 2 * time.Hour, at(time.March, 15, 10)},
+		{"office hours across fall back", office, at(time.November, 5, 16), 2 * time.Hour, at(time.November, 8, 10)},
+		// the night of March 14 only has three hours, 02:00 is skipped
+		{"short day at spring forward", night, at(time.March, 14, 1), 4 * time.Hour, at(time.March, 15, 2)},
+		// the night of November 7 has five hours, 01:00 happens twice
+		{"long day at fall back", night, at(time.November, 7, 1), 4 * time.Hour, at(time.November, 7, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calendar.Add(tt.start, tt.add); !got.Equal(tt.want) {
+				t.Errorf("Add(%v, %v) = %v, want %v", tt.start, tt.add, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarWithoutWorkingDays(t *testing.T) {
+
+	empty := &Calendar{location: time.UTC, holidays: map[string]bool{}}
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	if got, want := empty.Add(start, time.Hour), start.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Add() = %v, want the wall clock %v", got, want)
+	}
+	if got := empty.Between(start, start.AddDate(0, 0, 7)); got != 0 {
+		t.Errorf("Between() = %v, want 0", got)
+	}
+}
+
+func TestNilCalendar(t *testing.T) {
+
+	var wallClock *Calendar
+	start := time.Date(2021, 3, 6, 23, 0, 0, 0, time.UTC)
+
+	if got, want := wallClock.Add(start, 2*time.Hour), start.Add(2*time.Hour); !got.Equal(want) {
+		t.Errorf("Add() = %v, want %v", got, want)
+	}
+	if got := wallClock.Between(start, start.Add(90*time.Minute)); got != 90*time.Minute {
+		t.Errorf("Between() = %v, want 1h30m", got)
+	}
+}
+
+func TestCalendarBetween(t *testing.T) {
+
+	utc := newTestCalendar(t, "UTC", "09:00", "17:00", workWeek, "2021-03-09")
+	at := func(day, hour int) time.Time { return time.Date(2021, 3, day, hour, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     time.Duration
+	}{
+		{"same day", at(1, 10), at(1, 12), 2 * time.Hour},
+		{"overnight", at(1, 10), at(2, 10), 8 * time.Hour},
+		{"outside working hours", at(1, 18), at(2, 8), 0},
+		{"weekend", at(5, 16), at(8, 10), 2 * time.Hour},
+		{"holiday", at(8, 16), at(10, 10), 2 * time.Hour},
+		{"reversed", at(2, 10), at(1, 10), -8 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utc.Between(tt.from, tt.to); got != tt.want {
+				t.Errorf("Between(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+
+	utc := newTestCalendar(t, "UTC", "09:00", "17:00", workWeek)
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	minutes := func(m int) *int { return &m }
+
+	tests := []struct {
+		name              string
+		target            *model.SLATarget
+		wantFirstResponse *time.Time
+		wantResolution    time.Time
+		wantWarning       time.Time
+	}{
+		{
+			name:              "first response and resolution",
+			target:            &model.SLATarget{FirstResponseMinutes: minutes(60), ResolutionMinutes: minutes(480)},
+			wantFirstResponse: func() *time.Time { t := time.Date(2021, 3, 1, 11, 0, 0, 0, time.UTC); return &t }(),
+			wantResolution:    time.Date(2021, 3, 2, 10, 0, 0, 0, time.UTC),
+			// at risk once three quarters of the resolution time are spent
+			wantWarning: time.Date(2021, 3, 1, 16, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "resolution only",
+			target:         &model.SLATarget{ResolutionMinutes: minutes(120)},
+			wantResolution: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC),
+			wantWarning:    time.Date(2021, 3, 1, 11, 30, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compute(start, tt.target, utc)
+			if !got.Resolution.Equal(tt.wantResolution) {
+				t.Errorf("Resolution = %v, want %v", got.Resolution, tt.wantResolution)
+			}
+			if !got.Warning.Equal(tt.wantWarning) {
+				t.Errorf("Warning = %v, want %v", got.Warning, tt.wantWarning)
+			}
+			switch {
+			case tt.wantFirstResponse == nil && got.FirstResponse != nil:
+				t.Errorf("FirstResponse = %v, want none", *got.FirstResponse)
+			case tt.wantFirstResponse != nil && (got.FirstResponse == nil || !got.FirstResponse.Equal(*tt.wantFirstResponse)):
+				t.Errorf("FirstResponse = %v, want %v", got.FirstResponse, *tt.wantFirstResponse)
+			}
+		})
+	}
+}
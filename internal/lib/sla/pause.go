@@ -0,0 +1,76 @@
+package sla
+
+import (
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// StatusField - the activity field that records the status changes of a ticket
+const StatusField = "status_id"
+
+// Paused - returns the working time a ticket spent in pausing statuses between start and until.
+// The status history is read from the ticket activities in the order they happened, current is
+// the status the ticket is in at until and is used when the history has no status change.
+func Paused(start, until time.Time, current model.StatusID, history []*model.Activity, pausing map[model.StatusID]bool, calendar *Calendar) time.Duration {
+
+	changes := []*model.Activity{}
+	for _, activity := range history {
+		if activity.Field != nil && *activity.Field == StatusField && activity.CreatedAt != nil {
+			changes = append(changes, activity)
+		}
+	}
+
+	// the status the ticket was created in is the old value of its first change
+	status := current
+	if len(changes) != 0 {
+		status = model.StatusID(value(changes[0].OldValue))
+	}
+
+	var paused time.Duration
+	from := start
+	for _, change := range changes {
+		changedAt := *change.CreatedAt
+		if changedAt.After(until) {
+			break
+		}
+		if pausing[status] && changedAt.After(from) {
+			paused += calendar.Between(from, changedAt)
+		}
+		status = model.StatusID(value(change.NewValue))
+		if changedAt.After(from) {
+			from = changedAt
+		}
+	}
+	if pausing[status] && until.After(from) {
+		paused += calendar.Between(from, until)
+	}
+	return paused
+}
+
+// Extend - moves the deadlines of the ticket by the paused working time
+func Extend(ticket *model.Ticket, paused time.Duration, calendar *Calendar) {
+
+	if paused <= 0 {
+		return
+	}
+	extend := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		extended := calendar.Add(*t, paused)
+		return &extended
+	}
+	ticket.DueDate = extend(ticket.DueDate)
+	ticket.SLAWarningAt = extend(ticket.SLAWarningAt)
+	if ticket.FirstRespondedAt == nil {
+		ticket.FirstResponseDue = extend(ticket.FirstResponseDue)
+	}
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
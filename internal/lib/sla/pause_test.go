@@ -0,0 +1,212 @@
+package sla
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+const (
+	open    model.StatusID = "open"
+	onHold  model.StatusID = "on-hold"
+	pending model.StatusID = "pending"
+)
+
+var pausing = map[model.StatusID]bool{onHold: true, pending: true}
+
+// statusChange - the activity recorded when the status of a ticket changes
+func statusChange(at time.Time, from, to model.StatusID) *model.Activity {
+	field, old, new := StatusField, string(from), string(to)
+	return &model.Activity{Action: "updated", Field: &field, OldValue: &old, NewValue: &new, CreatedAt: &at}
+}
+
+func TestPaused(t *testing.T) {
+
+	utc := newTestCalendar(t, "UTC", "09:00", "17:00", workWeek)
+	// 2021-03-01 is a Monday
+	at := func(day, hour int) time.Time { return time.Date(2021, 3, day, hour, 0, 0, 0, time.UTC) }
+	start := at(1, 9)
+
+	priority := "priority_id"
+	otherField := &model.Activity{Field: &priority, CreatedAt: func() *time.Time { t := at(1, 10); return &t }()}
+
+	tests := []struct {
+		name    string
+		until   time.Time
+		current model.StatusID
+		history []*model.Activity
+		want    time.Duration
+	}{
+		{"never paused", at(2, 10), open, nil, 0},
+		{"paused since created", at(1, 12), onHold, nil, 3 * time.Hour},
+		{
+			name:    "pause then resume",
+			until:   at(2, 10),
+			current: open,
+			history: []*model.Activity{statusChange(at(1, 10), open, onHold), statusChange(at(1, 12), onHold, open)},
+			want:    2 * time.Hour,
+		},
+		{
+			name:    "still paused",
+			until:   at(1, 13),
+			current: onHold,
+			history: []*model.Activity{statusChange(at(1, 10), open, onHold)},
+			want:    3 * time.Hour,
+		},
+		{
+			name:    "several pauses",
+			until:   at(2, 10),
+			current: open,
+			history: []*model.Activity{
+				statusChange(at(1, 10), open, onHold),
+				statusChange(at(1, 11), onHold, open),
+				statusChange(at(1, 14), open, pending),
+				statusChange(at(1, 16), pending, open),
+			},
+			want: 3 * time.Hour,
+		},
+		{
+			name:    "moving between pausing statuses",
+			until:   at(2, 10),
+			current: open,
+			history: []*model.Activity{
+				statusChange(at(1, 10), open, onHold),
+				statusChange(at(1, 11), onHold, pending),
+				statusChange(at(1, 13), pending, open),
+			},
+			want: 3 * time.Hour,
+		},
+		{
+			// only the two working hours of Friday and Monday count, not the weekend
+			name:    "pause over the weekend",
+			until:   at(9, 10),
+			current: open,
+			history: []*model.Activity{statusChange(at(5, 16), open, onHold), statusChange(at(8, 10), onHold, open)},
+			want:    2 * time.Hour,
+		},
+		{
+			name:    "pause overnight",
+			until:   at(3, 10),
+			current: open,
+			history: []*model.Activity{statusChange(at(1, 16), open, onHold), statusChange(at(2, 10), onHold, open)},
+			want:    2 * time.Hour,
+		},
+		{
+			name:    "changes after until are ignored",
+			until:   at(1, 11),
+			current: open,
+			history: []*model.Activity{statusChange(at(1, 10), open, onHold), statusChange(at(1, 12), onHold, open)},
+			want:    time.Hour,
+		},
+		{
+			name:    "other fields are ignored",
+			until:   at(1, 12),
+			current: open,
+			history: []*model.Activity{otherField},
+			want:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Paused(start, tt.until, tt.current, tt.history, pausing, utc); got != tt.want {
+				t.Errorf("Paused() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtend(t *testing.T) {
+
+	utc := newTestCalendar(t, "UTC", "09:00", "17:00", workWeek)
+	at := func(day, hour int) *time.Time { t := time.Date(2021, 3, day, hour, 0, 0, 0, time.UTC); return &t }
+
+	tests := []struct {
+		name                 string
+		ticket               model.Ticket
+		paused               time.Duration
+		wantDue, wantWarning *time.Time
+		wantFirstResponseDue *time.Time
+	}{
+		{
+			name:                 "deadlines moved in working hours",
+			ticket:               model.Ticket{DueDate: at(1, 16), SLAWarningAt: at(1, 14), FirstResponseDue: at(1, 10)},
+			paused:               2 * time.Hour,
+			wantDue:              at(2, 10),
+			wantWarning:          at(1, 16),
+			wantFirstResponseDue: at(1, 12),
+		},
+		{
+			name:                 "first response already given",
+			ticket:               model.Ticket{DueDate: at(1, 16), SLAWarningAt: at(1, 14), FirstResponseDue: at(1, 10), FirstRespondedAt: at(1, 9)},
+			paused:               time.Hour,
+			wantDue:              at(1, 17),
+			wantWarning:          at(1, 15),
+			wantFirstResponseDue: at(1, 10),
+		},
+		{
+			name:                 "nothing paused",
+			ticket:               model.Ticket{DueDate: at(1, 16), SLAWarningAt: at(1, 14)},
+			wantDue:              at(1, 16),
+			wantWarning:          at(1, 14),
+			wantFirstResponseDue: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := tt.ticket
+			Extend(&ticket, tt.paused, utc)
+			checkTime(t, "DueDate", ticket.DueDate, tt.wantDue)
+			checkTime(t, "SLAWarningAt", ticket.SLAWarningAt, tt.wantWarning)
+			checkTime(t, "FirstResponseDue", ticket.FirstResponseDue, tt.wantFirstResponseDue)
+		})
+	}
+}
+
+// TestPriorityChangeDuringPause - the deadlines are computed again from the new target the way the
+// tickets API does, the time left is frozen until the pause ends
+func TestPriorityChangeDuringPause(t *testing.T) {
+
+	utc := newTestCalendar(t, "UTC", "09:00", "17:00", workWeek)
+	at := func(day, hour int) time.Time { return time.Date(2021, 3, day, hour, 0, 0, 0, time.UTC) }
+	minutes := func(m int) *int { return &m }
+
+	start := at(1, 9)
+	pausedAt := at(1, 11)
+	history := []*model.Activity{statusChange(pausedAt, open, onHold)}
+	ticket := model.Ticket{CreatedAt: &start, StatusID: onHold, SLAPausedAt: &pausedAt}
+
+	// the priority changes on Monday at 15:00, the new target gives 4 working hours
+	urgent := &model.SLATarget{ResolutionMinutes: minutes(240)}
+	Apply(&ticket, start, urgent, utc)
+	Extend(&ticket, Paused(start, pausedAt, onHold, history, pausing, utc), utc)
+
+	checkTime(t, "DueDate while paused", ticket.DueDate, func() *time.Time { t := at(1, 13); return &t }())
+	// two hours were worked before the pause
+	if remaining := Remaining(&ticket, at(1, 15), utc); remaining == nil || *remaining != 2*time.Hour {
+		t.Errorf("Remaining() while paused = %v, want 2h", remaining)
+	}
+
+	// the ticket is opened again on Tuesday at 10:00, the two hours left start from there
+	resumedAt := at(2, 10)
+	history = append(history, statusChange(resumedAt, onHold, open))
+	ticket.StatusID, ticket.SLAPausedAt = open, nil
+	Apply(&ticket, start, urgent, utc)
+	Extend(&ticket, Paused(start, resumedAt, open, history, pausing, utc), utc)
+
+	checkTime(t, "DueDate once resumed", ticket.DueDate, func() *time.Time { t := at(2, 12); return &t }())
+	if remaining := Remaining(&ticket, resumedAt, utc); remaining == nil || *remaining != 2*time.Hour {
+		t.Errorf("Remaining() once resumed = %v, want 2h", remaining)
+	}
+}
+
+func checkTime(t *testing.T, name string, got, want *time.Time) {
+	t.Helper()
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("%s = %v, want %v", name, got, want)
+	case !got.Equal(*want):
+		t.Errorf("%s = %v, want %v", name, *got, *want)
+	}
+}
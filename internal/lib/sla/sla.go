@@ -0,0 +1,56 @@
+// Package sla computes the SLA deadlines of tickets
+package sla
+
+import (
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// AtRiskRatio - the part of the resolution time left when a ticket becomes at risk
+const AtRiskRatio = 0.25
+
+// Deadlines - the due dates of a ticket under an SLA target
+type Deadlines struct {
+	FirstResponse *time.Time // nil when the target has no first response time
+	Resolution    time.Time
+	Warning       time.Time
+}
+
+// Compute - returns the deadlines for a ticket opened at start, the times are counted in the calendar's working hours
+func Compute(start time.Time, target *model.SLATarget, calendar *Calendar) Deadlines {
+
+	resolution := target.Resolution()
+	deadlines := Deadlines{
+		Resolution: calendar.Add(start, resolution),
+		Warning:    calendar.Add(start, resolution-time.Duration(float64(resolution)*AtRiskRatio)),
+	}
+	if firstResponse := target.FirstResponse(); firstResponse > 0 {
+		due := calendar.Add(start, firstResponse)
+		deadlines.FirstResponse = &due
+	}
+	return deadlines
+}
+
+// Apply - sets the deadlines of the ticket, counted from when it was opened
+func Apply(ticket *model.Ticket, start time.Time, target *model.SLATarget, calendar *Calendar) {
+
+	deadlines := Compute(start, target, calendar)
+	ticket.DueDate = &deadlines.Resolution
+	ticket.SLAWarningAt = &deadlines.Warning
+	ticket.FirstResponseDue = deadlines.FirstResponse
+}
+
+// Remaining - the working time left before the resolution deadline, negative once it has passed.
+// The time left does not change while the SLA is paused.
+func Remaining(ticket *model.Ticket, now time.Time, calendar *Calendar) *time.Duration {
+
+	if ticket.DueDate == nil || ticket.ClosedAt != nil {
+		return nil
+	}
+	if ticket.SLAPaused() {
+		now = *ticket.SLAPausedAt
+	}
+	remaining := calendar.Between(now, *ticket.DueDate)
+	return &remaining
+}
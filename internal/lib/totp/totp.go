@@ -0,0 +1,128 @@
+// Package totp generates and checks the time based one time passwords of RFC 6238
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period - the seconds a code is valid for
+	Period = 30
+	// Digits - the length of the codes
+	Digits = 6
+	// Skew - the steps before and after the current one accepted, for the clocks drifting apart
+	Skew = 1
+
+	// secretSize - 160 bits, the size of the SHA1 output recommended by RFC 4226
+	secretSize = 20
+)
+
+// encoding - the authenticator apps take the secrets in base32 without padding
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret - a new random secret, base32 encoded
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI - the otpauth URI shown as a QR code to enroll an authenticator app
+func ProvisioningURI(issuer, account, secret string) string {
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(Period))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	// some apps show the + of the query encoding instead of a space
+	return "otpauth://totp/" + label + "?" + strings.Replace(query.Encode(), "+", "%20", -1)
+}
+
+// Step - the time step of t, the counter the codes are computed from
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// Code - the code of the secret at the time t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(Step(t)), Digits), nil
+}
+
+// Validate - checks the code against the steps around the time t, the steps up to lastStep were
+// already used and are refused so a code cannot be replayed. The step of the code is returned to be
+// kept as the new last step.
+func Validate(secret, code string, t time.Time, lastStep int64) (int64, bool, error) {
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	code = strings.Replace(strings.TrimSpace(code), " ", "", -1)
+	if len(code) != Digits {
+		return 0, false, nil
+	}
+
+	current := Step(t)
+	for step := current - Skew; step <= current+Skew; step++ {
+		if step <= lastStep || step < 0 {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(step), Digits)), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// hotp - the HMAC based one time password of RFC 4226 for the counter
+func hotp(key []byte, counter uint64, digits int) string {
+
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+// decodeSecret - the secrets typed by hand may be in lower case and grouped with spaces
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.Replace(strings.TrimSpace(secret), " ", "", -1))
+	key, err := encoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid totp secret: %v", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("empty totp secret")
+	}
+	return key, nil
+}
@@ -0,0 +1,172 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret - the SHA1 seed of RFC 6238 Appendix B, "12345678901234567890" in base32
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// TestRFC6238Vectors - the SHA1 test vectors of RFC 6238 Appendix B
+func TestRFC6238Vectors(t *testing.T) {
+
+	key, err := decodeSecret(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0)
+		if got := hotp(key, uint64(Step(at)), 8); got != tt.want {
+			t.Errorf("hotp(T=%d, 8 digits) = %s, want %s", tt.unix, got, tt.want)
+		}
+		// the 6 digit codes are the last digits of the same value
+		if got, err := Code(rfcSecret, at); err != nil || got != tt.want[2:] {
+			t.Errorf("Code(T=%d) = %s, %v, want %s", tt.unix, got, err, tt.want[2:])
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	codeAt := func(offset time.Duration) string {
+		code, err := Code(rfcSecret, now.Add(offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current step", rfcSecret, codeAt(0), 0, true, current},
+		{"one step behind", rfcSecret, codeAt(-Period * time.Second), 0, true, current - 1},
+		{"one step ahead", rfcSecret, codeAt(Period * time.Second), 0, true, current + 1},
+		{"two steps behind", rfcSecret, codeAt(-2 * Period * time.Second), 0, false, 0},
+		{"two steps ahead", rfcSecret, codeAt(2 * Period * time.Second), 0, false, 0},
+		{"replayed step", rfcSecret, codeAt(0), current, false, 0},
+		{"step before the last used", rfcSecret, codeAt(-Period * time.Second), current - 1, false, 0},
+		{"step after the last used", rfcSecret, codeAt(0), current - 1, true, current},
+		{"wrong code", rfcSecret, "000000", 0, false, 0},
+		{"too short", rfcSecret, codeAt(0)[1:], 0, false, 0},
+		{"spaces in the code", rfcSecret, codeAt(0)[:3] + " " + codeAt(0)[3:], 0, true, current},
+		{"lower case secret", strings.ToLower(rfcSecret), codeAt(0), 0, true, current},
+		{"grouped secret", groups(rfcSecret), codeAt(0), 0, true, current},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := Validate(tt.secret, tt.code, now, tt.lastStep)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestPaddedSecret - a secret whose size is not a multiple of 5 bytes is padded in base32
+func TestPaddedSecret(t *testing.T) {
+
+	now := time.Unix(1234567890, 0)
+	padded := base32.StdEncoding.EncodeToString([]byte("1234567890123456789"))
+	if !strings.HasSuffix(padded, "=") {
+		t.Fatalf("%s is not padded", padded)
+	}
+	unpadded := strings.TrimRight(padded, "=")
+
+	want, err := Code(unpadded, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{padded, strings.ToLower(padded)} {
+		if got, err := Code(secret, now); err != nil || got != want {
+			t.Errorf("Code(%s) = %s, %v, want %s", secret, got, err, want)
+		}
+		if _, ok, err := Validate(secret, want, now, 0); err != nil || !ok {
+			t.Errorf("Validate(%s) = %v, %v, want true", secret, ok, err)
+		}
+	}
+}
+
+func TestInvalidSecret(t *testing.T) {
+
+	for _, secret := range []string{"", "not base32!", "   "} {
+		if _, _, err := Validate(secret, "123456", time.Now(), 0); err == nil {
+			t.Errorf("Validate(%q) error = nil, want an error", secret)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+
+	first, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := GenerateSecret()
+	if first == second {
+		t.Error("GenerateSecret() returned the same secret twice")
+	}
+	key, err := decodeSecret(first)
+	if err != nil || len(key) != secretSize {
+		t.Errorf("GenerateSecret() = %s, decodes to %d bytes, %v", first, len(key), err)
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+
+	uri := ProvisioningURI("ASA Ticket", "agent@example.com", rfcSecret)
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("ProvisioningURI() = %s, want an otpauth://totp URI", uri)
+	}
+	if parsed.Path != "/ASA Ticket:agent@example.com" {
+		t.Errorf("label = %s", parsed.Path)
+	}
+	if strings.Contains(parsed.RawQuery, "+") {
+		t.Errorf("query %s encodes the spaces with +", parsed.RawQuery)
+	}
+	query := parsed.Query()
+	for key, want := range map[string]string{"secret": rfcSecret, "issuer": "ASA Ticket", "algorithm": "SHA1", "digits": "6", "period": "30"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %s, want %s", key, got, want)
+		}
+	}
+}
+
+// groups - the secret split in groups of four the way the apps show it
+func groups(secret string) string {
+	var parts []string
+	for len(secret) > 4 {
+		parts = append(parts, secret[:4])
+		secret = secret[4:]
+	}
+	return strings.ToLower(strings.Join(append(parts, secret), " "))
+}
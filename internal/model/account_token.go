@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// AccountTokenPurpose - what a mailed account token can be used for
+type AccountTokenPurpose string
+
+const (
+	// PurposePasswordReset - the token sets a new password
+	PurposePasswordReset AccountTokenPurpose = "password_reset"
+	// PurposeEmailVerification - the token confirms the user owns the email of their account
+	PurposeEmailVerification AccountTokenPurpose = "email_verification"
+)
+
+// AccountToken - a single use token mailed to a user, only its hash is stored
+type AccountToken struct {
+	UserID    UserID              `json:"user_id" db:"user_id"`
+	Purpose   AccountTokenPurpose `json:"purpose" db:"purpose"`
+	Token     string              `json:"-" db:"-"`
+	ExpiresAt time.Time           `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time          `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt *time.Time          `json:"created_at,omitempty" db:"created_at"`
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CalendarID is the identifier for a business calendar
+type CalendarID string
+
+// NilCalendarID is an empty CalendarID
+var NilCalendarID CalendarID
+
+// HolidayID is the identifier for a holiday
+type HolidayID string
+
+// Calendar - represents the working hours and holidays used to compute SLA deadlines
+type Calendar struct {
+	ID        CalendarID      `json:"id,omitempty" db:"calendar_id"`
+	Name      *string         `json:"name,omitempty" db:"name"`
+	TimeZone  *string         `json:"time_zone,omitempty" db:"time_zone"`
+	Hours     []*WorkingHours `json:"hours,omitempty" db:"-"`
+	Holidays  []*Holiday      `json:"holidays,omitempty" db:"-"`
+	CreatedAt *time.Time      `json:"created_at,omitempty"  db:"created_at"`
+	UpdatedAt *time.Time      `json:"updated_at,omitempty"  db:"updated_at"`
+	DeletedAt *time.Time      `json:"deleted_at,omitempty"  db:"deleted_at"`
+}
+
+// WorkingHours - the working hours of a weekday, Start and End use the HH:MM format
+type WorkingHours struct {
+	CalendarID CalendarID   `json:"-" db:"calendar_id"`
+	Weekday    time.Weekday `json:"weekday" db:"weekday"`
+	Start      string       `json:"start" db:"start_time"`
+	End        string       `json:"end" db:"end_time"`
+}
+
+// Holiday - a day without working hours, Date uses the YYYY-MM-DD format
+type Holiday struct {
+	ID         HolidayID  `json:"id,omitempty" db:"holiday_id"`
+	CalendarID CalendarID `json:"-" db:"calendar_id"`
+	Date       string     `json:"date" db:"date"`
+	Name       *string    `json:"name,omitempty" db:"name"`
+}
+
+// Decode - Calendar to JSON
+func (c *Calendar) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(&c)
+}
+
+// Verify -  ensures required variables are present and valid
+func (c *Calendar) Verify() error {
+
+	if c.Name == nil || len(*c.Name) == 0 {
+		return errors.New("Name is required")
+	}
+	if c.TimeZone == nil || len(*c.TimeZone) == 0 {
+		c.TimeZone = func() *string { s := "UTC"; return &s }()
+	}
+	if _, err := time.LoadLocation(*c.TimeZone); err != nil {
+		return fmt.Errorf("Invalid time zone %s", *c.TimeZone)
+	}
+	if len(c.Hours) == 0 {
+		return errors.New("Working hours are required")
+	}
+	return c.verifyDays()
+}
+
+// verifyDays - checks the working hours and the holidays
+func (c *Calendar) verifyDays() error {
+
+	weekdays := map[time.Weekday]bool{}
+	for _, hours := range c.Hours {
+		if err := hours.Verify(); err != nil {
+			return err
+		}
+		if weekdays[hours.Weekday] {
+			return fmt.Errorf("%s has more than one set of working hours", hours.Weekday)
+		}
+		weekdays[hours.Weekday] = true
+	}
+
+	dates := map[string]bool{}
+	for _, holiday := range c.Holidays {
+		if _, err := time.Parse("2006-01-02", holiday.Date); err != nil {
+			return fmt.Errorf("Invalid holiday date %s, use YYYY-MM-DD", holiday.Date)
+		}
+		if dates[holiday.Date] {
+			return fmt.Errorf("%s is a holiday more than once", holiday.Date)
+		}
+		dates[holiday.Date] = true
+	}
+	return nil
+}
+
+// UpdateValues is used to update empty values, the hours and holidays are replaced when sent
+func (c *Calendar) UpdateValues(nv *Calendar) { //nv means new values
+	// Avoid updating the same values
+	if c == nv {
+		return
+	}
+
+	if nv.Name != nil && len(*nv.Name) != 0 {
+		c.Name = nv.Name
+	}
+	if nv.TimeZone != nil && len(*nv.TimeZone) != 0 {
+		c.TimeZone = nv.TimeZone
+	}
+	if nv.Hours != nil {
+		c.Hours = nv.Hours
+	}
+	if nv.Holidays != nil {
+		c.Holidays = nv.Holidays
+	}
+}
+
+// Verify -  ensures the working hours are within a day
+func (h *WorkingHours) Verify() error {
+
+	if h.Weekday < time.Sunday || h.Weekday > time.Saturday {
+		return errors.New("Weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	start, err := ParseClock(h.Start)
+	if err != nil {
+		return err
+	}
+	end, err := ParseClock(h.End)
+	if err != nil {
+		return err
+	}
+	if end <= start {
+		return fmt.Errorf("The working hours of %s must end after they start", h.Weekday)
+	}
+	return nil
+}
+
+// ParseClock - returns the time since midnight of a HH:MM or HH:MM:SS clock
+func ParseClock(clock string) (time.Duration, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid time %s, use HH:MM", clock)
+}
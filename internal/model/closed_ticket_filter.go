@@ -0,0 +1,49 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+)
+
+// ClosedTicketFilter - holds the values used to filter and page closed tickets
+type ClosedTicketFilter struct {
+	CauseID    CauseID
+	ClosedByID UserID
+
+	ClosedFrom *time.Time
+	ClosedTo   *time.Time
+
+	Order string
+
+	Limit  int
+	Offset int
+}
+
+// Verify -  ensures the filter values are safe and sets the defaults
+func (f *ClosedTicketFilter) Verify() error {
+
+	f.Order = strings.ToLower(f.Order)
+	if f.Order == "" {
+		f.Order = "desc"
+	} else if !utils.ItemExists(sortOrders, f.Order) {
+		return errors.New("Invalid order, use asc or desc")
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = DefaultTicketLimit
+	} else if f.Limit > MaxTicketLimit {
+		f.Limit = MaxTicketLimit
+	}
+	if f.Offset < 0 {
+		return errors.New("Offset cannot be negative")
+	}
+
+	if f.ClosedFrom != nil && f.ClosedTo != nil && f.ClosedFrom.After(*f.ClosedTo) {
+		return errors.New("closed_from must be before closed_to")
+	}
+
+	return nil
+}
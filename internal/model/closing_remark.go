@@ -17,11 +17,11 @@ var NilClosingRemarkID ClosingRemarkID
 type ClosingRemark struct {
 	ID       ClosingRemarkID `json:"id,omitempty" db:"remark_id"`
 	UserID   UserID          `json:"-" db:"closed_by"`
-	ClosedBy *User           `json:"closed_by,omitempty"`
+	ClosedBy *User           `json:"closed_by,omitempty" db:"closer"`
 	TicketID TicketID        `json:"ticket_id,omitempty" db:"ticket_id"`
-	Ticket   *Ticket         `json:"ticket,omitempty"`
+	Ticket   *Ticket         `json:"ticket,omitempty" db:"-"`
 	CauseID  CauseID         `json:"cause_id,omitempty" db:"cause_id"`
-	Cause    *Cause          `json:"cause,omitempty"`
+	Cause    *Cause          `json:"cause,omitempty" db:"cause"`
 	Remark   *string         `json:"remark,omitempty" db:"remark"`
 
 	CreatedAt *time.Time `json:"created_at,omitempty"  db:"created_at"`
@@ -24,6 +24,9 @@ type Principal struct {
 	Name   string `json:"name,omitempty"`
 	Role   string `json:"role,omitempty"`
 	Type   string `json:"type,omitempty"`
+
+	// TokenVersion - the version of the tokens of the user the token was issued with
+	TokenVersion int64 `json:"-"`
 }
 
 // Decode - Credentials to JSON
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// QueuedEmailID is the identifier for a mail waiting to be sent
+type QueuedEmailID string
+
+// NilQueuedEmailID is an empty QueuedEmailID
+var NilQueuedEmailID QueuedEmailID
+
+// QueuedEmail - a mail waiting to be sent, it stays in the queue until the SMTP server accepts it
+type QueuedEmail struct {
+	ID            QueuedEmailID `json:"id,omitempty" db:"queue_id"`
+	TicketID      TicketID      `json:"ticket_id,omitempty" db:"ticket_id"`
+	NoteID        NoteID        `json:"-" db:"-"` // the reply the mail was sent for
+	Event         string        `json:"event" db:"event"`
+	To            string        `json:"to" db:"to_address"`
+	Subject       string        `json:"subject" db:"subject"`
+	Body          string        `json:"body" db:"body"`
+	MessageID     string        `json:"message_id" db:"message_id"`
+	InReplyTo     string        `json:"in_reply_to,omitempty" db:"in_reply_to"`
+	References    string        `json:"references,omitempty" db:"refs"` // space separated, as in the header
+	Attempts      int           `json:"attempts" db:"attempts"`
+	NextAttemptAt *time.Time    `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastError     string        `json:"last_error,omitempty" db:"last_error"`
+	SentAt        *time.Time    `json:"sent_at,omitempty" db:"sent_at"`
+	ExpiresAt     *time.Time    `json:"expires_at,omitempty" db:"expires_at"` // set on the mails carrying a token, their body is not kept
+	CreatedAt     *time.Time    `json:"created_at,omitempty" db:"created_at"`
+}
+
+// maxRetryDelay - failed mails are tried at least this often
+const maxRetryDelay = time.Hour
+
+// RetryDelay - the wait before the next attempt, it doubles with every failure up to an hour
+func (q *QueuedEmail) RetryDelay() time.Duration {
+	if q.Attempts > 6 {
+		return maxRetryDelay
+	}
+	delay := time.Duration(math.Pow(2, float64(q.Attempts))) * time.Minute
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
@@ -1,6 +1,11 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
 
 // InboudMailID is the identifier for the student
 type InboudMailID string
@@ -8,7 +13,7 @@ type InboudMailID string
 // NilInboudMailID is an empty InboudMailID
 var NilInboudMailID InboudMailID
 
-// InboudMail is a structure that represents InboudMail Object
+// InboudMail is a structure that represents InboudMail Object, EmailSecret is only read from the requests
 type InboudMail struct {
 	ID          InboudMailID `json:"id,omitempty" db:"email_id"`
 	Name        *string      `json:"name,omitempty" db:"name"`
@@ -25,7 +30,162 @@ type InboudMail struct {
 	LastSynced  *time.Time   `json:"last_synced,omitempty"  db:"last_synced"`
 	UserID      UserID       `json:"created_by,omitempty" db:"created_by"`
 	DeleteSeen  *bool        `json:"delete_seen,omitempty" db:"delete_seen"`
-	CreatedAt   *time.Time   `json:"created_at,omitempty"  db:"created_at"`
-	UpdatedAt   *time.Time   `json:"updated_at,omitempty"  db:"updated_at"`
-	DeletedAt   *time.Time   `json:"deleted_at,omitempty"  db:"deleted_at"`
+	Active      *bool        `json:"active,omitempty" db:"active"` // only the active mailboxes are polled
+
+	// The position of the sync, the UIDs are only valid for their UIDValidity
+	UIDValidity uint32 `json:"uid_validity,omitempty" db:"uid_validity"`
+	LastUID     uint32 `json:"last_uid,omitempty" db:"last_uid"`
+
+	// The health of the mailbox
+	LastError         *string    `json:"last_error,omitempty" db:"last_error"`
+	LastErrorAt       *time.Time `json:"last_error_at,omitempty" db:"last_error_at"`
+	MessagesProcessed *int64     `json:"messages_processed,omitempty" db:"messages_processed"`
+
+	// The properties given to the tickets created from the mails
+	CategoryID CategoryID `json:"category_id,omitempty" db:"category_id"`
+	PriorityID PriorityID `json:"priority_id,omitempty" db:"priority_id"`
+	SLAID      SLAID      `json:"sla_id,omitempty" db:"agreement_id"`
+	SourceID   SourceID   `json:"source_id,omitempty" db:"source_id"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"  db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"  db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"  db:"deleted_at"`
+}
+
+// MailboxHealth - the state of the poller of a mailbox
+type MailboxHealth struct {
+	ID                InboudMailID `json:"id"`
+	Name              string       `json:"name"`
+	Active            bool         `json:"active"`
+	Running           bool         `json:"running"`   // the mailbox has a poller
+	Connected         bool         `json:"connected"` // the poller is logged in
+	Idle              bool         `json:"idle"`      // the server pushes the new mails, otherwise they are polled
+	Status            string       `json:"status"`
+	LastSynced        *time.Time   `json:"last_synced,omitempty"`
+	LastError         string       `json:"last_error,omitempty"`
+	LastErrorAt       *time.Time   `json:"last_error_at,omitempty"`
+	MessagesProcessed int64        `json:"messages_processed"`
+	LastUID           uint32       `json:"last_uid"`
+}
+
+// MarshalJSON - the secret of the mailbox is never sent back
+func (m InboudMail) MarshalJSON() ([]byte, error) {
+	type inboundMail InboudMail
+	mail := inboundMail(m)
+	mail.EmailSecret = nil
+	return json.Marshal(mail)
+}
+
+// Decode - InboudMail to JSON
+func (m *InboudMail) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(&m)
+}
+
+// Verify - ensures the connection values are present and sets the defaults
+func (m *InboudMail) Verify() error {
+
+	if m.Name == nil || len(*m.Name) == 0 {
+		return errors.New("Name is required")
+	}
+	if m.Address == nil || len(*m.Address) == 0 {
+		return errors.New("Address is required")
+	}
+	if m.EmailUser == nil || len(*m.EmailUser) == 0 {
+		return errors.New("Email user is required")
+	}
+	if m.EmailSecret == nil || len(*m.EmailSecret) == 0 {
+		return errors.New("Email secret is required")
+	}
+	if m.Port == nil {
+		m.Port = func() *int { p := 993; return &p }()
+	}
+	if *m.Port <= 0 || *m.Port > 65535 {
+		return errors.New("Port is not valid")
+	}
+	if m.Secured == nil {
+		m.Secured = func() *bool { b := true; return &b }()
+	}
+	if m.Mailbox == nil || len(*m.Mailbox) == 0 {
+		m.Mailbox = func() *string { s := "INBOX"; return &s }()
+	}
+	if m.PollPeriod == nil {
+		m.PollPeriod = func() *int { p := 5; return &p }()
+	}
+	if *m.PollPeriod <= 0 {
+		return errors.New("Poll period must be at least a minute")
+	}
+	if m.LastSeq == nil {
+		m.LastSeq = func() *int { i := 0; return &i }()
+	}
+	if m.Status == nil {
+		m.Status = func() *string { s := ""; return &s }()
+	}
+	if m.IsPrimary == nil {
+		m.IsPrimary = func() *bool { b := false; return &b }()
+	}
+	if m.DeleteSeen == nil {
+		m.DeleteSeen = func() *bool { b := false; return &b }()
+	}
+	if m.Active == nil {
+		m.Active = func() *bool { b := true; return &b }()
+	}
+	return nil
+}
+
+// UpdateValues is used to update empty values
+func (m *InboudMail) UpdateValues(nv *InboudMail) { //nv means new values
+	// Avoid updating the same values
+	if m == nv {
+		return
+	}
+	if nv.Name != nil && len(*nv.Name) != 0 {
+		m.Name = nv.Name
+	}
+	if nv.Address != nil && len(*nv.Address) != 0 {
+		m.Address = nv.Address
+	}
+	if nv.EmailUser != nil && len(*nv.EmailUser) != 0 {
+		m.EmailUser = nv.EmailUser
+	}
+	if nv.EmailSecret != nil && len(*nv.EmailSecret) != 0 {
+		m.EmailSecret = nv.EmailSecret
+	}
+	if nv.Port != nil {
+		m.Port = nv.Port
+	}
+	if nv.Secured != nil {
+		m.Secured = nv.Secured
+	}
+	if nv.Mailbox != nil && len(*nv.Mailbox) != 0 {
+		m.Mailbox = nv.Mailbox
+	}
+	if nv.PollPeriod != nil {
+		m.PollPeriod = nv.PollPeriod
+	}
+	if nv.IsPrimary != nil {
+		m.IsPrimary = nv.IsPrimary
+	}
+	if nv.DeleteSeen != nil {
+		m.DeleteSeen = nv.DeleteSeen
+	}
+	if nv.Active != nil {
+		m.Active = nv.Active
+	}
+	if nv.CategoryID != NilCategoryID {
+		m.CategoryID = nv.CategoryID
+	}
+	if nv.PriorityID != NilPriorityID {
+		m.PriorityID = nv.PriorityID
+	}
+	if nv.SLAID != NilSLAID {
+		m.SLAID = nv.SLAID
+	}
+	if nv.SourceID != NilSourceID {
+		m.SourceID = nv.SourceID
+	}
+}
+
+// IsActive - reports if the mailbox is polled
+func (m *InboudMail) IsActive() bool {
+	return m.Active == nil || *m.Active
 }
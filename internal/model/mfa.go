@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+)
+
+// MFA - the authenticator app of a user, the secret is only used once enrolment is confirmed
+type MFA struct {
+	UserID         UserID     `json:"-" db:"user_id"`
+	Secret         string     `json:"-" db:"totp_secret"`
+	EnabledAt      *time.Time `json:"enabled_at,omitempty" db:"enabled_at"`
+	LastUsedStep   int64      `json:"-" db:"last_used_step"`
+	FailedAttempts int        `json:"-" db:"failed_attempts"`
+	LastFailedAt   *time.Time `json:"-" db:"last_failed_at"`
+	CreatedAt      *time.Time `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// Enabled - checks the user signs in with a code
+func (m *MFA) Enabled() bool {
+	return m != nil && m.EnabledAt != nil
+}
+
+// Locked - checks the user typed too many invalid codes within the window
+func (m *MFA) Locked(maxAttempts int, window time.Duration, now time.Time) bool {
+	if m == nil || m.LastFailedAt == nil {
+		return false
+	}
+	return m.FailedAttempts >= maxAttempts && m.LastFailedAt.After(now.Add(-window))
+}
+
+// MFARequirementID is the identifier of a MFA requirement
+type MFARequirementID string
+
+// MFARequirement - the users of the type or with the role have to sign in with MFA
+type MFARequirement struct {
+	ID        MFARequirementID `json:"id,omitempty" db:"requirement_id"`
+	UserType  *string          `json:"user_type,omitempty" db:"user_type"`
+	RoleID    *RoleID          `json:"role_id,omitempty" db:"role_id"`
+	CreatedAt *time.Time       `json:"created_at,omitempty" db:"created_at"`
+}
+
+// Decode - MFARequirement from JSON
+func (m *MFARequirement) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(m)
+}
+
+//Verify all fields before create
+func (m *MFARequirement) Verify() error {
+
+	hasType := m.UserType != nil && len(*m.UserType) != 0
+	hasRole := m.RoleID != nil && len(*m.RoleID) != 0
+	if hasType == hasRole {
+		return errors.New("Either user_type or role_id is required")
+	}
+	if hasType && !utils.ItemExists(userTypes, *m.UserType) {
+		return errors.New("Invalid user type")
+	}
+	if !hasType {
+		m.UserType = nil
+	}
+	if !hasRole {
+		m.RoleID = nil
+	}
+
+	return nil
+}
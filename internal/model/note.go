@@ -16,14 +16,17 @@ var NilNoteID NoteID
 // Note - represents User Notes
 type Note struct {
 	// Note Note `json:"note" db:"note"`
-	ID        NoteID     `json:"id,omitempty" db:"note_id"`
-	Note      *string    `json:"note,omitempty" db:"note"`
-	TicketID  TicketID   `json:"ticket_id,omitempty" db:"ticket_id"`
-	UserID    UserID     `json:"-" db:"created_by"`
-	CreatedBy *User      `json:"created_by,omitempty"`
-	CreatedAt *time.Time `json:"created_at,omitempty"  db:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"  db:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"  db:"deleted_at"`
+	ID        NoteID      `json:"id,omitempty" db:"note_id"`
+	Note      *string     `json:"note,omitempty" db:"note"`
+	TicketID  TicketID    `json:"ticket_id,omitempty" db:"ticket_id"`
+	Public    *bool       `json:"public,omitempty" db:"public"` // public notes are mailed to the customer as replies
+	UserID    UserID      `json:"-" db:"created_by"`
+	CreatedBy *User       `json:"created_by,omitempty"`
+	From      string      `json:"from,omitempty" db:"from_address"` // the sender of the notes received by mail, they have no author
+	Files     []*NoteFile `json:"files,omitempty" db:"-"`
+	CreatedAt *time.Time  `json:"created_at,omitempty"  db:"created_at"`
+	UpdatedAt *time.Time  `json:"updated_at,omitempty"  db:"updated_at"`
+	DeletedAt *time.Time  `json:"deleted_at,omitempty"  db:"deleted_at"`
 }
 
 // Decode - UserParameters to JSON
@@ -58,5 +61,13 @@ func (n *Note) UpdateValues(nv *Note) { //nv means new values
 	if nv.Note != nil || len(*nv.Note) != 0 {
 		n.Note = nv.Note
 	}
+	if nv.Public != nil {
+		n.Public = nv.Public
+	}
+
+}
 
+// IsPublic - reports if the note is a reply to the customer
+func (n *Note) IsPublic() bool {
+	return n.Public != nil && *n.Public
 }
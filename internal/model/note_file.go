@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// NoteFileID is the identifier for a file of a ticket
+type NoteFileID string
+
+// NilNoteFileID is an empty NoteFileID
+var NilNoteFileID NoteFileID
+
+// NoteFile - a file of a ticket or of one of its notes, the content is kept in the file store
+type NoteFile struct {
+	ID         NoteFileID `json:"id,omitempty" db:"file_id"`
+	TicketID   TicketID   `json:"ticket_id,omitempty" db:"ticket_id"`
+	NoteID     NoteID     `json:"note_id,omitempty" db:"note_id"`
+	Filename   string     `json:"filename" db:"filename"`
+	MimeType   string     `json:"mime_type" db:"mime_type"`
+	Size       int64      `json:"size" db:"size"`
+	StorageKey string     `json:"-" db:"storage_key"`
+	ContentID  string     `json:"content_id,omitempty" db:"content_id"` // the inline images are referred to as cid:ContentID
+	Inline     bool       `json:"inline" db:"inline"`
+	UserID     UserID     `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  *time.Time `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// OutboundMailID is the identifier for the outbound mail server
+type OutboundMailID string
+
+// NilOutboundMailID is an empty OutboundMailID
+var NilOutboundMailID OutboundMailID
+
+// OutboundMail - the SMTP server the notifications are sent through, EmailSecret is only read from the requests
+type OutboundMail struct {
+	ID          OutboundMailID `json:"id,omitempty" db:"email_id"`
+	Name        *string        `json:"name,omitempty" db:"name"`
+	Status      *string        `json:"status,omitempty" db:"status"`
+	Address     *string        `json:"address,omitempty" db:"address"`
+	EmailUser   *string        `json:"email_user,omitempty" db:"email_user"`
+	EmailSecret *string        `json:"email_secret,omitempty" db:"email_secret"`
+	Port        *int           `json:"port,omitempty" db:"port"`
+	Secured     *bool          `json:"secured,omitempty" db:"secured"` // TLS from the start, otherwise STARTTLS is used when offered
+	FromAddress *string        `json:"from_address,omitempty" db:"from_address"`
+	FromName    *string        `json:"from_name,omitempty" db:"from_name"`
+	IsPrimary   *bool          `json:"is_primary,omitempty" db:"is_primary"`
+	LastSent    *time.Time     `json:"last_sent,omitempty" db:"last_sent"`
+	UserID      UserID         `json:"created_by,omitempty" db:"created_by"`
+
+	CreatedAt *time.Time `json:"created_at,omitempty"  db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"  db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"  db:"deleted_at"`
+}
+
+// MarshalJSON - the secret of the mail server is never sent back
+func (m OutboundMail) MarshalJSON() ([]byte, error) {
+	type outboundMail OutboundMail
+	mail := outboundMail(m)
+	mail.EmailSecret = nil
+	return json.Marshal(mail)
+}
+
+// Decode - OutboundMail to JSON
+func (m *OutboundMail) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(&m)
+}
+
+// Verify - ensures the connection values are present and sets the defaults,
+// the secret is only required when the server is logged in to
+func (m *OutboundMail) Verify() error {
+
+	if m.Name == nil || len(*m.Name) == 0 {
+		return errors.New("Name is required")
+	}
+	if m.Address == nil || len(*m.Address) == 0 {
+		return errors.New("Address is required")
+	}
+	if m.FromAddress == nil || len(*m.FromAddress) == 0 {
+		return errors.New("From address is required")
+	}
+	if m.EmailUser == nil {
+		m.EmailUser = func() *string { s := ""; return &s }()
+	}
+	if len(*m.EmailUser) != 0 && (m.EmailSecret == nil || len(*m.EmailSecret) == 0) {
+		return errors.New("Email secret is required")
+	}
+	if m.EmailSecret == nil {
+		m.EmailSecret = func() *string { s := ""; return &s }()
+	}
+	if m.Port == nil {
+		m.Port = func() *int { p := 587; return &p }()
+	}
+	if *m.Port <= 0 || *m.Port > 65535 {
+		return errors.New("Port is not valid")
+	}
+	if m.Secured == nil {
+		m.Secured = func() *bool { b := false; return &b }()
+	}
+	if m.FromName == nil {
+		m.FromName = func() *string { s := ""; return &s }()
+	}
+	if m.Status == nil {
+		m.Status = func() *string { s := ""; return &s }()
+	}
+	if m.IsPrimary == nil {
+		m.IsPrimary = func() *bool { b := false; return &b }()
+	}
+	return nil
+}
+
+// UpdateValues is used to update empty values
+func (m *OutboundMail) UpdateValues(nv *OutboundMail) { //nv means new values
+	// Avoid updating the same values
+	if m == nv {
+		return
+	}
+	if nv.Name != nil && len(*nv.Name) != 0 {
+		m.Name = nv.Name
+	}
+	if nv.Address != nil && len(*nv.Address) != 0 {
+		m.Address = nv.Address
+	}
+	if nv.EmailUser != nil {
+		m.EmailUser = nv.EmailUser
+	}
+	if nv.EmailSecret != nil && len(*nv.EmailSecret) != 0 {
+		m.EmailSecret = nv.EmailSecret
+	}
+	if nv.Port != nil {
+		m.Port = nv.Port
+	}
+	if nv.Secured != nil {
+		m.Secured = nv.Secured
+	}
+	if nv.FromAddress != nil && len(*nv.FromAddress) != 0 {
+		m.FromAddress = nv.FromAddress
+	}
+	if nv.FromName != nil {
+		m.FromName = nv.FromName
+	}
+	if nv.IsPrimary != nil {
+		m.IsPrimary = nv.IsPrimary
+	}
+}
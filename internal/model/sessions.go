@@ -1,6 +1,11 @@
 package model
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
 
 // DeviceID is an abstract type to represent a specific device
 type DeviceID string
@@ -10,10 +15,13 @@ var NilDeviceID DeviceID
 
 // Session represents structure used to store sessions in the database
 type Session struct {
-	UserID       UserID   `db:"user_id"`
-	DeviceID     DeviceID `db:"device_id"`
-	RefreshToken string   `db:"refresh_token"`
-	ExpiresAt    int64    `db:"expires_at"`
+	UserID       UserID     `json:"userID" db:"user_id"`
+	DeviceID     DeviceID   `json:"deviceID" db:"device_id"`
+	RefreshToken string     `json:"-" db:"refresh_token"`
+	ExpiresAt    int64      `json:"expiresAt" db:"expires_at"`
+	IPAddress    string     `json:"ipAddress" db:"ip_address"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	LastUsedAt   *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
 }
 
 // SessionData used to represent data sent in json body with requests
@@ -21,6 +29,11 @@ type SessionData struct {
 	DeviceID DeviceID `json:"deviceID"`
 }
 
+// Decode - SessionData from JSON
+func (s *SessionData) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(s)
+}
+
 //Verify all fields before create or update
 func (s *SessionData) Verify() error {
 	
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+)
+
+// EscalationID is the identifier for an escalation step
+type EscalationID string
+
+// NilEscalationID is an empty EscalationID
+var NilEscalationID EscalationID
+
+const (
+	// EscalateOnAtRisk - the step runs once the ticket reaches its warning time
+	EscalateOnAtRisk = "at_risk"
+	// EscalateOnBreached - the step runs once the ticket misses its deadline
+	EscalateOnBreached = "breached"
+)
+
+var escalationTriggers = []string{EscalateOnAtRisk, EscalateOnBreached}
+
+// Escalation - a step run on open tickets that are about to miss, or missed, their deadline
+type Escalation struct {
+	ID           EscalationID `json:"id,omitempty" db:"escalation_id"`
+	Name         *string      `json:"name,omitempty" db:"name"`
+	Level        *int         `json:"level,omitempty" db:"level"`
+	TriggerOn    *string      `json:"trigger_on,omitempty" db:"trigger_on"`
+	AfterMinutes *int         `json:"after_minutes,omitempty" db:"after_minutes"` // delay after the warning time or the deadline
+	SLAID        SLAID        `json:"sla_id,omitempty" db:"agreement_id"`         // empty applies to every SLA
+
+	// Actions
+	PriorityID   PriorityID `json:"priority_id,omitempty" db:"priority_id"`       // the priority the ticket is moved to
+	AssignTo     UserID     `json:"assign_to,omitempty" db:"assign_to"`           // the fallback assignee
+	AssignRoleID RoleID     `json:"assign_role_id,omitempty" db:"assign_role_id"` // the least busy agent of the role is assigned
+	Note         *string    `json:"note,omitempty" db:"note"`                     // added to the ticket as a system note
+	Notify       *bool      `json:"notify,omitempty" db:"notify"`
+
+	UserID    UserID     `json:"-" db:"created_by"`
+	CreatedAt *time.Time `json:"created_at,omitempty"  db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"  db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"  db:"deleted_at"`
+}
+
+// TicketEscalation - records that a ticket reached an escalation level
+type TicketEscalation struct {
+	TicketID     TicketID     `json:"ticket_id" db:"ticket_id"`
+	EscalationID EscalationID `json:"escalation_id" db:"escalation_id"`
+	Level        int          `json:"level" db:"level"`
+	EscalatedAt  *time.Time   `json:"escalated_at,omitempty" db:"escalated_at"`
+}
+
+// Decode - Escalation to JSON
+func (e *Escalation) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(&e)
+}
+
+// Verify -  ensures required variables are present
+func (e *Escalation) Verify() error {
+
+	if e.Name == nil || len(*e.Name) == 0 {
+		return errors.New("Name is required")
+	}
+	if e.Level == nil || *e.Level <= 0 {
+		return errors.New("Level is required and must be greater than 0")
+	}
+	if e.TriggerOn == nil || len(*e.TriggerOn) == 0 {
+		e.TriggerOn = func() *string { t := EscalateOnBreached; return &t }()
+	} else if !utils.ItemExists(escalationTriggers, *e.TriggerOn) {
+		return errors.New("Invalid trigger_on, use one of " + strings.Join(escalationTriggers, ", "))
+	}
+	if e.AfterMinutes == nil || *e.AfterMinutes < 0 {
+		e.AfterMinutes = func() *int { m := 0; return &m }()
+	}
+	if e.Notify == nil {
+		e.Notify = func() *bool { b := false; return &b }()
+	}
+	if e.Note != nil && len(*e.Note) == 0 {
+		e.Note = nil
+	}
+	if e.AssignTo != NilUserID && e.AssignRoleID != NilRoleID {
+		return errors.New("Assign to a user or a role, not both")
+	}
+	if e.PriorityID == NilPriorityID && e.AssignTo == NilUserID && e.AssignRoleID == NilRoleID && e.Note == nil && !*e.Notify {
+		return errors.New("At least one action is required")
+	}
+	return nil
+}
+
+// UpdateValues is used to update empty values
+func (e *Escalation) UpdateValues(nv *Escalation) { //nv means new values
+	// Avoid updating the same values
+	if e == nv {
+		return
+	}
+
+	if nv.Name != nil && len(*nv.Name) != 0 {
+		e.Name = nv.Name
+	}
+	if nv.Level != nil {
+		e.Level = nv.Level
+	}
+	if nv.TriggerOn != nil {
+		e.TriggerOn = nv.TriggerOn
+	}
+	if nv.AfterMinutes != nil {
+		e.AfterMinutes = nv.AfterMinutes
+	}
+	if nv.SLAID != NilSLAID {
+		e.SLAID = nv.SLAID
+	}
+	if nv.PriorityID != NilPriorityID {
+		e.PriorityID = nv.PriorityID
+	}
+	// assigning to a user replaces the role and the other way round
+	if nv.AssignTo != NilUserID {
+		e.AssignTo = nv.AssignTo
+		e.AssignRoleID = NilRoleID
+	}
+	if nv.AssignRoleID != NilRoleID {
+		e.AssignRoleID = nv.AssignRoleID
+		e.AssignTo = NilUserID
+	}
+	if nv.Note != nil {
+		e.Note = nv.Note
+	}
+	if nv.Notify != nil {
+		e.Notify = nv.Notify
+	}
+}
+
+// Notifies - checks if the step sends a notification
+func (e *Escalation) Notifies() bool {
+	return e.Notify != nil && *e.Notify
+}
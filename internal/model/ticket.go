@@ -20,28 +20,44 @@ type Ticket struct {
 	Description *string    `json:"description,omitempty" db:"description"`
 	Code        *int       `json:"number,omitempty" db:"number"`
 	UserID      UserID     `json:"-" db:"created_by"`
-	CreatedBy   *User      `json:"created_by,omitempty"`
+	CreatedBy   *User      `json:"created_by,omitempty" db:"creator"`
 	CategoryID  CategoryID `json:"category_id,omitempty" db:"category_id"`
-	Category    *Category  `json:"category,omitempty"`
+	Category    *Category  `json:"category,omitempty" db:"category"`
 	StatusID    StatusID   `json:"status_id,omitempty" db:"status_id"`
-	Status      *Status    `json:"status,omitempty"`
+	Status      *Status    `json:"status,omitempty" db:"status"`
 	PriorityID  PriorityID `json:"priority_id,omitempty" db:"priority_id"`
-	Priority    *Priority  `json:"priority,omitempty"`
+	Priority    *Priority  `json:"priority,omitempty" db:"priority"`
 	SLAID       SLAID      `json:"sla_id,omitempty" db:"sla_id"`
-	SLA         *SLA       `json:"sla,omitempty"`
+	SLA         *SLA       `json:"sla,omitempty" db:"sla"`
 	SourceID    SourceID   `json:"source_id,omitempty" db:"source_id"`
-	Source      *Source    `json:"source,omitempty"`
+	Source      *Source    `json:"source,omitempty" db:"source"`
+	ContactID   ContactID  `json:"contact_id,omitempty" db:"contact_id"` // the customer of tickets created from mails
 
 	DueDate    *time.Time `json:"deadline,omitempty"  db:"deadline"`
+
+	// SLA tracking, SLAState is computed when the ticket is loaded
+	FirstResponseDue *time.Time `json:"first_response_due,omitempty" db:"first_response_due"`
+	FirstRespondedAt *time.Time `json:"first_responded_at,omitempty" db:"first_responded_at"`
+	SLAWarningAt     *time.Time `json:"sla_warning_at,omitempty" db:"sla_warning_at"`
+	SLAState         *string    `json:"sla_state,omitempty" db:"-"`
+
+	// SLAPausedAt is set while the ticket is in a status that pauses the SLA,
+	// SLAPausedMinutes is the working time spent paused that was added back to the deadlines
+	SLAPausedAt      *time.Time `json:"sla_paused_at,omitempty" db:"sla_paused_at"`
+	SLAPausedMinutes *int       `json:"sla_paused_minutes,omitempty" db:"sla_paused_minutes"`
+
+	// SLARemainingMinutes is the working time left before the deadline, negative once it has passed
+	SLARemainingMinutes *int `json:"sla_remaining_minutes,omitempty" db:"-"`
+
 	ClosedAt   *time.Time `json:"closed_at,omitempty"  db:"closed_at"`
 	CreatedAt  *time.Time `json:"created_at,omitempty"  db:"created_at"`
 	UpdatedAt  *time.Time `json:"updated_at,omitempty"  db:"updated_at"`
 	DeletedAt  *time.Time `json:"deleted_at,omitempty"  db:"deleted_at"`
 	AssignedID UserID     `json:"assigned_id,omitempty" db:"assigned_to"`
-	AssignedTo *User      `json:"assigned_to,omitempty"`
+	AssignedTo *User      `json:"assigned_to,omitempty" db:"assignee"`
 
-	// Users Are represent the people assigned to the ticket
-	Users []*User `json:"users,omitempty"`
+	// Users represent the people involved in the ticket (assignee, collaborators and watchers)
+	Users []*TicketUser `json:"users,omitempty"`
 
 	
 	// Helpful for retrieving Tickets fromt the database
@@ -49,8 +65,11 @@ type Ticket struct {
 	/* MISC */
 	Grace *int `json:"-" db:"grace"`
 
+	// Note is sent with a status change, it is saved as a ticket note
+	Note *string `json:"note,omitempty" db:"-"`
+
 	// For closed tickets
-	ClosingRemark *ClosingRemark `json:"closing_remark,omitempty"`
+	ClosingRemark *ClosingRemark `json:"closing_remark,omitempty" db:"closing_remark"`
 }
 
 // Decode - UserParameters to JSON
@@ -113,13 +132,15 @@ func (t *Ticket) UpdateValues(nv *Ticket) { //nv means new values
 	if nv.SourceID != NilSourceID {
 		t.SourceID = nv.SourceID
 	}
-	/* if nv.SLAID != NilSLAID {
+	// the deadlines are computed again when the SLA or the priority changes
+	if nv.SLAID != NilSLAID {
 		t.SLAID = nv.SLAID
-	} */
+	}
 
-	if nv.AssignedID != NilUserID {
+	// the assignee is changed through the assign and unassign endpoints
+	/* if nv.AssignedID != NilUserID {
 		t.AssignedID = nv.AssignedID
-	}
+	} */
 
 	if nv.Description != nil {
 		if len(*nv.Description) != 0 {
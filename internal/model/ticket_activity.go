@@ -0,0 +1,107 @@
+package model
+
+import (
+	"time"
+)
+
+// ActivityID the identifier for a ticket activity
+type ActivityID string
+
+// NilActivityID an empty ActivityID
+var NilActivityID ActivityID
+
+const (
+	// ActivityCreated - the ticket was created
+	ActivityCreated = "created"
+	// ActivityUpdated - a field of the ticket was changed
+	ActivityUpdated = "updated"
+	// ActivityAssigned - the ticket was given to a user
+	ActivityAssigned = "assigned"
+	// ActivityUnassigned - the assignee was removed from the ticket
+	ActivityUnassigned = "unassigned"
+	// ActivityClosed - the ticket was closed
+	ActivityClosed = "closed"
+	// ActivityReopened - a closed ticket was opened again
+	ActivityReopened = "reopened"
+	// ActivityDeleted - the ticket was deleted
+	ActivityDeleted = "deleted"
+	// ActivityNoteAdded - a note was added to the ticket
+	ActivityNoteAdded = "note_added"
+	// ActivityNoteDeleted - a note was removed from the ticket
+	ActivityNoteDeleted = "note_deleted"
+	// ActivityEscalated - the SLA monitor ran an escalation step on the ticket
+	ActivityEscalated = "escalated"
+)
+
+// Activity - represents a single change made to a ticket
+type Activity struct {
+	ID        ActivityID `json:"id,omitempty" db:"activity_id"`
+	TicketID  TicketID   `json:"ticket_id,omitempty" db:"ticket_id"`
+	UserID    UserID     `json:"-" db:"user_id"`
+	Actor     *User      `json:"actor,omitempty" db:"actor"`
+	Action    string     `json:"action" db:"action"`
+	Field     *string    `json:"field,omitempty" db:"field"`
+	OldValue  *string    `json:"old_value,omitempty" db:"old_value"`
+	NewValue  *string    `json:"new_value,omitempty" db:"new_value"`
+	NoteID    NoteID     `json:"-" db:"note_id"`
+	Note      *Note      `json:"note,omitempty" db:"note"`
+	CreatedAt *time.Time `json:"created_at,omitempty"  db:"created_at"`
+}
+
+// NewActivity - creates an activity that is not tied to a field
+func NewActivity(ticketID TicketID, userID UserID, action string) *Activity {
+	return &Activity{
+		TicketID: ticketID,
+		UserID:   userID,
+		Action:   action,
+	}
+}
+
+// FieldActivity - creates an activity recording the change of a field
+func FieldActivity(ticketID TicketID, userID UserID, action, field, oldValue, newValue string) *Activity {
+	activity := NewActivity(ticketID, userID, action)
+	activity.Field = &field
+	if oldValue != "" {
+		activity.OldValue = &oldValue
+	}
+	if newValue != "" {
+		activity.NewValue = &newValue
+	}
+	return activity
+}
+
+// Changes - lists the fields that differ between the ticket and the updated ticket
+func (t *Ticket) Changes(nv *Ticket, userID UserID) []*Activity {
+	activities := []*Activity{}
+
+	field := func(name, oldValue, newValue string) {
+		if oldValue != newValue {
+			activities = append(activities, FieldActivity(t.ID, userID, ActivityUpdated, name, oldValue, newValue))
+		}
+	}
+
+	field("subject", stringValue(t.Subject), stringValue(nv.Subject))
+	field("description", stringValue(t.Description), stringValue(nv.Description))
+	field("category_id", string(t.CategoryID), string(nv.CategoryID))
+	field("status_id", string(t.StatusID), string(nv.StatusID))
+	field("priority_id", string(t.PriorityID), string(nv.PriorityID))
+	field("source_id", string(t.SourceID), string(nv.SourceID))
+	field("sla_id", string(t.SLAID), string(nv.SLAID))
+	field("deadline", timeValue(t.DueDate), timeValue(nv.DueDate))
+
+	return activities
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func timeValue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
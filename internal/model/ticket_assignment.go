@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// AssignmentID the identifier for a ticket assignment
+type AssignmentID string
+
+// NilAssignmentID an empty AssignmentID
+var NilAssignmentID AssignmentID
+
+// Assignment - represents a change of the person assigned to a ticket
+type Assignment struct {
+	ID         AssignmentID `json:"id,omitempty" db:"assignment_id"`
+	TicketID   TicketID     `json:"ticket_id,omitempty" db:"ticket_id"`
+	AssignedID UserID       `json:"user_id,omitempty" db:"assigned_to"` // empty when the ticket was unassigned
+	AssignedTo *User        `json:"assigned_to,omitempty" db:"assignee"`
+	UserID     UserID       `json:"-" db:"assigned_by"`
+	AssignedBy *User        `json:"assigned_by,omitempty" db:"assigner"`
+	CreatedAt  *time.Time   `json:"created_at,omitempty"  db:"created_at"`
+}
+
+// Decode - Assignment to JSON, an empty body is allowed
+func (a *Assignment) Decode(reader io.Reader) error {
+	if err := json.NewDecoder(reader).Decode(&a); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// Verify -  ensures required variables are present
+func (a *Assignment) Verify() error {
+
+	if a.TicketID == NilTicketID {
+		return errors.New("Ticket is required")
+	}
+	if a.UserID == NilUserID {
+		return errors.New("User is required")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// TicketEmailID is the identifier for a mail of a ticket
+type TicketEmailID string
+
+// NilTicketEmailID is an empty TicketEmailID
+var NilTicketEmailID TicketEmailID
+
+const (
+	// EmailInbound - the mail was received from the customer
+	EmailInbound = "inbound"
+	// EmailOutbound - the mail was sent to the customer
+	EmailOutbound = "outbound"
+)
+
+// TicketEmail - a mail received or sent for a ticket, the Message-ID keeps the replies on the ticket
+type TicketEmail struct {
+	ID             TicketEmailID `json:"id,omitempty" db:"ticket_email_id"`
+	TicketID       TicketID      `json:"ticket_id,omitempty" db:"ticket_id"`
+	NoteID         NoteID        `json:"note_id,omitempty" db:"note_id"`
+	InboundEmailID InboudMailID  `json:"-" db:"inbound_email_id"`
+	Direction      string        `json:"direction" db:"direction"`
+	MessageID      string        `json:"message_id" db:"message_id"`
+	InReplyTo      string        `json:"in_reply_to,omitempty" db:"in_reply_to"`
+	From           string        `json:"from" db:"from_address"`
+	Subject        string        `json:"subject" db:"subject"`
+	HTMLBody       string        `json:"html_body,omitempty" db:"html_body"`
+	CreatedAt      *time.Time    `json:"created_at,omitempty" db:"created_at"`
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+)
+
+const (
+	// TicketStateOpen - tickets that have not been closed
+	TicketStateOpen = "open"
+	// TicketStateClosed - tickets that have been closed
+	TicketStateClosed = "closed"
+	// TicketStateAll - open and closed tickets
+	TicketStateAll = "all"
+
+	// DefaultTicketLimit - number of tickets returned when no limit is given
+	DefaultTicketLimit = 50
+	// MaxTicketLimit - largest number of tickets returned in one page
+	MaxTicketLimit = 500
+)
+
+var (
+	ticketStates     = []string{TicketStateOpen, TicketStateClosed, TicketStateAll}
+	ticketSortFields = []string{"created_at", "updated_at", "deadline", "closed_at", "number", "subject"}
+	sortOrders       = []string{"asc", "desc"}
+)
+
+// TicketFilter - holds the values used to filter, sort and page tickets
+type TicketFilter struct {
+	StatusID   StatusID
+	PriorityID PriorityID
+	CategoryID CategoryID
+	SourceID   SourceID
+	SLAID      SLAID
+	AssignedID UserID
+	UserID     UserID // created by
+
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	DeadlineFrom *time.Time
+	DeadlineTo   *time.Time
+
+	State    string
+	SLAState string
+	Sort     string
+	Order    string
+
+	Limit  int
+	Offset int
+}
+
+// Verify -  ensures the filter values are safe and sets the defaults
+func (f *TicketFilter) Verify() error {
+
+	if f.State == "" {
+		f.State = TicketStateOpen
+	} else if !utils.ItemExists(ticketStates, f.State) {
+		return errors.New("Invalid state, use one of " + strings.Join(ticketStates, ", "))
+	}
+
+	if f.SLAState != "" && !utils.ItemExists(slaStates, f.SLAState) {
+		return errors.New("Invalid sla_state, use one of " + strings.Join(slaStates, ", "))
+	}
+
+	if f.Sort == "" {
+		f.Sort = "created_at"
+	} else if !utils.ItemExists(ticketSortFields, f.Sort) {
+		return errors.New("Invalid sort field, use one of " + strings.Join(ticketSortFields, ", "))
+	}
+
+	f.Order = strings.ToLower(f.Order)
+	if f.Order == "" {
+		f.Order = "desc"
+	} else if !utils.ItemExists(sortOrders, f.Order) {
+		return errors.New("Invalid order, use asc or desc")
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = DefaultTicketLimit
+	} else if f.Limit > MaxTicketLimit {
+		f.Limit = MaxTicketLimit
+	}
+	if f.Offset < 0 {
+		return errors.New("Offset cannot be negative")
+	}
+
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedFrom.After(*f.CreatedTo) {
+		return errors.New("created_from must be before created_to")
+	}
+	if f.DeadlineFrom != nil && f.DeadlineTo != nil && f.DeadlineFrom.After(*f.DeadlineTo) {
+		return errors.New("deadline_from must be before deadline_to")
+	}
+
+	return nil
+}
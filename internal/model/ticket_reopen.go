@@ -0,0 +1,37 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Reopening - represents the request to open a closed ticket again
+type Reopening struct {
+	TicketID TicketID `json:"ticket_id,omitempty"`
+	UserID   UserID   `json:"-"`
+	Reason   *string  `json:"reason,omitempty"`
+
+	// NoteID is the note holding the reason, set once the ticket is reopened
+	NoteID NoteID `json:"-"`
+}
+
+// Decode - Reopening to JSON
+func (ro *Reopening) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(&ro)
+}
+
+// Verify -  ensures required variables are present
+func (ro *Reopening) Verify() error {
+
+	if ro.UserID == NilUserID {
+		return errors.New("User is required")
+	}
+	if ro.TicketID == NilTicketID {
+		return errors.New("Ticket is required")
+	}
+	if ro.Reason == nil || len(*ro.Reason) == 0 {
+		return errors.New("Reason is required")
+	}
+	return nil
+}
@@ -18,6 +18,15 @@ type SLA struct {
 	ID          SLAID      `json:"id,omitempty" db:"agreement_id"`
 	Name        *string    `json:"name,omitempty" db:"name"`
 	GracePeriod *int       `json:"grace_period,omitempty" db:"grace_period"`
+
+	// FirstResponseMinutes and ResolutionMinutes are the default targets, Targets override them per priority
+	FirstResponseMinutes *int         `json:"first_response_minutes,omitempty" db:"first_response_minutes"`
+	ResolutionMinutes    *int         `json:"resolution_minutes,omitempty" db:"resolution_minutes"`
+	Targets              []*SLATarget `json:"targets,omitempty" db:"-"`
+
+	// CalendarID limits the targets to working hours, the wall clock is used when it is empty
+	CalendarID CalendarID `json:"calendar_id,omitempty" db:"calendar_id"`
+
 	Weight      *int       `json:"weight,omitempty" db:"weight"`
 	CreatedAt   *time.Time `json:"created_at,omitempty"  db:"created_at"`
 	UpdatedAt   *time.Time `json:"updated_at,omitempty"  db:"updated_at"`
@@ -44,8 +53,18 @@ func (s *SLA) Verify() error {
 	}else if (s.Weight != nil && *s.Weight <= 1){
 		s.Weight = func() *int { b := 1; return &b }()
 	}
-	if s.GracePeriod == nil || (s.GracePeriod != nil && *s.GracePeriod == 0) {
-		return errors.New("Grace Period is required")
+	if s.ResolutionMinutes != nil && *s.ResolutionMinutes > 0 {
+		// keep the grace period in hours for the older clients
+		if s.GracePeriod == nil || *s.GracePeriod == 0 {
+			s.GracePeriod = func() *int { h := (*s.ResolutionMinutes + 59) / 60; return &h }()
+		}
+	} else if s.GracePeriod != nil && *s.GracePeriod > 0 {
+		s.ResolutionMinutes = func() *int { m := *s.GracePeriod * 60; return &m }()
+	} else {
+		return errors.New("Resolution time or Grace Period is required")
+	}
+	if s.FirstResponseMinutes != nil && *s.FirstResponseMinutes <= 0 {
+		s.FirstResponseMinutes = nil
 	}
 
 	return nil
@@ -77,6 +96,22 @@ func (s *SLA) UpdateValues(nv *SLA) { //nv means new values
 	if nv.GracePeriod != nil {
 		if *nv.GracePeriod != 0 {
 			s.GracePeriod = nv.GracePeriod
+			if nv.ResolutionMinutes == nil {
+				s.ResolutionMinutes = func() *int { m := *nv.GracePeriod * 60; return &m }()
+			}
+		}
+	}
+	if nv.ResolutionMinutes != nil && *nv.ResolutionMinutes > 0 {
+		s.ResolutionMinutes = nv.ResolutionMinutes
+	}
+	if nv.CalendarID != NilCalendarID {
+		s.CalendarID = nv.CalendarID
+	}
+	if nv.FirstResponseMinutes != nil {
+		if *nv.FirstResponseMinutes > 0 {
+			s.FirstResponseMinutes = nv.FirstResponseMinutes
+		} else {
+			s.FirstResponseMinutes = nil
 		}
 	}
 
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	// SLAStateOK - the ticket is within its targets
+	SLAStateOK = "ok"
+	// SLAStateAtRisk - the ticket is in the last part of its resolution time
+	SLAStateAtRisk = "at_risk"
+	// SLAStateBreached - the first response or resolution target was missed
+	SLAStateBreached = "breached"
+	// SLAStatePaused - the open ticket is in a status that stops the SLA clock
+	SLAStatePaused = "paused"
+)
+
+var slaStates = []string{SLAStateOK, SLAStateAtRisk, SLAStateBreached, SLAStatePaused}
+
+// ComputeSLAState - sets the SLA state of the ticket at the given time
+func (t *Ticket) ComputeSLAState(now time.Time) {
+	if t.DueDate == nil {
+		t.SLAState = nil
+		return
+	}
+
+	state := SLAStateOK
+	switch {
+	case t.SLAPaused():
+		state = SLAStatePaused
+	case t.ResolutionBreached(now) || t.FirstResponseBreached(now):
+		state = SLAStateBreached
+	case t.ClosedAt == nil && t.SLAWarningAt != nil && !t.SLAWarningAt.After(now):
+		state = SLAStateAtRisk
+	}
+	t.SLAState = &state
+}
+
+// ResolutionBreached - checks if the ticket was not closed, or has not been closed, before its deadline
+func (t *Ticket) ResolutionBreached(now time.Time) bool {
+	if t.DueDate == nil {
+		return false
+	}
+	end := now
+	if t.ClosedAt != nil {
+		end = *t.ClosedAt
+	}
+	return t.DueDate.Before(end)
+}
+
+// FirstResponseBreached - checks if the first response came, or has not come, before it was due
+func (t *Ticket) FirstResponseBreached(now time.Time) bool {
+	if t.FirstResponseDue == nil {
+		return false
+	}
+	end := now
+	if t.FirstRespondedAt != nil {
+		end = *t.FirstRespondedAt
+	}
+	return t.FirstResponseDue.Before(end)
+}
+
+// SLAPaused - checks if the SLA clock of the open ticket is stopped
+func (t *Ticket) SLAPaused() bool {
+	return t.ClosedAt == nil && t.SLAPausedAt != nil
+}
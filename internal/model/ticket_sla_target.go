@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// SLATargetID is the identifier for an SLA target
+type SLATargetID string
+
+// NilSLATargetID is an empty SLATargetID
+var NilSLATargetID SLATargetID
+
+// SLATarget - the first response and resolution times of an SLA for a priority
+type SLATarget struct {
+	ID                   SLATargetID `json:"id,omitempty" db:"target_id"`
+	SLAID                SLAID       `json:"sla_id,omitempty" db:"agreement_id"`
+	PriorityID           PriorityID  `json:"priority_id,omitempty" db:"priority_id"` // empty when the SLA defaults are used
+	Priority             *Priority   `json:"priority,omitempty" db:"priority"`
+	FirstResponseMinutes *int        `json:"first_response_minutes,omitempty" db:"first_response_minutes"`
+	ResolutionMinutes    *int        `json:"resolution_minutes,omitempty" db:"resolution_minutes"`
+	CreatedAt            *time.Time  `json:"created_at,omitempty"  db:"created_at"`
+	UpdatedAt            *time.Time  `json:"updated_at,omitempty"  db:"updated_at"`
+
+	// CalendarID is the calendar of the SLA, set when the target is resolved for a ticket
+	CalendarID CalendarID `json:"-" db:"calendar_id"`
+}
+
+// Decode - SLATarget to JSON
+func (t *SLATarget) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(&t)
+}
+
+// Verify -  ensures required variables are present
+func (t *SLATarget) Verify() error {
+
+	if t.SLAID == NilSLAID {
+		return errors.New("SLA is required")
+	}
+	if t.PriorityID == NilPriorityID {
+		return errors.New("Priority is required")
+	}
+	if t.ResolutionMinutes == nil || *t.ResolutionMinutes <= 0 {
+		return errors.New("Resolution time is required")
+	}
+	if t.FirstResponseMinutes != nil && *t.FirstResponseMinutes <= 0 {
+		t.FirstResponseMinutes = nil
+	}
+	return nil
+}
+
+// FirstResponse - the time allowed for the first response, zero when the SLA has no first response target
+func (t *SLATarget) FirstResponse() time.Duration {
+	if t.FirstResponseMinutes == nil {
+		return 0
+	}
+	return time.Duration(*t.FirstResponseMinutes) * time.Minute
+}
+
+// Resolution - the time allowed to close the ticket
+func (t *SLATarget) Resolution() time.Duration {
+	if t.ResolutionMinutes == nil {
+		return 0
+	}
+	return time.Duration(*t.ResolutionMinutes) * time.Minute
+}
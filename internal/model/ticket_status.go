@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"strings"
 	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
 )
 
 // StatusID is the identifier for a ticket
@@ -13,11 +16,26 @@ type StatusID string
 // NilStatusID is an empty StatusID
 var NilStatusID StatusID
 
+const (
+	// StatusTypeInitial - the status new tickets start in, only one status can be initial
+	StatusTypeInitial = "initial"
+	// StatusTypeOpen - the ticket is being worked on
+	StatusTypeOpen = "open"
+	// StatusTypePending - the ticket is waiting on someone else
+	StatusTypePending = "pending"
+	// StatusTypeResolved - the work on the ticket is done
+	StatusTypeResolved = "resolved"
+)
+
+var statusTypes = []string{StatusTypeInitial, StatusTypeOpen, StatusTypePending, StatusTypeResolved}
+
 //Status - represents Tickets Status
 type Status struct {
 	ID        StatusID   `json:"id,omitempty" db:"status_id"`
 	Name      *string    `json:"name,omitempty" db:"name"`
 	Weight    *int       `json:"weight,omitempty" db:"weight"`
+	Type      *string    `json:"type,omitempty" db:"type"`
+	PausesSLA *bool      `json:"pauses_sla,omitempty" db:"pauses_sla"` // the SLA clock stops while tickets are in the status
 	CreatedAt *time.Time `json:"created_at,omitempty"  db:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"  db:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty"  db:"deleted_at"`
@@ -44,6 +62,15 @@ func (s *Status) Verify() error {
 		s.Weight = func() *int { b := 1; return &b }()
 	}
 
+	if s.Type == nil || len(*s.Type) == 0 {
+		s.Type = func() *string { t := StatusTypeOpen; return &t }()
+	} else if !utils.ItemExists(statusTypes, *s.Type) {
+		return errors.New("Invalid type, use one of " + strings.Join(statusTypes, ", "))
+	}
+	if s.PausesSLA == nil {
+		s.PausesSLA = func() *bool { b := false; return &b }()
+	}
+
 	return nil
 }
 
@@ -70,5 +97,16 @@ func (s *Status) UpdateValues(nv *Status) { //nv means new values
 			s.Weight = nv.Weight
 		}
 	}
+	if nv.Type != nil && utils.ItemExists(statusTypes, *nv.Type) {
+		s.Type = nv.Type
+	}
+	if nv.PausesSLA != nil {
+		s.PausesSLA = nv.PausesSLA
+	}
+
+}
 
+// Pauses - checks if the SLA clock stops while tickets are in the status
+func (s *Status) Pauses() bool {
+	return s.PausesSLA != nil && *s.PausesSLA
 }
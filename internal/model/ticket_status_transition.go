@@ -0,0 +1,98 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// TransitionID is the identifier for a status transition
+type TransitionID string
+
+// NilTransitionID is an empty TransitionID
+var NilTransitionID TransitionID
+
+// Transition - represents an allowed move between two ticket statuses and its rules
+type Transition struct {
+	ID           TransitionID `json:"id,omitempty" db:"transition_id"`
+	FromStatusID StatusID     `json:"from_status_id,omitempty" db:"from_status_id"`
+	FromStatus   *Status      `json:"from_status,omitempty" db:"from_status"`
+	ToStatusID   StatusID     `json:"to_status_id,omitempty" db:"to_status_id"`
+	ToStatus     *Status      `json:"to_status,omitempty" db:"to_status"`
+	RequiresNote *bool        `json:"requires_note,omitempty" db:"requires_note"`
+	RoleID       RoleID       `json:"role_id,omitempty" db:"role_id"` // empty when any role can make the move
+	ClosesTicket *bool        `json:"closes_ticket,omitempty" db:"closes_ticket"`
+	UserID       UserID       `json:"-" db:"created_by"`
+	CreatedAt    *time.Time   `json:"created_at,omitempty"  db:"created_at"`
+	UpdatedAt    *time.Time   `json:"updated_at,omitempty"  db:"updated_at"`
+	DeletedAt    *time.Time   `json:"deleted_at,omitempty"  db:"deleted_at"`
+}
+
+// Decode - Transition to JSON
+func (t *Transition) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(&t)
+}
+
+// Verify -  ensures required variables are present and sets the defaults
+func (t *Transition) Verify() error {
+
+	if t.FromStatusID == NilStatusID {
+		return errors.New("From status is required")
+	}
+	if t.ToStatusID == NilStatusID {
+		return errors.New("To status is required")
+	}
+	if t.FromStatusID == t.ToStatusID {
+		return errors.New("A transition must move to a different status")
+	}
+	if t.RequiresNote == nil {
+		t.RequiresNote = func() *bool { b := false; return &b }()
+	}
+	if t.ClosesTicket == nil {
+		t.ClosesTicket = func() *bool { b := false; return &b }()
+	}
+	return nil
+}
+
+// UpdateValues is used to update empty values, the statuses of a transition cannot be changed
+func (t *Transition) UpdateValues(nv *Transition) { //nv means new values
+	// Avoid updating the same values
+	if t == nv {
+		return
+	}
+
+	if nv.RequiresNote != nil {
+		t.RequiresNote = nv.RequiresNote
+	}
+	if nv.ClosesTicket != nil {
+		t.ClosesTicket = nv.ClosesTicket
+	}
+	if nv.RoleID != NilRoleID {
+		t.RoleID = nv.RoleID
+	}
+}
+
+// AllowedFor - checks if the roles of a principal, separated by |, include the role the transition requires
+func (t *Transition) AllowedFor(roles string) bool {
+	if t.RoleID == NilRoleID {
+		return true
+	}
+	for _, role := range strings.Split(roles, "|") {
+		if RoleID(role) == t.RoleID {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsNote - checks if the transition requires a note
+func (t *Transition) NeedsNote() bool {
+	return t.RequiresNote != nil && *t.RequiresNote
+}
+
+// Closes - checks if the transition closes the ticket
+func (t *Transition) Closes() bool {
+	return t.ClosesTicket != nil && *t.ClosesTicket
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/utils"
+)
+
+const (
+	// TicketUserAssignee - the person working on the ticket, set through the assign endpoint
+	TicketUserAssignee = "assignee"
+	// TicketUserCollaborator - a person helping the assignee
+	TicketUserCollaborator = "collaborator"
+	// TicketUserWatcher - a person following the ticket
+	TicketUserWatcher = "watcher"
+)
+
+var (
+	ticketUserRoles = []string{TicketUserAssignee, TicketUserCollaborator, TicketUserWatcher}
+
+	// roles that can be granted through the ticket users endpoints
+	grantableTicketUserRoles = []string{TicketUserCollaborator, TicketUserWatcher}
+)
+
+// TicketUser - represents a person involved in a ticket
+type TicketUser struct {
+	TicketID  TicketID   `json:"ticket_id,omitempty" db:"ticket_id"`
+	Ticket    *Ticket    `json:"ticket,omitempty" db:"ticket"`
+	UserID    UserID     `json:"user_id,omitempty" db:"user_id"`
+	User      *User      `json:"user,omitempty" db:"member"`
+	Role      *string    `json:"role,omitempty" db:"role"`
+	AddedByID UserID     `json:"-" db:"added_by"`
+	CreatedAt *time.Time `json:"created_at,omitempty"  db:"created_at"`
+}
+
+// Decode - TicketUser to JSON
+func (tu *TicketUser) Decode(reader io.Reader) error {
+	return json.NewDecoder(reader).Decode(&tu)
+}
+
+// Verify -  ensures required variables are present
+func (tu *TicketUser) Verify() error {
+
+	if tu.TicketID == NilTicketID {
+		return errors.New("Ticket is required")
+	}
+	if tu.UserID == NilUserID {
+		return errors.New("User is required")
+	}
+	if tu.Role == nil || len(*tu.Role) == 0 {
+		role := TicketUserWatcher
+		tu.Role = &role
+	} else if !utils.ItemExists(ticketUserRoles, *tu.Role) {
+		return errors.New("Invalid role, use collaborator or watcher")
+	}
+	if !utils.ItemExists(grantableTicketUserRoles, *tu.Role) {
+		return errors.New("Use the assign endpoint to change the assignee")
+	}
+	return nil
+}
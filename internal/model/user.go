@@ -16,23 +16,27 @@ var NilUserID UserID
 
 var (
 	userTypes = []string{"admin", "agent", "user"}
+
+	// user types that tickets can be assigned to
+	assigneeTypes = []string{"admin", "agent"}
 )
 
 // User is a structure that represents User Object
 type User struct {
-	ID           UserID     `json:"id,omitempty" db:"user_id"`
-	Name         *string    `json:"name,omitempty"`
-	Firstname    *string    `json:"firstname,omitempty" db:"firstname"`
-	Lastname     *string    `json:"lastname,omitempty" db:"lastname"`
-	Email        *string    `json:"email,omitempty" db:"email"`
-	Type         *string    `json:"type,omitempty" db:"user_type"`
-	RoleID       RoleID     `json:"role_id,omitempty" db:"role_id"`
-	PasswordHash *[]byte    `json:"-" db:"password_hash"`
-	IsActive     *bool      `json:"-" db:"is_active"`
-	IsSystem     *bool      `json:"-" db:"is_system"`
-	CreatedAt    *time.Time `json:"created_at,omitempty"  db:"created_at"`
-	UpdatedAt    *time.Time `json:"updated_at,omitempty"  db:"updated_at"`
-	DeletedAt    *time.Time `json:"deleted_at,omitempty"  db:"deleted_at"`
+	ID              UserID     `json:"id,omitempty" db:"user_id"`
+	Name            *string    `json:"name,omitempty"`
+	Firstname       *string    `json:"firstname,omitempty" db:"firstname"`
+	Lastname        *string    `json:"lastname,omitempty" db:"lastname"`
+	Email           *string    `json:"email,omitempty" db:"email"`
+	Type            *string    `json:"type,omitempty" db:"user_type"`
+	RoleID          RoleID     `json:"role_id,omitempty" db:"role_id"`
+	PasswordHash    *[]byte    `json:"-" db:"password_hash"`
+	IsActive        *bool      `json:"-" db:"is_active"`
+	IsSystem        *bool      `json:"-" db:"is_system"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	CreatedAt       *time.Time `json:"created_at,omitempty"  db:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"  db:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"  db:"deleted_at"`
 
 	// MISC
 	Role            *Role     `json:"role,omitempty"` //Primary role
@@ -93,13 +97,30 @@ func HashPassword(password string) ([]byte, error) {
 	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 }
 
+// IsAgentType - checks if the user type works on tickets
+func IsAgentType(userType string) bool {
+	return utils.ItemExists(assigneeTypes, userType)
+}
+
+// CanBeAssigned - checks that tickets can be assigned to the user
+func (u *User) CanBeAssigned() bool {
+
+	if u.DeletedAt != nil || (u.IsActive != nil && !*u.IsActive) {
+		return false
+	}
+	if u.Type == nil {
+		return false
+	}
+	return utils.ItemExists(assigneeTypes, *u.Type)
+}
+
 // UpdateValues is used to update empty values
 func (u *User) UpdateValues(nv *User) { //nv means new values
 	// Avoid updating the same values
 	if u == nv {
 		return
 	}
-	if nv.PasswordHash != nil || len(*nv.PasswordHash) != 0 {
+	if nv.PasswordHash != nil && len(*nv.PasswordHash) != 0 {
 		u.PasswordHash = nv.PasswordHash
 	}
 
@@ -0,0 +1,30 @@
+// Package blob keeps the content of the files outside of the database
+package blob
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"path"
+	"time"
+)
+
+// ErrNotExist - no content is kept under the key
+var ErrNotExist = errors.New("blob does not exist")
+
+// Store - where the contents are kept, the keys are paths separated by slashes and size is the
+// length of the content
+type Store interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewKey - a unique key under the prefix, the month keeps the directories small
+func NewKey(prefix string) string {
+	random := make([]byte, 16)
+	rand.Read(random)
+	return path.Join(prefix, time.Now().UTC().Format("2006/01"), hex.EncodeToString(random))
+}
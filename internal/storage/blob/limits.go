@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"errors"
+	"mime"
+	"strings"
+)
+
+var (
+	// ErrTooLarge - the file is over the size limit
+	ErrTooLarge = errors.New("file is too large")
+	// ErrType - the type of the file is not allowed
+	ErrType = errors.New("file type is not allowed")
+)
+
+// Limits - the files that are kept, every type is allowed when Types is empty
+type Limits struct {
+	MaxSize int64    // bytes, 0 has no limit
+	Types   []string // media types, type/* allows all the subtypes
+}
+
+// Check - whether a file of the type and size can be kept
+func (l Limits) Check(mimeType string, size int64) error {
+
+	if l.MaxSize > 0 && size > l.MaxSize {
+		return ErrTooLarge
+	}
+	if len(l.Types) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return ErrType
+	}
+	for _, allowed := range l.Types {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == mediaType || allowed == "*/*" ||
+			(strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return nil
+		}
+	}
+	return ErrType
+}
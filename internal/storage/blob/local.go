@@ -0,0 +1,86 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local - keeps the contents as files under a directory
+type Local struct {
+	dir string
+}
+
+// NewLocal - creates the store, the directory is created when it does not exist
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+	return &Local{dir: dir}, nil
+}
+
+// path - the file of a key, the keys cannot leave the directory
+func (l *Local) path(key string) (string, error) {
+	name := filepath.Clean(filepath.FromSlash("/" + key))
+	if name == string(filepath.Separator) || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(l.dir, name), nil
+}
+
+// Put - writes the content to a temporary file first so a failed write leaves no partial file
+func (l *Local) Put(ctx context.Context, key string, content io.Reader, size int64) error {
+
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0750); err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(name), ".upload-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
+
+// Get - opens the content of a key
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return file, err
+}
+
+// Delete - removes the content of a key, a missing key is not an error
+func (l *Local) Delete(ctx context.Context, key string) error {
+
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
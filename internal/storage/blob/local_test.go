@@ -0,0 +1,148 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewLocal(t *testing.T) {
+
+	dir := filepath.Join(t.TempDir(), "files", "tickets")
+	if _, err := NewLocal(dir); err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("the directory %s was not created: %v", dir, err)
+	}
+	// the directory may exist already
+	if _, err := NewLocal(dir); err != nil {
+		t.Errorf("NewLocal() on an existing directory error = %v", err)
+	}
+}
+
+func TestLocal(t *testing.T) {
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := NewKey("tickets")
+	if err := store.Put(ctx, key, strings.NewReader("first version"), 13); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key))); err != nil {
+		t.Errorf("the file of %s is not under the directory: %v", key, err)
+	}
+	checkContent(t, store, key, "first version")
+
+	if err := store.Put(ctx, key, strings.NewReader("second version"), 14); err != nil {
+		t.Fatalf("Put() over the content error = %v", err)
+	}
+	checkContent(t, store, key, "second version")
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, key); err != ErrNotExist {
+		t.Errorf("Get() once deleted error = %v, want ErrNotExist", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing key error = %v", err)
+	}
+}
+
+func TestLocalInvalidKeys(t *testing.T) {
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocal(filepath.Join(dir, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", "/", "../outside", "tickets/../../outside", "tickets/.."} {
+		if err := store.Put(ctx, key, strings.NewReader("content"), 7); err == nil {
+			t.Errorf("Put(%q) error = nil, want an invalid key", key)
+		}
+		if _, err := store.Get(ctx, key); err == nil || err == ErrNotExist {
+			t.Errorf("Get(%q) error = %v, want an invalid key", key, err)
+		}
+		if err := store.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) error = nil, want an invalid key", key)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside")); !os.IsNotExist(err) {
+		t.Errorf("a file was written outside of the store: %v", err)
+	}
+}
+
+// failingReader - returns part of the content then fails, like an interrupted upload
+type failingReader struct {
+	read bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, errors.New("connection reset")
+	}
+	r.read = true
+	return copy(p, "partial"), nil
+}
+
+func TestLocalFailedPut(t *testing.T) {
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "tickets/2021/03/report.pdf"
+	if err := store.Put(ctx, key, strings.NewReader("complete"), 8); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, key, &failingReader{}, 100); err == nil {
+		t.Fatal("Put() error = nil, want the error of the reader")
+	}
+
+	// the content written before is kept and no temporary file is left behind
+	checkContent(t, store, key, "complete")
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "tickets", "2021", "03"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		names := []string{}
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("files = %v, want only report.pdf", names)
+	}
+}
+
+func checkContent(t *testing.T, store Store, key, want string) {
+	t.Helper()
+
+	content, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%s) error = %v", key, err)
+	}
+	defer content.Close()
+	got, err := ioutil.ReadAll(io.LimitReader(content, 1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("Get(%s) = %q, want %q", key, got, want)
+	}
+}
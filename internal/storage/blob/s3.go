@@ -0,0 +1,231 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload - the content is not part of the signature when it cannot be read twice
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config - the bucket of an S3 compatible server
+type S3Config struct {
+	Endpoint  string // like https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // the bucket is in the path instead of the host name, MinIO needs it
+}
+
+// S3 - keeps the contents as the objects of a bucket, the requests are signed with AWS Signature V4
+type S3 struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3 - creates the store, the bucket must exist
+func NewS3(config S3Config) (*S3, error) {
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", config.Endpoint)
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("no S3 bucket")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return &S3{config: config, endpoint: endpoint, client: &http.Client{Timeout: 5 * time.Minute}}, nil
+}
+
+// Put - uploads the content, a content that can be read twice is signed
+func (s *S3) Put(ctx context.Context, key string, content io.Reader, size int64) error {
+
+	payloadHash := unsignedPayload
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		hash := sha256.New()
+		if _, err := io.Copy(hash, seeker); err != nil {
+			return err
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		payloadHash = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, ioutil.NopCloser(content), size, payloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+// Get - downloads the content of a key
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, emptyHash)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotExist
+	}
+	defer resp.Body.Close()
+	return nil, s3Error(resp)
+}
+
+// Delete - removes the object of a key, a missing key is not an error
+func (s *S3) Delete(ctx context.Context, key string) error {
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, emptyHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+// do - sends a signed request for the object of a key
+func (s *S3) do(ctx context.Context, method, key string, body io.ReadCloser, size int64, payloadHash string) (*http.Response, error) {
+
+	object := *s.endpoint
+	path := "/" + uriEncode(strings.TrimPrefix(key, "/"), false)
+	if s.config.PathStyle {
+		path = "/" + uriEncode(s.config.Bucket, true) + path
+	} else {
+		object.Host = s.config.Bucket + "." + object.Host
+	}
+	object.Path, _ = url.PathUnescape(path)
+	object.RawPath = path
+
+	req, err := http.NewRequest(method, object.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.ContentLength = size
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signV4(req, path, "s3", s.config.Region, s.config.AccessKey, s.config.SecretKey, payloadHash, time.Now())
+	return s.client.Do(req)
+}
+
+// s3Error - the error returned by the server
+func s3Error(resp *http.Response) error {
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+}
+
+// emptyHash - the SHA256 of an empty payload
+var emptyHash = hex.EncodeToString(func() []byte { sum := sha256.Sum256(nil); return sum[:] }())
+
+// signV4 - adds the AWS Signature V4 of the request, the host and the x-amz-* headers are signed
+func signV4(req *http.Request, canonicalURI, service, region, accessKey, secretKey, payloadHash string, now time.Time) {
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery - the sorted and encoded query parameters
+func canonicalQuery(query url.Values) string {
+	pairs := []string{}
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode - the encoding of AWS, every byte but the unreserved characters is escaped,
+// the slashes are kept in the paths
+func uriEncode(s string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
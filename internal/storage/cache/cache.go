@@ -1,13 +1,17 @@
 package cache
 
 import (
+	"context"
 	"io"
+
 	"github.com/go-redis/redis/v8"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
 )
 
 // Cache is a closer interface
 type Cache interface {
 	io.Closer
+	TokenDB
 }
 
 type cache struct {
@@ -17,3 +21,28 @@ type cache struct {
 func (c *cache) Close() error {
 	return c.conn.Close()
 }
+
+// TokenDB - keeps the token versions of the users, shared by all the servers
+type TokenDB interface {
+	TokenVersion(ctx context.Context, userID model.UserID) (int64, error)
+	RevokeTokens(ctx context.Context, userID model.UserID) error
+}
+
+// tokenVersionKey - the key of the token version of a user
+func tokenVersionKey(userID model.UserID) string {
+	return "token_version:" + string(userID)
+}
+
+// TokenVersion - the version the new tokens of the user are issued with, 0 until they are first revoked
+func (c *cache) TokenVersion(ctx context.Context, userID model.UserID) (int64, error) {
+	version, err := c.conn.Get(ctx, tokenVersionKey(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+// RevokeTokens - moves the user to the next version, the tokens issued until now are revoked
+func (c *cache) RevokeTokens(ctx context.Context, userID model.UserID) error {
+	return c.conn.Incr(ctx, tokenVersionKey(userID)).Err()
+}
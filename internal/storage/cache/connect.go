@@ -4,35 +4,30 @@ import (
 	"context"
 	"time"
 
-	"github.com/namsral/flag"
-
 	"github.com/go-redis/redis/v8"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/config"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 var (
-	// fs           = flag.NewFlagSet("apigateway", flag.ExitOnError)
-	redisAddr    = flag.String("redis-addr", "localhost:6379", "")
-	redisSecret  = flag.String("redis-secret", "localhost:6379", "")
-	redisDB      = flag.Int64("redis-db", 0, "")
-	redisTimeout = flag.Int64("redis-timeout-ms", 2000, "")
+	redisAddr    string
+	redisSecret  string
+	redisDB      int
+	redisTimeout int64
 )
 
-func init() {
-
-}
-
 // Connect makes a new redis Connection.
 func connect() (*redis.Client, error) {
 	conn := redis.NewClient(&redis.Options{
-		Addr:     *redisAddr,
-		Password: *redisSecret,
-		DB:       int(*redisDB),
+		Addr:     redisAddr,
+		Password: redisSecret,
+		DB:       redisDB,
 	})
 
 	// check if the redis is running
 	if err := waitForDB(conn); err != nil {
+		conn.Close()
 		return nil, err
 	}
 
@@ -40,7 +35,12 @@ func connect() (*redis.Client, error) {
 }
 
 // New creates a new databse
-func New() (Cache, error) {
+func New(cfg *config.Info) (Cache, error) {
+
+	redisAddr = cfg.Cache.Addr
+	redisSecret = cfg.Cache.Password
+	redisDB = cfg.Cache.DB
+	redisTimeout = cfg.Cache.Timeout
 
 	conn, err := connect()
 	if err != nil {
@@ -53,10 +53,12 @@ func New() (Cache, error) {
 
 func waitForDB(conn *redis.Client) error {
 
-	var ctx = context.Background()
+	// the pings stop with the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	ready := make(chan struct{})
 	go func() {
-		for {
+		for ctx.Err() == nil {
 			if _, err := conn.Ping(ctx).Result(); err == nil {
 				logrus.Debug("Cache Connected")
 				close(ready)
@@ -69,7 +71,7 @@ func waitForDB(conn *redis.Client) error {
 	select {
 	case <-ready:
 		return nil
-	case <-time.After(time.Duration(*redisTimeout) * time.Millisecond):
+	case <-time.After(time.Duration(redisTimeout) * time.Millisecond):
 		return errors.New("redis not ready")
 	}
 }
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+)
+
+// AccountTokenDB - holds the tokens mailed to reset the passwords and verify the emails
+type AccountTokenDB interface {
+	CreateAccountToken(ctx context.Context, token *model.AccountToken) error
+	ResetPassword(ctx context.Context, token string, passwordHash []byte) (model.UserID, error)
+	VerifyEmail(ctx context.Context, token string) (model.UserID, error)
+}
+
+// the tokens mailed before stop working when a new one is sent
+const expireAccountTokensQuery = `
+	UPDATE account_tokens
+	SET used_at = NOW()
+	WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+`
+
+const createAccountTokenQuery = `
+	INSERT INTO account_tokens
+	(token_hash, user_id, purpose, expires_at)
+	VALUES($1, $2, $3, $4)
+	RETURNING created_at
+`
+
+// CreateAccountToken - keeps the hash of the token, the earlier tokens of the user for the same purpose
+// can no longer be used
+func (d *database) CreateAccountToken(ctx context.Context, token *model.AccountToken) (err error) {
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, expireAccountTokensQuery, token.UserID, token.Purpose); err != nil {
+		return errors.Wrap(err, "could not expire the previous tokens")
+	}
+	if err = tx.GetContext(ctx, &token.CreatedAt, createAccountTokenQuery, tokenHash(token.Token), token.UserID, token.Purpose, token.ExpiresAt); err != nil {
+		return errors.Wrap(err, "could not create the token")
+	}
+
+	return tx.Commit()
+}
+
+// only one request can use a token, the others find it used
+const useAccountTokenQuery = `
+	UPDATE account_tokens t
+	SET used_at = NOW()
+	FROM users u
+	WHERE t.token_hash = $1
+		AND t.purpose = $2
+		AND t.used_at IS NULL
+		AND t.expires_at > NOW()
+		AND u.user_id = t.user_id
+		AND u.deleted_at IS NULL
+	RETURNING t.user_id
+`
+
+// the reset link proves the user owns the email as well
+const resetPasswordQuery = `
+	UPDATE users
+	SET password_hash = $2,
+		email_verified_at = COALESCE(email_verified_at, NOW()),
+		updated_at = NOW()
+	WHERE user_id = $1
+`
+
+// ResetPassword - uses the token to set the new password of its user
+func (d *database) ResetPassword(ctx context.Context, token string, passwordHash []byte) (userID model.UserID, err error) {
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return model.NilUserID, errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if userID, err = useAccountToken(ctx, tx, token, model.PurposePasswordReset); err != nil {
+		return
+	}
+	if _, err = tx.ExecContext(ctx, resetPasswordQuery, userID, passwordHash); err != nil {
+		return model.NilUserID, errors.Wrap(err, "could not set the password")
+	}
+
+	return userID, tx.Commit()
+}
+
+const verifyEmailQuery = `
+	UPDATE users
+	SET email_verified_at = COALESCE(email_verified_at, NOW()),
+		updated_at = NOW()
+	WHERE user_id = $1
+`
+
+// VerifyEmail - uses the token to mark the email of its user as verified
+func (d *database) VerifyEmail(ctx context.Context, token string) (userID model.UserID, err error) {
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return model.NilUserID, errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if userID, err = useAccountToken(ctx, tx, token, model.PurposeEmailVerification); err != nil {
+		return
+	}
+	if _, err = tx.ExecContext(ctx, verifyEmailQuery, userID); err != nil {
+		return model.NilUserID, errors.Wrap(err, "could not verify the email")
+	}
+
+	return userID, tx.Commit()
+}
+
+// useAccountToken - marks the token as used, the unknown, used and expired tokens are all invalid
+func useAccountToken(ctx context.Context, tx *sqlx.Tx, token string, purpose model.AccountTokenPurpose) (model.UserID, error) {
+
+	var userID model.UserID
+	if err := tx.GetContext(ctx, &userID, useAccountTokenQuery, tokenHash(token), purpose); err != nil {
+		if err == sql.ErrNoRows {
+			return model.NilUserID, apiErr.ErrInvalidAccountToken
+		}
+		return model.NilUserID, errors.Wrap(err, "could not use the token")
+	}
+	return userID, nil
+}
@@ -0,0 +1,265 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// CalendarDB - holds the methods for the business calendars used by the SLAs
+type CalendarDB interface {
+	CreateCalendar(ctx context.Context, calendar *model.Calendar) error
+	GetCalendarByID(ctx context.Context, calendarID *model.CalendarID) (*model.Calendar, error)
+	UpdateCalendar(ctx context.Context, calendar *model.Calendar) error
+	ListAllCalendars(ctx context.Context) ([]*model.Calendar, error)
+	DeleteCalendar(ctx context.Context, calendarID *model.CalendarID) (bool, error)
+}
+
+const createCalendarQuery = `
+	INSERT INTO business_calendars (
+		name, time_zone
+	)
+	VALUES (
+		:name, :time_zone
+	)
+	RETURNING calendar_id`
+
+// CreateCalendar - saves the calendar with its working hours and holidays
+func (d *database) CreateCalendar(ctx context.Context, calendar *model.Calendar) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.PrepareNamedContext(ctx, createCalendarQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare calendar")
+	}
+	defer stmt.Close()
+
+	if err = stmt.QueryRowxContext(ctx, calendar).Scan(&calendar.ID); err != nil {
+		err = calendarError(err)
+		return
+	}
+
+	if err = saveCalendarDays(ctx, tx, calendar); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+const getCalendarByIDQuery = `
+	SELECT calendar_id, name, time_zone, created_at, updated_at, deleted_at
+	FROM business_calendars
+	WHERE calendar_id = $1
+	AND deleted_at IS NULL`
+
+const listCalendarHoursQuery = `
+	SELECT calendar_id, weekday, to_char(start_time, 'HH24:MI') AS start_time, to_char(end_time, 'HH24:MI') AS end_time
+	FROM business_calendar_hours
+	WHERE calendar_id = $1
+	ORDER BY weekday ASC`
+
+const listCalendarHolidaysQuery = `
+	SELECT holiday_id, calendar_id, to_char(date, 'YYYY-MM-DD') AS date, name
+	FROM business_calendar_holidays
+	WHERE calendar_id = $1
+	ORDER BY date ASC`
+
+// GetCalendarByID - returns the calendar with its working hours and holidays
+func (d *database) GetCalendarByID(ctx context.Context, calendarID *model.CalendarID) (*model.Calendar, error) {
+	return getCalendar(ctx, d.conn, calendarID)
+}
+
+// txCalendars - reads the calendars within the transaction of a change
+type txCalendars struct {
+	tx *sqlx.Tx
+}
+
+func (c txCalendars) GetCalendarByID(ctx context.Context, calendarID *model.CalendarID) (*model.Calendar, error) {
+	return getCalendar(ctx, c.tx, calendarID)
+}
+
+func getCalendar(ctx context.Context, q sqlx.QueryerContext, calendarID *model.CalendarID) (*model.Calendar, error) {
+
+	calendar := model.Calendar{}
+	if err := sqlx.GetContext(ctx, q, &calendar, getCalendarByIDQuery, calendarID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apiErr.ErrNotExist("Calendar")
+		}
+		return nil, errors.Wrap(err, "could not get calendar")
+	}
+
+	calendar.Hours = []*model.WorkingHours{}
+	if err := sqlx.SelectContext(ctx, q, &calendar.Hours, listCalendarHoursQuery, calendarID); err != nil {
+		return nil, errors.Wrap(err, "could not get calendar hours")
+	}
+	calendar.Holidays = []*model.Holiday{}
+	if err := sqlx.SelectContext(ctx, q, &calendar.Holidays, listCalendarHolidaysQuery, calendarID); err != nil {
+		return nil, errors.Wrap(err, "could not get calendar holidays")
+	}
+	return &calendar, nil
+}
+
+const updateCalendarQuery = `
+	UPDATE business_calendars
+	SET name = :name,
+		time_zone = :time_zone,
+		updated_at = NOW()
+	WHERE calendar_id = :calendar_id
+	AND deleted_at IS NULL`
+
+// UpdateCalendar - updates the calendar and replaces its working hours and holidays
+func (d *database) UpdateCalendar(ctx context.Context, calendar *model.Calendar) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result, err := tx.NamedExecContext(ctx, updateCalendarQuery, calendar)
+	if err != nil {
+		err = calendarError(err)
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		err = apiErr.ErrNotExist("Calendar")
+		return
+	}
+
+	if err = saveCalendarDays(ctx, tx, calendar); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+const listAllCalendarsQuery = `
+	SELECT calendar_id, name, time_zone, created_at, updated_at, deleted_at
+	FROM business_calendars
+	WHERE deleted_at IS NULL
+	ORDER BY name ASC`
+
+func (d *database) ListAllCalendars(ctx context.Context) ([]*model.Calendar, error) {
+
+	calendars := []*model.Calendar{}
+	if err := d.conn.SelectContext(ctx, &calendars, listAllCalendarsQuery); err != nil {
+		return nil, errors.Wrap(err, "could not get calendars")
+	}
+	return calendars, nil
+}
+
+const deleteCalendarQuery = `
+	UPDATE business_calendars
+	SET deleted_at = NOW()
+	WHERE calendar_id = $1
+	AND deleted_at IS NULL`
+
+// the SLAs of a deleted calendar go back to the wall clock
+const detachCalendarQuery = `
+	UPDATE ticket_slas
+	SET calendar_id = NULL,
+		updated_at = NOW()
+	WHERE calendar_id = $1`
+
+func (d *database) DeleteCalendar(ctx context.Context, calendarID *model.CalendarID) (deleted bool, err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, deleteCalendarQuery, calendarID)
+	if err != nil {
+		return false, err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return false, tx.Rollback()
+	}
+
+	if _, err = tx.ExecContext(ctx, detachCalendarQuery, calendarID); err != nil {
+		return false, errors.Wrap(err, "could not detach calendar")
+	}
+	return true, tx.Commit()
+}
+
+const removeCalendarHoursQuery = `DELETE FROM business_calendar_hours WHERE calendar_id = $1`
+
+const addCalendarHoursQuery = `
+	INSERT INTO business_calendar_hours (
+		calendar_id, weekday, start_time, end_time
+	)
+	VALUES (
+		$1, $2, $3::time, $4::time
+	)`
+
+const removeCalendarHolidaysQuery = `DELETE FROM business_calendar_holidays WHERE calendar_id = $1`
+
+const addCalendarHolidayQuery = `
+	INSERT INTO business_calendar_holidays (
+		calendar_id, date, name
+	)
+	VALUES (
+		$1, $2::date, COALESCE($3, '')
+	)
+	RETURNING holiday_id`
+
+// saveCalendarDays - replaces the working hours and holidays of the calendar
+func saveCalendarDays(ctx context.Context, tx *sqlx.Tx, calendar *model.Calendar) error {
+
+	if _, err := tx.ExecContext(ctx, removeCalendarHoursQuery, calendar.ID); err != nil {
+		return errors.Wrap(err, "could not remove calendar hours")
+	}
+	for _, hours := range calendar.Hours {
+		hours.CalendarID = calendar.ID
+		if _, err := tx.ExecContext(ctx, addCalendarHoursQuery, calendar.ID, int(hours.Weekday), hours.Start, hours.End); err != nil {
+			return errors.Wrap(err, "could not save calendar hours")
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, removeCalendarHolidaysQuery, calendar.ID); err != nil {
+		return errors.Wrap(err, "could not remove calendar holidays")
+	}
+	for _, holiday := range calendar.Holidays {
+		holiday.CalendarID = calendar.ID
+		if err := tx.QueryRowxContext(ctx, addCalendarHolidayQuery, calendar.ID, holiday.Date, holiday.Name).Scan(&holiday.ID); err != nil {
+			return errors.Wrap(err, "could not save calendar holiday")
+		}
+	}
+	return nil
+}
+
+// calendarError - maps the constraint errors of the calendars
+func calendarError(err error) error {
+
+	if pqError, ok := err.(*pq.Error); ok {
+		if pqError.Code.Name() == UniqueViolation && pqError.Constraint == "business_calendars_name" {
+			return apiErr.ErrCalendarExists
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"PQ Code.Name":   pqError.Code.Name(),
+			"PQ Constraints": pqError.Constraint,
+			"PQ Column":      pqError.Column,
+		}).Info()
+	}
+	return errors.Wrap(err, "could not save calendar")
+}
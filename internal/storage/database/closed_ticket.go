@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ClosedTicketDB - holds the methods for the closed ticket archive
+type ClosedTicketDB interface {
+	GetClosedTicket(ctx context.Context, ticketID *model.TicketID) (*model.Ticket, error)
+	ListClosedTickets(ctx context.Context, filter *model.ClosedTicketFilter) ([]*model.Ticket, int, error)
+}
+
+// closedTicketJoins - joins the closed tickets with their active closing remark, its cause and the user who closed them
+const closedTicketJoins = `
+	INNER JOIN ticket_closing_remarks rm ON rm.ticket_id = tk.ticket_id AND rm.deleted_at IS NULL
+	LEFT JOIN ticket_causes cs ON cs.cause_id = rm.cause_id
+	LEFT JOIN users cb ON cb.user_id = rm.closed_by
+`
+
+const closedTicketSelect = ticketDetailsColumns + `,
+	rm.remark_id AS "closing_remark.remark_id", rm.remark AS "closing_remark.remark", rm.created_at AS "closing_remark.created_at",
+	COALESCE(rm.cause_id::text, '') AS "closing_remark.cause_id", COALESCE(rm.closed_by::text, '') AS "closing_remark.closed_by",
+	COALESCE(cs.cause_id::text, '') AS "closing_remark.cause.cause_id", cs.name AS "closing_remark.cause.name",
+	COALESCE(cb.user_id::text, '') AS "closing_remark.closer.user_id", cb.firstname AS "closing_remark.closer.firstname", cb.lastname AS "closing_remark.closer.lastname"
+` + ticketDetailsJoins + closedTicketJoins
+
+const getClosedTicketQuery = closedTicketSelect + `
+	WHERE tk.ticket_id = $1
+	AND tk.closed_at IS NOT NULL
+	AND tk.deleted_at IS NULL
+`
+
+// GetClosedTicket - retrieves a closed ticket with its properties and closing remark
+func (d *database) GetClosedTicket(ctx context.Context, ticketID *model.TicketID) (*model.Ticket, error) {
+	ticket := model.Ticket{}
+	if err := d.conn.GetContext(ctx, &ticket, getClosedTicketQuery, ticketID); err != nil {
+		logrus.WithError(err).Error()
+		return nil, apiErr.ErrNotFound
+	}
+	tidyClosedTicketProps(&ticket)
+	return &ticket, nil
+}
+
+const listClosedTicketsQuery = closedTicketSelect + `
+	WHERE %s
+	ORDER BY tk.closed_at %s, tk.ticket_id %s
+	LIMIT %d OFFSET %d
+`
+
+const countClosedTicketsQuery = `
+	SELECT COUNT(*)
+	FROM tickets tk
+` + closedTicketJoins + `
+	WHERE %s
+`
+
+// closedTicketFilterClause - builds the WHERE clause and its arguments for the closed ticket filter
+func closedTicketFilterClause(filter *model.ClosedTicketFilter) (string, []interface{}) {
+	conditions := []string{"tk.deleted_at IS NULL", "tk.closed_at IS NOT NULL"}
+	args := []interface{}{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.CauseID != model.NilCauseID {
+		add("rm.cause_id = $%d", filter.CauseID)
+	}
+	if filter.ClosedByID != model.NilUserID {
+		add("rm.closed_by = $%d", filter.ClosedByID)
+	}
+	if filter.ClosedFrom != nil {
+		add("tk.closed_at >= $%d", *filter.ClosedFrom)
+	}
+	if filter.ClosedTo != nil {
+		add("tk.closed_at <= $%d", *filter.ClosedTo)
+	}
+
+	return strings.Join(conditions, "\n\tAND "), args
+}
+
+// ListClosedTickets - returns a page of closed tickets matching the filter and the total number of matches
+func (d *database) ListClosedTickets(ctx context.Context, filter *model.ClosedTicketFilter) ([]*model.Ticket, int, error) {
+
+	where, args := closedTicketFilterClause(filter)
+
+	var total int
+	if err := d.conn.GetContext(ctx, &total, fmt.Sprintf(countClosedTicketsQuery, where), args...); err != nil {
+		return nil, 0, errors.Wrap(err, "could not count closed tickets")
+	}
+
+	order := "DESC"
+	if filter.Order == "asc" {
+		order = "ASC"
+	}
+
+	query := fmt.Sprintf(listClosedTicketsQuery, where, order, order, filter.Limit, filter.Offset)
+	tickets := []*model.Ticket{}
+	if err := d.conn.SelectContext(ctx, &tickets, query, args...); err != nil {
+		return nil, 0, errors.Wrap(err, "could not list closed tickets")
+	}
+	for index := range tickets {
+		tidyClosedTicketProps(tickets[index])
+	}
+	return tickets, total, nil
+}
+
+// tidyClosedTicketProps - tidies the ticket properties and the joined closing remark
+func tidyClosedTicketProps(ticket *model.Ticket) {
+	tidyTicketProps(ticket)
+
+	remark := ticket.ClosingRemark
+	if remark == nil {
+		return
+	}
+	if remark.Cause != nil && remark.Cause.ID == model.NilCauseID {
+		remark.Cause = nil
+	}
+	remark.ClosedBy = tidyUserName(remark.ClosedBy)
+	remark.TicketID = model.NilTicketID
+}
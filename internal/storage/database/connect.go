@@ -7,6 +7,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/config"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/secret"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -86,7 +87,15 @@ func New(cfg *config.Info) (Database, error) {
 		return nil, err
 	}
 
-	database := &database{conn: conn}
+	secrets, err := secret.New(cfg.Mail.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if secrets == nil {
+		logrus.Warn("MAIL_SECRET_KEY is not set, mailbox and TOTP secrets cannot be saved")
+	}
+
+	database := &database{conn: conn, secrets: secrets}
 	return database, nil
 }
 
@@ -68,7 +68,7 @@ func (d *database) CreateContact(ctx context.Context, contact *model.Contact) (e
 
 // correct the  db insertions
 const getContactByIDQuery = `
-	SELECT ct.contact_id, ct.firstname, ct.lastname, ct.phone_no,ct.email ,ct.created_by, ct.created_at, ct.deleted_at
+	SELECT ct.contact_id, ct.firstname, ct.lastname, ct.phone_no,ct.email ,COALESCE(ct.created_by::text, '') AS created_by, ct.created_at, ct.deleted_at
 	FROM contacts ct
 	WHERE ct.contact_id = $1
 	AND ct.deleted_at IS NULL
@@ -87,7 +87,7 @@ func (d *database) GetContactByID(ctx context.Context, contactID *model.ContactI
 // correct db insertions
 
 const listAllContactsQuery = `
-	SELECT ct.contact_id, ct.firstname, ct.lastname, ct.phone_no,ct.email ,ct.created_by, ct.created_at, ct.deleted_at
+	SELECT ct.contact_id, ct.firstname, ct.lastname, ct.phone_no,ct.email ,COALESCE(ct.created_by::text, '') AS created_by, ct.created_at, ct.deleted_at
 	FROM contacts ct
 	WHERE ct.deleted_at IS NULL`
 
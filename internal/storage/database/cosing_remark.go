@@ -25,7 +25,7 @@ const createClosingRemarkQuery = `
 		ticket_id, cause_id, closed_by, remark
 	)
 	VALUES (
-		:ticket_id, :cause_id, :closed_by, :remark
+		:ticket_id, NULLIF(:cause_id, '')::uuid, :closed_by, :remark
 		)
 		RETURNING remark_id`
 
@@ -60,7 +60,7 @@ func (d *database) CreateClosingRemark(ctx context.Context, closingRemark *model
 }
 
 const getClosingRemarkByIDQuery = `
-	SELECT remark_id, ticket_id, cause_id, closed_by, remark, created_at, updated_at, deleted_at
+	SELECT remark_id, ticket_id, COALESCE(cause_id::text, '') AS cause_id, closed_by, remark, created_at, updated_at, deleted_at
 	FROM ticket_closing_remarks
 	WHERE remark_id = $1 
 	AND deleted_at is NULL`
@@ -78,7 +78,7 @@ func (d *database) GetClosingRemarkByID(ctx context.Context, closingRemarkID *mo
 const updateClosingRemarkQuery = `
 	UPDATE ticket_closing_remarks
 	SET 
-	 cause_id = :cause_id,
+	 cause_id = NULLIF(:cause_id, '')::uuid,
 	 remark = :remark,
 	updated_at = NOW()
 	WHERE remark_id = :remark_id
@@ -102,7 +102,7 @@ func (d *database) UpdateClosingRemark(ctx context.Context, closingRemark *model
 }
 
 const listAllRemarksQuery = `
-	SELECT remark_id, ticket_id, cause_id, closed_by, remark, created_at, updated_at, deleted_at
+	SELECT remark_id, ticket_id, COALESCE(cause_id::text, '') AS cause_id, closed_by, remark, created_at, updated_at, deleted_at
 	FROM ticket_closing_remarks
 	WHERE deleted_at is NULL`
 
@@ -4,6 +4,7 @@ import (
 	"io"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/secret"
 )
 
 // UniqueViolation - Postgres error string for a unique Index violation
@@ -20,11 +21,25 @@ type Database interface {
 	PolicyDB
 	RoleDB
 	SessionDB
+	AccountTokenDB
+	MFADB
 	UserDB
 	UserRoleDB
 	SLADB //Service Level Agreement
 	// Tickets
 	TicketsDB
+	TicketAssignmentDB
+	TicketUserDB
+	TicketActivityDB
+	ClosedTicketDB
+	TicketStatusTransitionDB
+	TicketEmailDB
+	NoteFileDB
+	OutboundMailDB
+	EmailQueueDB
+	SLATargetDB
+	CalendarDB
+	SLAEscalationDB
 	TicketCauseDB
 	TicketCategoryDB
 	TicketPriorityDB
@@ -35,6 +50,8 @@ type Database interface {
 
 type database struct {
 	conn *sqlx.DB
+	// secrets encrypts the mailbox and the TOTP secrets
+	secrets *secret.Box
 }
 
 func (d *database) Close() error {
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+)
+
+// EmailQueueDB - holds the mails waiting to be sent
+type EmailQueueDB interface {
+	QueueEmails(ctx context.Context, from string, emails ...*model.QueuedEmail) error
+	ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]*model.QueuedEmail, error)
+	EmailSent(ctx context.Context, emailID model.QueuedEmailID) error
+	EmailFailed(ctx context.Context, email *model.QueuedEmail) error
+	DeleteExpiredEmails(ctx context.Context) (int64, error)
+}
+
+const queueEmailQuery = `
+	INSERT INTO email_queue (
+		ticket_id, event, to_address, subject, body, message_id, in_reply_to, refs, expires_at
+	)
+	VALUES (
+		NULLIF(:ticket_id, '')::uuid, :event, :to_address, :subject, :body, :message_id, :in_reply_to, :refs, :expires_at
+	)
+	RETURNING queue_id, next_attempt_at, created_at`
+
+// QueueEmails - adds the mails to the queue, the Message-IDs are kept on the tickets
+// so the replies of the customers come back to them
+func (d *database) QueueEmails(ctx context.Context, from string, emails ...*model.QueuedEmail) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.PrepareNamedContext(ctx, queueEmailQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare email queue")
+	}
+	defer stmt.Close()
+
+	for _, email := range emails {
+		if err = stmt.QueryRowxContext(ctx, email).Scan(&email.ID, &email.NextAttemptAt, &email.CreatedAt); err != nil {
+			return errors.Wrap(err, "could not queue email")
+		}
+		if email.TicketID == model.NilTicketID {
+			continue
+		}
+		ticketEmail := model.TicketEmail{
+			TicketID:  email.TicketID,
+			NoteID:    email.NoteID,
+			Direction: model.EmailOutbound,
+			MessageID: email.MessageID,
+			InReplyTo: email.InReplyTo,
+			From:      from,
+			Subject:   email.Subject,
+		}
+		if err = insertTicketEmail(ctx, tx, &ticketEmail); err != nil {
+			return
+		}
+	}
+	return tx.Commit()
+}
+
+// the claimed mails are hidden from the other servers until the lease ends
+const claimEmailsQuery = `
+	UPDATE email_queue
+	SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+	WHERE queue_id IN (
+		SELECT queue_id
+		FROM email_queue
+		WHERE sent_at IS NULL
+		AND next_attempt_at <= NOW()
+		AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY next_attempt_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING queue_id, COALESCE(ticket_id::text, '') AS ticket_id, event, to_address, subject, body,
+	message_id, in_reply_to, refs, attempts, next_attempt_at, last_error, sent_at, expires_at, created_at`
+
+// ClaimEmails - returns the mails due to be sent, the lease is how long the sender has to send them
+func (d *database) ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]*model.QueuedEmail, error) {
+	emails := []*model.QueuedEmail{}
+	if err := d.conn.SelectContext(ctx, &emails, claimEmailsQuery, limit, int(lease.Seconds())); err != nil {
+		return nil, errors.Wrap(err, "could not claim emails")
+	}
+	return emails, nil
+}
+
+// the body of the mails carrying a token is blanked, the token is not kept once mailed
+const emailSentQuery = `
+	UPDATE email_queue
+	SET sent_at = NOW(),
+	attempts = attempts + 1,
+	last_error = '',
+	body = CASE WHEN expires_at IS NULL THEN body ELSE '' END
+	WHERE queue_id = $1`
+
+// EmailSent - removes the mail from the queue
+func (d *database) EmailSent(ctx context.Context, emailID model.QueuedEmailID) error {
+	if _, err := d.conn.ExecContext(ctx, emailSentQuery, emailID); err != nil {
+		return errors.Wrap(err, "could not mark email as sent")
+	}
+	return nil
+}
+
+const emailFailedQuery = `
+	UPDATE email_queue
+	SET attempts = :attempts,
+	next_attempt_at = :next_attempt_at,
+	last_error = :last_error
+	WHERE queue_id = :queue_id
+	AND sent_at IS NULL`
+
+// EmailFailed - keeps the mail in the queue until its next attempt
+func (d *database) EmailFailed(ctx context.Context, email *model.QueuedEmail) error {
+	if _, err := d.conn.NamedExecContext(ctx, emailFailedQuery, email); err != nil {
+		return errors.Wrap(err, "could not save the email failure")
+	}
+	return nil
+}
+
+const deleteExpiredEmailsQuery = `
+	DELETE FROM email_queue
+	WHERE sent_at IS NULL
+	AND expires_at <= NOW()`
+
+// DeleteExpiredEmails - removes the mails whose token expired before they could be sent, returns how many
+func (d *database) DeleteExpiredEmails(ctx context.Context) (int64, error) {
+	result, err := d.conn.ExecContext(ctx, deleteExpiredEmailsQuery)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not delete the expired emails")
+	}
+	return result.RowsAffected()
+}
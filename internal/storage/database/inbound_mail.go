@@ -5,30 +5,81 @@ import (
 
 	"github.com/lib/pq"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/secret"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	pkgErrors "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
 // InboundEmaiiDB - Holds all the information to connect to the email server
 type InboundEmaiiDB interface {
-	GetInboundMail(ctx context.Context) (*model.InboudMail, error)
+	CreateInboundMail(ctx context.Context, inboundMail *model.InboudMail) error
+	GetInboundMailByID(ctx context.Context, inboundMailID *model.InboudMailID) (*model.InboudMail, error)
+	ListInboundMails(ctx context.Context) ([]*model.InboudMail, error)
+	ListActiveInboundMails(ctx context.Context) ([]*model.InboudMail, error)
 	UpdateInboudMail(ctx context.Context, inboundMail *model.InboudMail) error
+	UpdateInboundMailStatus(ctx context.Context, inboundMail *model.InboudMail) error
+	DeleteInboundMail(ctx context.Context, inboundMailID *model.InboudMailID) (bool, error)
 }
 
-const getInboundMailQuery = `
-	SELECT 
-	email_id, "name", status, address, email_user, 
-	email_secret, port, secured, mailbox, is_primary, 
-	last_seq, last_synced,poll_period, created_by, delete_seen, 
+// the default source of the tickets is the Email source
+const createInboundMailQuery = `
+	INSERT INTO inbound_emails (
+		"name", address, email_user, email_secret, port, secured, mailbox, is_primary,
+		last_seq, poll_period, created_by, delete_seen, active,
+		category_id, priority_id, agreement_id, source_id
+	)
+	VALUES (
+		:name, :address, :email_user, :email_secret, :port, :secured, :mailbox, :is_primary,
+		:last_seq, :poll_period, NULLIF(:created_by, '')::uuid, :delete_seen, :active,
+		NULLIF(:category_id, '')::uuid, NULLIF(:priority_id, '')::uuid, NULLIF(:agreement_id, '')::uuid,
+		COALESCE(NULLIF(:source_id, '')::uuid, (
+			SELECT source_id FROM ticket_sources WHERE name = 'Email' AND deleted_at IS NULL LIMIT 1
+		))
+	)
+	RETURNING email_id`
+
+// CreateInboundMail - saves a mailbox, the secret is encrypted
+func (d *database) CreateInboundMail(ctx context.Context, inboundMail *model.InboudMail) error {
+
+	stored, err := d.sealInboundMail(inboundMail)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := d.conn.PrepareNamedContext(ctx, createInboundMailQuery)
+	if err != nil {
+		return pkgErrors.Wrap(err, "could not prepare inbound mail")
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowxContext(ctx, stored).Scan(&inboundMail.ID); err != nil {
+		return inboundMailError(err)
+	}
+	return nil
+}
+
+const inboundMailColumns = `
+	SELECT
+	email_id, "name", status, address, email_user,
+	email_secret, port, secured, mailbox, is_primary,
+	last_seq, last_synced,poll_period, COALESCE(created_by::text, '') AS created_by, delete_seen, active,
+	uid_validity, last_uid, last_error, last_error_at, messages_processed,
+	COALESCE(category_id::text, '') AS category_id, COALESCE(priority_id::text, '') AS priority_id,
+	COALESCE(agreement_id::text, '') AS agreement_id, COALESCE(source_id::text, '') AS source_id,
 	created_at, updated_at, deleted_at
-	FROM 
-	inbound_emails;
-`
+	FROM
+	inbound_emails
+	WHERE deleted_at IS NULL`
 
-func (d *database) GetInboundMail(ctx context.Context) (*model.InboudMail, error) {
+const getInboundMailByIDQuery = inboundMailColumns + `
+	AND email_id = $1`
+
+// GetInboundMailByID - returns a mailbox with its secret decrypted
+func (d *database) GetInboundMailByID(ctx context.Context, inboundMailID *model.InboudMailID) (*model.InboudMail, error) {
 
 	var inboudMail model.InboudMail
-	if err := d.conn.GetContext(ctx, &inboudMail, getInboundMailQuery); err != nil {
+	if err := d.conn.GetContext(ctx, &inboudMail, getInboundMailByIDQuery, inboundMailID); err != nil {
 		if pqError, ok := err.(*pq.Error); ok {
 			logrus.WithFields(logrus.Fields{
 				"PQ Code.Name":   pqError.Code.Name(),
@@ -37,42 +88,203 @@ func (d *database) GetInboundMail(ctx context.Context) (*model.InboudMail, error
 			}).Info()
 		}
 
-		return nil, err
+		return nil, errors.ErrNotExist("Inbound mail")
 	}
 
+	if err := d.openInboundMail(&inboudMail); err != nil {
+		return nil, err
+	}
 	return &inboudMail, nil
 }
 
+const listInboundMailsQuery = inboundMailColumns + `
+	ORDER BY is_primary DESC, created_at ASC`
+
+// ListInboundMails - returns all the mailboxes
+func (d *database) ListInboundMails(ctx context.Context) ([]*model.InboudMail, error) {
+	return d.listInboundMails(ctx, listInboundMailsQuery)
+}
+
+const listActiveInboundMailsQuery = inboundMailColumns + `
+	AND active
+	ORDER BY is_primary DESC, created_at ASC`
+
+// ListActiveInboundMails - returns the mailboxes that are polled
+func (d *database) ListActiveInboundMails(ctx context.Context) ([]*model.InboudMail, error) {
+	return d.listInboundMails(ctx, listActiveInboundMailsQuery)
+}
+
+func (d *database) listInboundMails(ctx context.Context, query string) ([]*model.InboudMail, error) {
+
+	inboundMails := []*model.InboudMail{}
+	if err := d.conn.SelectContext(ctx, &inboundMails, query); err != nil {
+		return nil, pkgErrors.Wrap(err, "could not get inbound mails")
+	}
+	for _, inboundMail := range inboundMails {
+		if err := d.openInboundMail(inboundMail); err != nil {
+			return nil, err
+		}
+	}
+	return inboundMails, nil
+}
+
 const updateInboudMailQuery = `
 	UPDATE inbound_emails
 	SET
-	name=:name, 
-	status=:status, 
-	address=:address, 
-	email_user=:email_user, 
-	email_secret=:email_secret, 
+	name=:name,
+	status=:status,
+	address=:address,
+	email_user=:email_user,
+	email_secret=:email_secret,
 	port=:port,
-	secured=:secured, 
-	mailbox=:mailbox, 
-	is_primary=:is_primary, 
-	last_seq=:last_seq, 
-	last_synced=:last_synced, 
-	delete_seen=:delete_seen, 
+	secured=:secured,
+	mailbox=:mailbox,
+	is_primary=:is_primary,
+	poll_period=:poll_period,
+	delete_seen=:delete_seen,
+	active=:active,
+	category_id=NULLIF(:category_id, '')::uuid,
+	priority_id=NULLIF(:priority_id, '')::uuid,
+	agreement_id=NULLIF(:agreement_id, '')::uuid,
+	source_id=NULLIF(:source_id, '')::uuid,
 	updated_at=NOW()
 	WHERE email_id = :email_id
 	AND deleted_at is null
 `
 
+// UpdateInboudMail - saves the settings of a mailbox, the secret is encrypted again
 func (d *database) UpdateInboudMail(ctx context.Context, inboundMail *model.InboudMail) error {
 
-	result, err := d.conn.NamedExecContext(ctx, updateInboudMailQuery, inboundMail)
+	stored, err := d.sealInboundMail(inboundMail)
 	if err != nil {
 		return err
 	}
 
+	result, err := d.conn.NamedExecContext(ctx, updateInboudMailQuery, stored)
+	if err != nil {
+		return inboundMailError(err)
+	}
+
 	rows, err := result.RowsAffected()
 	if err != nil || rows == 0 {
 		return errors.ErrNotFound
 	}
 	return nil
 }
+
+const updateInboundMailStatusQuery = `
+	UPDATE inbound_emails
+	SET
+	status=:status,
+	last_seq=:last_seq,
+	last_synced=:last_synced,
+	uid_validity=:uid_validity,
+	last_uid=:last_uid,
+	last_error=COALESCE(:last_error, last_error),
+	last_error_at=:last_error_at,
+	messages_processed=COALESCE(:messages_processed, messages_processed),
+	updated_at=NOW()
+	WHERE email_id = :email_id
+	AND deleted_at is null
+`
+
+// UpdateInboundMailStatus - saves the state of the poller, the settings are left as they are
+func (d *database) UpdateInboundMailStatus(ctx context.Context, inboundMail *model.InboudMail) error {
+
+	result, err := d.conn.NamedExecContext(ctx, updateInboundMailStatusQuery, inboundMail)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+const deleteInboundMailQuery = `
+	UPDATE inbound_emails
+	SET deleted_at = NOW()
+	WHERE email_id = $1
+	AND deleted_at IS NULL`
+
+// DeleteInboundMail - removes a mailbox, the tickets it created are kept
+func (d *database) DeleteInboundMail(ctx context.Context, inboundMailID *model.InboudMailID) (bool, error) {
+
+	result, err := d.conn.ExecContext(ctx, deleteInboundMailQuery, inboundMailID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+	return true, nil
+}
+
+// sealInboundMail - a copy of the mailbox with its secret encrypted
+func (d *database) sealInboundMail(inboundMail *model.InboudMail) (*model.InboudMail, error) {
+
+	stored := *inboundMail
+	if stored.EmailSecret == nil {
+		return &stored, nil
+	}
+	sealed, err := d.secrets.Seal(*stored.EmailSecret)
+	if err != nil {
+		if err == secret.ErrNoKey {
+			return nil, errors.ErrNoSecretKey
+		}
+		return nil, pkgErrors.Wrap(err, "could not encrypt the secret")
+	}
+	stored.EmailSecret = &sealed
+	return &stored, nil
+}
+
+// openInboundMail - decrypts the secret of a mailbox
+func (d *database) openInboundMail(inboundMail *model.InboudMail) error {
+
+	if inboundMail.EmailSecret == nil {
+		return nil
+	}
+	plain, err := d.secrets.Open(*inboundMail.EmailSecret)
+	if err != nil {
+		if err == secret.ErrNoKey {
+			return errors.ErrNoSecretKey
+		}
+		return pkgErrors.Wrap(err, "could not decrypt the secret")
+	}
+	inboundMail.EmailSecret = &plain
+	return nil
+}
+
+// inboundMailError - maps the constraints of the mailboxes to their errors
+func inboundMailError(err error) error {
+
+	if pqError, ok := err.(*pq.Error); ok {
+		switch pqError.Code.Name() {
+		case UniqueViolation:
+			if pqError.Constraint == "inbound_emails_unique" || pqError.Constraint == "inbound_emails_name" {
+				return errors.ErrInboundMailExists
+			}
+		case "foreign_key_violation":
+			switch pqError.Constraint {
+			case "inbound_emails_category_id_fkey":
+				return errors.ErrNotExist("Category")
+			case "inbound_emails_priority_id_fkey":
+				return errors.ErrNotExist("Priority")
+			case "inbound_emails_agreement_id_fkey":
+				return errors.ErrNotExist("SLA")
+			case "inbound_emails_source_id_fkey":
+				return errors.ErrNotExist("Source")
+			}
+		}
+		logrus.WithFields(logrus.Fields{
+			"PQ Code.Name":   pqError.Code.Name(),
+			"PQ Constraints": pqError.Constraint,
+			"PQ Column":      pqError.Column,
+		}).Info()
+	}
+	return pkgErrors.Wrap(err, "could not save inbound mail")
+}
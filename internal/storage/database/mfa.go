@@ -0,0 +1,410 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/secret"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// MFADB - holds the authenticator apps, the recovery codes and the MFA requirements
+type MFADB interface {
+	GetMFA(ctx context.Context, userID *model.UserID) (*model.MFA, error)
+	EnrollMFA(ctx context.Context, userID *model.UserID, totpSecret string, recoveryCodes []string) error
+	EnableMFA(ctx context.Context, userID *model.UserID, step int64) (bool, error)
+	DisableMFA(ctx context.Context, userID *model.UserID) (bool, error)
+	UseTOTPStep(ctx context.Context, userID *model.UserID, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID *model.UserID, code string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID *model.UserID, recoveryCodes []string) error
+	CountRecoveryCodes(ctx context.Context, userID *model.UserID) (int, error)
+	RecordMFAFailure(ctx context.Context, userID *model.UserID, window time.Duration) error
+	IsMFARequired(ctx context.Context, userID *model.UserID) (bool, error)
+	CreateMFARequirement(ctx context.Context, requirement *model.MFARequirement) error
+	ListMFARequirements(ctx context.Context) ([]*model.MFARequirement, error)
+	DeleteMFARequirement(ctx context.Context, requirementID *model.MFARequirementID) (bool, error)
+}
+
+const getMFAQuery = `
+	SELECT user_id, totp_secret, enabled_at, last_used_step, failed_attempts, last_failed_at, created_at, updated_at
+	FROM user_mfa
+	WHERE user_id = $1
+`
+
+// GetMFA - the authenticator app of the user with its secret decrypted, sql.ErrNoRows when there is none
+func (d *database) GetMFA(ctx context.Context, userID *model.UserID) (*model.MFA, error) {
+
+	var mfa model.MFA
+	if err := d.conn.GetContext(ctx, &mfa, getMFAQuery, userID); err != nil {
+		return nil, err
+	}
+	plain, err := d.secrets.Open(mfa.Secret)
+	if err != nil {
+		if err == secret.ErrNoKey {
+			return nil, apiErr.ErrNoMFASecretKey
+		}
+		return nil, errors.Wrap(err, "could not decrypt the totp secret")
+	}
+	mfa.Secret = plain
+	return &mfa, nil
+}
+
+// a new enrolment replaces the one that was not confirmed, never an enabled one
+const enrollMFAQuery = `
+	INSERT INTO user_mfa
+	(user_id, totp_secret)
+	VALUES($1, $2)
+	ON CONFLICT (user_id)
+	DO
+		UPDATE
+			SET totp_secret = $2,
+				last_used_step = 0,
+				failed_attempts = 0,
+				last_failed_at = NULL,
+				updated_at = NOW()
+			WHERE user_mfa.enabled_at IS NULL
+`
+
+// EnrollMFA - keeps the secret of a new authenticator app and its recovery codes, they are used once
+// the app is enabled with a first code
+func (d *database) EnrollMFA(ctx context.Context, userID *model.UserID, totpSecret string, recoveryCodes []string) (err error) {
+
+	sealed, err := d.secrets.Seal(totpSecret)
+	if err != nil {
+		if err == secret.ErrNoKey {
+			return apiErr.ErrNoMFASecretKey
+		}
+		return errors.Wrap(err, "could not encrypt the totp secret")
+	}
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, enrollMFAQuery, userID, sealed)
+	if err != nil {
+		return errors.Wrap(err, "could not save the totp secret")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		err = apiErr.ErrMFAAlreadyEnabled
+		return
+	}
+	if err = replaceRecoveryCodes(ctx, tx, userID, recoveryCodes); err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
+
+const enableMFAQuery = `
+	UPDATE user_mfa
+	SET enabled_at = NOW(),
+		last_used_step = $2,
+		failed_attempts = 0,
+		last_failed_at = NULL,
+		updated_at = NOW()
+	WHERE user_id = $1 AND enabled_at IS NULL
+`
+
+// EnableMFA - confirms the enrolment with the step of the first code, false when it was already enabled
+func (d *database) EnableMFA(ctx context.Context, userID *model.UserID, step int64) (bool, error) {
+	result, err := d.conn.ExecContext(ctx, enableMFAQuery, userID, step)
+	if err != nil {
+		return false, errors.Wrap(err, "could not enable mfa")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+const deleteMFAQuery = `
+	DELETE FROM user_mfa
+	WHERE user_id = $1
+`
+
+const deleteRecoveryCodesQuery = `
+	DELETE FROM mfa_recovery_codes
+	WHERE user_id = $1
+`
+
+// DisableMFA - removes the authenticator app and the recovery codes of the user
+func (d *database) DisableMFA(ctx context.Context, userID *model.UserID) (deleted bool, err error) {
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, deleteMFAQuery, userID)
+	if err != nil {
+		return false, errors.Wrap(err, "could not disable mfa")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if _, err = tx.ExecContext(ctx, deleteRecoveryCodesQuery, userID); err != nil {
+		return false, errors.Wrap(err, "could not delete the recovery codes")
+	}
+
+	return rows > 0, tx.Commit()
+}
+
+// only one request can use a code, the codes of a step before the last one are refused as well
+const useTOTPStepQuery = `
+	UPDATE user_mfa
+	SET last_used_step = $2,
+		failed_attempts = 0,
+		last_failed_at = NULL,
+		updated_at = NOW()
+	WHERE user_id = $1
+		AND enabled_at IS NOT NULL
+		AND last_used_step < $2
+`
+
+// UseTOTPStep - keeps the step of the code accepted, false when a code of the step was already used
+func (d *database) UseTOTPStep(ctx context.Context, userID *model.UserID, step int64) (bool, error) {
+	result, err := d.conn.ExecContext(ctx, useTOTPStepQuery, userID, step)
+	if err != nil {
+		return false, errors.Wrap(err, "could not use the totp code")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// the recovery codes only work once the app is enabled
+const useRecoveryCodeQuery = `
+	UPDATE mfa_recovery_codes c
+	SET used_at = NOW()
+	FROM user_mfa m
+	WHERE c.code_hash = $1
+		AND c.user_id = $2
+		AND c.used_at IS NULL
+		AND m.user_id = c.user_id
+		AND m.enabled_at IS NOT NULL
+`
+
+const resetMFAFailuresQuery = `
+	UPDATE user_mfa
+	SET failed_attempts = 0,
+		last_failed_at = NULL,
+		updated_at = NOW()
+	WHERE user_id = $1
+`
+
+// UseRecoveryCode - marks the recovery code as used, false when it is unknown or was already used
+func (d *database) UseRecoveryCode(ctx context.Context, userID *model.UserID, code string) (used bool, err error) {
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, useRecoveryCodeQuery, tokenHash(code), userID)
+	if err != nil {
+		return false, errors.Wrap(err, "could not use the recovery code")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, tx.Commit()
+	}
+	if _, err = tx.ExecContext(ctx, resetMFAFailuresQuery, userID); err != nil {
+		return false, errors.Wrap(err, "could not reset the failed attempts")
+	}
+
+	return true, tx.Commit()
+}
+
+// ReplaceRecoveryCodes - the new recovery codes of the user, the previous ones no longer work
+func (d *database) ReplaceRecoveryCodes(ctx context.Context, userID *model.UserID, recoveryCodes []string) (err error) {
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, recoveryCodes); err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
+
+const insertRecoveryCodeQuery = `
+	INSERT INTO mfa_recovery_codes
+	(code_hash, user_id)
+	VALUES($1, $2)
+`
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID *model.UserID, recoveryCodes []string) error {
+
+	if _, err := tx.ExecContext(ctx, deleteRecoveryCodesQuery, userID); err != nil {
+		return errors.Wrap(err, "could not delete the recovery codes")
+	}
+	for _, code := range recoveryCodes {
+		if _, err := tx.ExecContext(ctx, insertRecoveryCodeQuery, tokenHash(code), userID); err != nil {
+			return errors.Wrap(err, "could not save the recovery codes")
+		}
+	}
+	return nil
+}
+
+const countRecoveryCodesQuery = `
+	SELECT COUNT(*)
+	FROM mfa_recovery_codes
+	WHERE user_id = $1 AND used_at IS NULL
+`
+
+// CountRecoveryCodes - the recovery codes the user has left
+func (d *database) CountRecoveryCodes(ctx context.Context, userID *model.UserID) (int, error) {
+	var count int
+	if err := d.conn.GetContext(ctx, &count, countRecoveryCodesQuery, userID); err != nil {
+		return 0, errors.Wrap(err, "could not count the recovery codes")
+	}
+	return count, nil
+}
+
+// the failures older than the window are forgotten
+const recordMFAFailureQuery = `
+	UPDATE user_mfa
+	SET failed_attempts = CASE
+			WHEN last_failed_at IS NULL OR last_failed_at < $2 THEN 1
+			ELSE failed_attempts + 1
+		END,
+		last_failed_at = NOW(),
+		updated_at = NOW()
+	WHERE user_id = $1
+`
+
+// RecordMFAFailure - counts an invalid code typed by the user
+func (d *database) RecordMFAFailure(ctx context.Context, userID *model.UserID, window time.Duration) error {
+	if _, err := d.conn.ExecContext(ctx, recordMFAFailureQuery, userID, time.Now().Add(-window)); err != nil {
+		return errors.Wrap(err, "could not record the failed attempt")
+	}
+	return nil
+}
+
+// the primary role of the user and the roles given to them are all checked
+const isMFARequiredQuery = `
+	SELECT EXISTS(
+		SELECT 1
+		FROM mfa_requirements r
+		JOIN users u ON u.user_id = $1
+		WHERE r.user_type = u.user_type
+			OR r.role_id = u.role_id
+			OR r.role_id IN (
+				SELECT ur.role_id FROM users_roles ur
+				WHERE ur.user_id = u.user_id AND ur.deleted_at IS NULL
+			)
+	)
+`
+
+// IsMFARequired - checks the type or a role of the user requires MFA
+func (d *database) IsMFARequired(ctx context.Context, userID *model.UserID) (bool, error) {
+	var required bool
+	if err := d.conn.GetContext(ctx, &required, isMFARequiredQuery, userID); err != nil {
+		return false, errors.Wrap(err, "could not check the mfa requirements")
+	}
+	return required, nil
+}
+
+const createMFARequirementQuery = `
+	INSERT INTO mfa_requirements
+	(user_type, role_id)
+	VALUES($1, $2)
+	RETURNING requirement_id, created_at
+`
+
+// CreateMFARequirement - requires MFA for the user type or the role
+func (d *database) CreateMFARequirement(ctx context.Context, requirement *model.MFARequirement) error {
+
+	row := d.conn.QueryRowxContext(ctx, createMFARequirementQuery, requirement.UserType, requirement.RoleID)
+	if err := row.Scan(&requirement.ID, &requirement.CreatedAt); err != nil {
+		if pqError, ok := err.(*pq.Error); ok {
+			switch pqError.Code.Name() {
+			case UniqueViolation:
+				return apiErr.ErrMFARequirementExists
+			case "foreign_key_violation":
+				return apiErr.ErrNotExist("Role")
+			}
+			logrus.WithFields(logrus.Fields{
+				"PQ Code.Name":   pqError.Code.Name(),
+				"PQ Constraints": pqError.Constraint,
+				"PQ Column":      pqError.Column,
+			}).Info()
+		}
+		return errors.Wrap(err, "could not create the mfa requirement")
+	}
+	return nil
+}
+
+const listMFARequirementsQuery = `
+	SELECT requirement_id, user_type, role_id, created_at
+	FROM mfa_requirements
+	ORDER BY created_at
+`
+
+// ListMFARequirements - the user types and roles that have to sign in with MFA
+func (d *database) ListMFARequirements(ctx context.Context) ([]*model.MFARequirement, error) {
+	requirements := []*model.MFARequirement{}
+	if err := d.conn.SelectContext(ctx, &requirements, listMFARequirementsQuery); err != nil {
+		return nil, errors.Wrap(err, "could not list the mfa requirements")
+	}
+	return requirements, nil
+}
+
+const deleteMFARequirementQuery = `
+	DELETE FROM mfa_requirements
+	WHERE requirement_id = $1
+`
+
+// DeleteMFARequirement - MFA is optional again for the user type or the role
+func (d *database) DeleteMFARequirement(ctx context.Context, requirementID *model.MFARequirementID) (bool, error) {
+	result, err := d.conn.ExecContext(ctx, deleteMFARequirementQuery, requirementID)
+	if err != nil {
+		return false, errors.Wrap(err, "could not delete the mfa requirement")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS tickets_status_id;
+DROP INDEX IF EXISTS tickets_priority_id;
+DROP INDEX IF EXISTS tickets_category_id;
+DROP INDEX IF EXISTS tickets_assigned_to;
+DROP INDEX IF EXISTS tickets_created_by;
+DROP INDEX IF EXISTS tickets_created_at;
+DROP INDEX IF EXISTS tickets_deadline;
@@ -0,0 +1,7 @@
+CREATE INDEX IF NOT EXISTS tickets_status_id ON tickets USING btree (status_id) WHERE (deleted_at IS NULL);
+CREATE INDEX IF NOT EXISTS tickets_priority_id ON tickets USING btree (priority_id) WHERE (deleted_at IS NULL);
+CREATE INDEX IF NOT EXISTS tickets_category_id ON tickets USING btree (category_id) WHERE (deleted_at IS NULL);
+CREATE INDEX IF NOT EXISTS tickets_assigned_to ON tickets USING btree (assigned_to) WHERE (deleted_at IS NULL);
+CREATE INDEX IF NOT EXISTS tickets_created_by ON tickets USING btree (created_by) WHERE (deleted_at IS NULL);
+CREATE INDEX IF NOT EXISTS tickets_created_at ON tickets USING btree (created_at) WHERE (deleted_at IS NULL);
+CREATE INDEX IF NOT EXISTS tickets_deadline ON tickets USING btree (deadline) WHERE (deleted_at IS NULL);
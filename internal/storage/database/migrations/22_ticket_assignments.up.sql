@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS ticket_assignments(
+    assignment_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL REFERENCES tickets,
+    assigned_to UUID REFERENCES users,
+    assigned_by UUID REFERENCES users,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS ticket_assignments_ticket ON ticket_assignments USING btree (ticket_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS tickets_users CASCADE;
+DROP TYPE IF EXISTS ticket_user_role;
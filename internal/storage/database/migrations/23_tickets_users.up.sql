@@ -0,0 +1,29 @@
+DROP TYPE IF EXISTS ticket_user_role;
+CREATE TYPE ticket_user_role AS ENUM (
+'assignee',
+'collaborator',
+'watcher'
+);
+
+CREATE TABLE IF NOT EXISTS tickets_users(
+    ticket_id UUID NOT NULL REFERENCES tickets,
+    user_id UUID NOT NULL REFERENCES users,
+    role ticket_user_role NOT NULL DEFAULT 'watcher',
+    added_by UUID REFERENCES users,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX user_tickets_user ON tickets_users USING btree (ticket_id, user_id)
+WHERE (deleted_at IS NULL);
+
+CREATE INDEX IF NOT EXISTS tickets_users_user ON tickets_users USING btree (user_id)
+WHERE (deleted_at IS NULL);
+
+-- tickets that are already assigned
+INSERT INTO tickets_users (ticket_id, user_id, role)
+SELECT ticket_id, assigned_to, 'assignee'
+FROM tickets
+WHERE assigned_to IS NOT NULL
+AND deleted_at IS NULL;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS ticket_activities(
+    activity_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL REFERENCES tickets,
+    user_id UUID REFERENCES users,
+    action VARCHAR(32) NOT NULL,
+    field VARCHAR(64),
+    old_value TEXT,
+    new_value TEXT,
+    note_id UUID REFERENCES ticket_notes,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS ticket_activities_ticket ON ticket_activities USING btree (ticket_id, created_at);
+
+-- give the existing tickets a starting point in their timeline
+INSERT INTO ticket_activities (ticket_id, user_id, action, created_at)
+SELECT ticket_id, created_by, 'created', created_at
+FROM tickets;
+
+INSERT INTO ticket_activities (ticket_id, user_id, action, note_id, created_at)
+SELECT ticket_id, created_by, 'note_added', note_id, created_at
+FROM ticket_notes
+WHERE ticket_id IS NOT NULL;
+
+INSERT INTO ticket_activities (ticket_id, user_id, action, field, new_value, created_at)
+SELECT ticket_id, closed_by, 'closed', 'closed_at', created_at::text, created_at
+FROM ticket_closing_remarks
+WHERE deleted_at IS NULL;
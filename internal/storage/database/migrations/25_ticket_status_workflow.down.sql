@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ticket_status_transitions CASCADE;
+
+DROP INDEX IF EXISTS ticket_statuses_initial;
+
+ALTER TABLE ticket_statuses DROP COLUMN IF EXISTS type;
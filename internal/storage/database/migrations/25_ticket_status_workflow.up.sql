@@ -0,0 +1,23 @@
+ALTER TABLE ticket_statuses ADD COLUMN IF NOT EXISTS type VARCHAR(16) NOT NULL DEFAULT 'open';
+
+-- new tickets start in the initial status
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_statuses_initial ON ticket_statuses USING btree (type)
+WHERE
+    (type = 'initial' AND deleted_at IS NULL);
+
+CREATE TABLE IF NOT EXISTS ticket_status_transitions(
+    transition_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    from_status_id UUID NOT NULL REFERENCES ticket_statuses,
+    to_status_id UUID NOT NULL REFERENCES ticket_statuses,
+    requires_note BOOLEAN NOT NULL DEFAULT FALSE,
+    role_id UUID REFERENCES roles,
+    closes_ticket BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID REFERENCES users,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_status_transitions_unique ON ticket_status_transitions USING btree (from_status_id, to_status_id)
+WHERE
+    (deleted_at IS NULL);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS tickets_sla_warning_at;
+
+ALTER TABLE tickets DROP COLUMN IF EXISTS sla_warning_at;
+ALTER TABLE tickets DROP COLUMN IF EXISTS first_responded_at;
+ALTER TABLE tickets DROP COLUMN IF EXISTS first_response_due;
+
+DROP TABLE IF EXISTS ticket_sla_targets CASCADE;
+
+ALTER TABLE ticket_slas DROP COLUMN IF EXISTS resolution_minutes;
+ALTER TABLE ticket_slas DROP COLUMN IF EXISTS first_response_minutes;
@@ -0,0 +1,29 @@
+ALTER TABLE ticket_slas ADD COLUMN IF NOT EXISTS first_response_minutes int4;
+ALTER TABLE ticket_slas ADD COLUMN IF NOT EXISTS resolution_minutes int4;
+
+-- the grace period becomes the resolution target
+UPDATE ticket_slas SET resolution_minutes = grace_period * 60 WHERE resolution_minutes IS NULL;
+
+CREATE TABLE IF NOT EXISTS ticket_sla_targets(
+    target_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    agreement_id UUID NOT NULL REFERENCES ticket_slas,
+    priority_id UUID NOT NULL REFERENCES ticket_priorities,
+    first_response_minutes int4,
+    resolution_minutes int4 NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_sla_targets_priority ON ticket_sla_targets USING btree (agreement_id, priority_id)
+WHERE
+    (deleted_at IS NULL);
+
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS first_response_due TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS first_responded_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS sla_warning_at TIMESTAMP WITH TIME ZONE;
+
+-- open tickets are at risk once the last quarter of their resolution time starts
+UPDATE tickets SET sla_warning_at = deadline - (deadline - created_at) / 4 WHERE deadline IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS tickets_sla_warning_at ON tickets USING btree (sla_warning_at) WHERE (closed_at IS NULL AND deleted_at IS NULL);
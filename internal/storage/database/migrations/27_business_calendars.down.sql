@@ -0,0 +1,5 @@
+ALTER TABLE ticket_slas DROP COLUMN IF EXISTS calendar_id;
+
+DROP TABLE IF EXISTS business_calendar_holidays CASCADE;
+DROP TABLE IF EXISTS business_calendar_hours CASCADE;
+DROP TABLE IF EXISTS business_calendars CASCADE;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS business_calendars(
+    calendar_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(50) NOT NULL DEFAULT '',
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS business_calendars_name ON business_calendars USING btree (name)
+WHERE
+    (deleted_at IS NULL);
+
+-- weekday follows time.Weekday, 0 is Sunday
+CREATE TABLE IF NOT EXISTS business_calendar_hours(
+    calendar_id UUID NOT NULL REFERENCES business_calendars ON DELETE CASCADE,
+    weekday int2 NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL CHECK (end_time > start_time),
+    PRIMARY KEY (calendar_id, weekday)
+);
+
+CREATE TABLE IF NOT EXISTS business_calendar_holidays(
+    holiday_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    calendar_id UUID NOT NULL REFERENCES business_calendars ON DELETE CASCADE,
+    date DATE NOT NULL,
+    name VARCHAR(50) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS business_calendar_holidays_date ON business_calendar_holidays USING btree (calendar_id, date);
+
+ALTER TABLE ticket_slas ADD COLUMN IF NOT EXISTS calendar_id UUID REFERENCES business_calendars;
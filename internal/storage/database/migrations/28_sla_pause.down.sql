@@ -0,0 +1,4 @@
+ALTER TABLE tickets DROP COLUMN IF EXISTS sla_paused_minutes;
+ALTER TABLE tickets DROP COLUMN IF EXISTS sla_paused_at;
+
+ALTER TABLE ticket_statuses DROP COLUMN IF EXISTS pauses_sla;
@@ -0,0 +1,6 @@
+ALTER TABLE ticket_statuses ADD COLUMN IF NOT EXISTS pauses_sla BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- sla_paused_at is set while the ticket is in a pausing status,
+-- sla_paused_minutes is the paused time that was added back to the deadlines
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS sla_paused_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS sla_paused_minutes INTEGER NOT NULL DEFAULT 0;
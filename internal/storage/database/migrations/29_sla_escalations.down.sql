@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ticket_escalations CASCADE;
+DROP TABLE IF EXISTS sla_escalations CASCADE;
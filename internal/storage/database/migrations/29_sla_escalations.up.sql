@@ -0,0 +1,32 @@
+-- escalation steps run when a ticket reaches its warning time or its deadline,
+-- a step without an SLA applies to every SLA
+CREATE TABLE IF NOT EXISTS sla_escalations(
+    escalation_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(50) NOT NULL DEFAULT '',
+    level int2 NOT NULL CHECK (level > 0),
+    trigger_on VARCHAR(20) NOT NULL DEFAULT 'breached' CHECK (trigger_on IN ('at_risk', 'breached')),
+    after_minutes INTEGER NOT NULL DEFAULT 0,
+    agreement_id UUID REFERENCES ticket_slas,
+    priority_id UUID REFERENCES ticket_priorities,
+    assign_to UUID REFERENCES users,
+    assign_role_id UUID REFERENCES roles,
+    note TEXT,
+    notify BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID REFERENCES users,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS sla_escalations_level ON sla_escalations USING btree (COALESCE(agreement_id, '00000000-0000-0000-0000-000000000000'), level)
+WHERE
+    (deleted_at IS NULL);
+
+-- the primary key makes sure a ticket is escalated once per level, whichever server runs the monitor
+CREATE TABLE IF NOT EXISTS ticket_escalations(
+    ticket_id UUID NOT NULL REFERENCES tickets,
+    level int2 NOT NULL,
+    escalation_id UUID NOT NULL REFERENCES sla_escalations,
+    escalated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (ticket_id, level)
+);
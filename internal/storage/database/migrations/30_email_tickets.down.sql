@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS ticket_emails CASCADE;
+
+ALTER TABLE tickets DROP COLUMN IF EXISTS contact_id;
+
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS source_id;
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS agreement_id;
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS priority_id;
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS category_id;
@@ -0,0 +1,38 @@
+INSERT INTO ticket_sources (name, description)
+SELECT 'Email', 'Tickets created from inbound mail'
+WHERE NOT EXISTS (
+    SELECT 1 FROM ticket_sources WHERE name = 'Email' AND deleted_at IS NULL
+);
+
+-- the properties given to the tickets created from the mailbox
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS category_id UUID REFERENCES ticket_categories;
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS priority_id UUID REFERENCES ticket_priorities;
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS agreement_id UUID REFERENCES ticket_slas;
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS source_id UUID REFERENCES ticket_sources;
+
+UPDATE inbound_emails
+SET source_id = (SELECT source_id FROM ticket_sources WHERE name = 'Email' AND deleted_at IS NULL LIMIT 1)
+WHERE source_id IS NULL;
+
+ALTER TABLE tickets ADD COLUMN IF NOT EXISTS contact_id UUID REFERENCES contacts;
+
+-- the mails of a ticket, message_id is the Message-ID header
+CREATE TABLE IF NOT EXISTS ticket_emails(
+    ticket_email_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID NOT NULL REFERENCES tickets,
+    note_id UUID REFERENCES ticket_notes,
+    inbound_email_id UUID REFERENCES inbound_emails,
+    direction VARCHAR(10) NOT NULL DEFAULT 'inbound' CHECK (direction IN ('inbound', 'outbound')),
+    message_id TEXT NOT NULL DEFAULT '',
+    in_reply_to TEXT NOT NULL DEFAULT '',
+    from_address TEXT NOT NULL DEFAULT '',
+    subject TEXT NOT NULL DEFAULT '',
+    html_body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ticket_emails_message_id ON ticket_emails USING btree (message_id)
+WHERE
+    (message_id <> '');
+
+CREATE INDEX IF NOT EXISTS ticket_emails_ticket ON ticket_emails USING btree (ticket_id, created_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS email_queue;
+
+ALTER TABLE ticket_notes DROP COLUMN IF EXISTS public;
+
+DROP TABLE IF EXISTS outbound_emails;
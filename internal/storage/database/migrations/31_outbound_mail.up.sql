@@ -0,0 +1,48 @@
+-- the SMTP server the notifications are sent through
+CREATE TABLE IF NOT EXISTS outbound_emails(
+    email_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(500) NOT NULL DEFAULT '',
+    address VARCHAR(100) NOT NULL DEFAULT '',
+    email_user VARCHAR(100) NOT NULL DEFAULT '',
+    email_secret VARCHAR(100) NOT NULL DEFAULT '',
+    port INT NOT NULL DEFAULT 587,
+    secured bool NOT NULL DEFAULT FALSE,
+    from_address VARCHAR(100) NOT NULL DEFAULT '',
+    from_name VARCHAR(100) NOT NULL DEFAULT '',
+    is_primary bool NOT NULL DEFAULT FALSE,
+    last_sent TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS outbound_emails_unique ON outbound_emails USING btree (address, email_user, port, from_address)
+WHERE
+    (deleted_at IS NULL);
+
+-- notes are internal unless they are replies to the customer
+ALTER TABLE ticket_notes ADD COLUMN IF NOT EXISTS public BOOL NOT NULL DEFAULT FALSE;
+
+-- the mails waiting to be sent, failed mails are tried again at next_attempt_at
+CREATE TABLE IF NOT EXISTS email_queue(
+    queue_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ticket_id UUID REFERENCES tickets,
+    event VARCHAR(30) NOT NULL DEFAULT '',
+    to_address TEXT NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    message_id TEXT NOT NULL DEFAULT '',
+    in_reply_to TEXT NOT NULL DEFAULT '',
+    refs TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS email_queue_pending ON email_queue USING btree (next_attempt_at)
+WHERE
+    (sent_at IS NULL);
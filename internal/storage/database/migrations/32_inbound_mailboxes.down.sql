@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS inbound_emails_name;
+DROP INDEX IF EXISTS inbound_emails_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS inbound_emails_unique ON inbound_emails USING btree (address, email_user, email_secret, port, mailbox)
+WHERE
+    (deleted_at IS NULL);
+
+-- email_secret stays TEXT, the encrypted secrets do not fit the old column
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS active;
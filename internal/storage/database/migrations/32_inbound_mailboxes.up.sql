@@ -0,0 +1,13 @@
+-- every active mailbox is polled, the secrets are encrypted by the server
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS active BOOL NOT NULL DEFAULT TRUE;
+ALTER TABLE inbound_emails ALTER COLUMN email_secret TYPE TEXT;
+
+DROP INDEX IF EXISTS inbound_emails_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS inbound_emails_unique ON inbound_emails USING btree (address, email_user, port, mailbox)
+WHERE
+    (deleted_at IS NULL);
+
+CREATE UNIQUE INDEX IF NOT EXISTS inbound_emails_name ON inbound_emails USING btree (name)
+WHERE
+    (deleted_at IS NULL AND name <> '');
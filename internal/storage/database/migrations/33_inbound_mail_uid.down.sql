@@ -0,0 +1,5 @@
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS messages_processed;
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS last_error_at;
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS last_error;
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS last_uid;
+ALTER TABLE inbound_emails DROP COLUMN IF EXISTS uid_validity;
@@ -0,0 +1,8 @@
+-- the mails are tracked by UID, sequence numbers shift when mails are expunged
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS uid_validity BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS last_uid BIGINT NOT NULL DEFAULT 0;
+
+-- the health of the mailbox
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS last_error_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE inbound_emails ADD COLUMN IF NOT EXISTS messages_processed BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS ticket_notes_files_note;
+DROP INDEX IF EXISTS ticket_notes_files_ticket;
+ALTER TABLE ticket_notes_files ALTER COLUMN size TYPE INT;
+ALTER TABLE ticket_notes_files DROP COLUMN IF EXISTS inline;
+ALTER TABLE ticket_notes_files DROP COLUMN IF EXISTS content_id;
+ALTER TABLE ticket_notes_files DROP COLUMN IF EXISTS storage_key;
+ALTER TABLE ticket_notes_files DROP COLUMN IF EXISTS ticket_id;
@@ -0,0 +1,13 @@
+-- the files of a ticket created from a mail have no note
+ALTER TABLE ticket_notes_files ADD COLUMN IF NOT EXISTS ticket_id UUID REFERENCES tickets;
+UPDATE ticket_notes_files f SET ticket_id = n.ticket_id FROM ticket_notes n WHERE f.note_id = n.note_id AND f.ticket_id IS NULL;
+
+-- where the content is kept in the file store
+ALTER TABLE ticket_notes_files ADD COLUMN IF NOT EXISTS storage_key TEXT NOT NULL DEFAULT '';
+-- the inline images are shown in the html body through their Content-ID
+ALTER TABLE ticket_notes_files ADD COLUMN IF NOT EXISTS content_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE ticket_notes_files ADD COLUMN IF NOT EXISTS inline BOOL NOT NULL DEFAULT FALSE;
+ALTER TABLE ticket_notes_files ALTER COLUMN size TYPE BIGINT;
+
+CREATE INDEX IF NOT EXISTS ticket_notes_files_ticket ON ticket_notes_files (ticket_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS ticket_notes_files_note ON ticket_notes_files (note_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS rotated_refresh_tokens_user;
+DROP TABLE IF EXISTS rotated_refresh_tokens;
@@ -0,0 +1,10 @@
+-- the refresh tokens replaced by a newer one, presenting one again means it was stolen
+CREATE TABLE IF NOT EXISTS rotated_refresh_tokens(
+	token_hash TEXT PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+	device_id TEXT NOT NULL,
+	expires_at INTEGER NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS rotated_refresh_tokens_user ON rotated_refresh_tokens (user_id);
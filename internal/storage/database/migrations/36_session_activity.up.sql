@@ -0,0 +1,4 @@
+-- shown to the users in the list of the devices they are signed in on
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip_address TEXT NOT NULL DEFAULT '';
+UPDATE sessions SET last_used_at = updated_at WHERE last_used_at IS NULL;
@@ -0,0 +1,15 @@
+-- the new accounts sign in once their email is verified, the existing ones already could
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- the single use tokens mailed to reset a password or verify an email, only their hashes are kept
+CREATE TABLE IF NOT EXISTS account_tokens(
+	token_hash TEXT PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+	purpose VARCHAR(30) NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS account_tokens_user ON account_tokens (user_id, purpose) WHERE used_at IS NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS mfa_requirement_role;
+DROP INDEX IF EXISTS mfa_requirement_user_type;
+DROP TABLE IF EXISTS mfa_requirements;
+DROP INDEX IF EXISTS mfa_recovery_codes_user;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
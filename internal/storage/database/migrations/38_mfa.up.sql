@@ -0,0 +1,34 @@
+-- the authenticator app of a user, the secret is encrypted and the MFA is on once enabled_at is set
+CREATE TABLE IF NOT EXISTS user_mfa(
+	user_id UUID PRIMARY KEY REFERENCES users ON DELETE CASCADE,
+	totp_secret TEXT NOT NULL,
+	enabled_at TIMESTAMP WITH TIME ZONE,
+	-- the time step of the last code accepted, the codes of the same step or before are refused
+	last_used_step BIGINT NOT NULL DEFAULT 0,
+	failed_attempts INTEGER NOT NULL DEFAULT 0,
+	last_failed_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- the single use codes to sign in without the app, only their hashes are kept
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes(
+	code_hash TEXT PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users ON DELETE CASCADE,
+	used_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS mfa_recovery_codes_user ON mfa_recovery_codes (user_id) WHERE used_at IS NULL;
+
+-- the user types and roles that have to sign in with MFA
+CREATE TABLE IF NOT EXISTS mfa_requirements(
+	requirement_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+	user_type TEXT,
+	role_id UUID REFERENCES roles ON DELETE CASCADE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	CONSTRAINT mfa_requirement_subject CHECK ((user_type IS NULL) <> (role_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS mfa_requirement_user_type ON mfa_requirements (user_type) WHERE user_type IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS mfa_requirement_role ON mfa_requirements (role_id) WHERE role_id IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE email_queue DROP COLUMN IF EXISTS expires_at;
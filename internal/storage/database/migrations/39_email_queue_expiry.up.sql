@@ -0,0 +1,4 @@
+-- the mails carrying an account token are not kept once sent, nor sent once the token expired
+ALTER TABLE email_queue ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+UPDATE email_queue SET body = '' WHERE event IN ('password_reset', 'verify_email');
+DELETE FROM email_queue WHERE sent_at IS NULL AND event IN ('password_reset', 'verify_email');
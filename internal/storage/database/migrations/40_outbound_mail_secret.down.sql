@@ -0,0 +1 @@
+-- email_secret stays TEXT, the encrypted secrets do not fit the old column
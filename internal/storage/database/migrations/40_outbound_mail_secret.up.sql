@@ -0,0 +1,2 @@
+-- the encrypted secrets do not fit the old column
+ALTER TABLE outbound_emails ALTER COLUMN email_secret TYPE TEXT;
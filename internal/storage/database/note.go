@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
 	"github.com/pkg/errors"
@@ -28,20 +29,35 @@ type NoteDB interface {
 
 const createNoteQuery = `
 		INSERT INTO ticket_notes (
-			note, ticket_id, created_by
+			note, ticket_id, created_by, public
 			)
 			VALUES (
-				 :note, :ticket_id, :created_by
+				 :note, :ticket_id, :created_by, COALESCE(:public, FALSE)
 				)
 				RETURNING note_id`
 
+// CreateNote - creates the note, its activity is recorded in the same transaction
 func (d *database) CreateNote(ctx context.Context, userNote *model.Note) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
 
-	rows, err := d.conn.NamedQueryContext(ctx, createNoteQuery, userNote)
-	if rows != nil {
-		defer rows.Close()
+	if err = insertNote(ctx, tx, userNote); err != nil {
+		return
 	}
+	return tx.Commit()
+}
 
+// insertNote - creates the note in the transaction and records that it was added to the ticket
+func insertNote(ctx context.Context, tx *sqlx.Tx, userNote *model.Note) error {
+
+	rows, err := sqlx.NamedQueryContext(ctx, tx, createNoteQuery, userNote)
 	if err != nil {
 		if pqError, ok := err.(*pq.Error); ok {
 			logrus.WithFields(logrus.Fields{
@@ -50,18 +66,27 @@ func (d *database) CreateNote(ctx context.Context, userNote *model.Note) (err er
 				"PQ Column":      pqError.Column,
 			}).Info()
 		}
-		return
+		return errors.Wrap(err, "could not create note")
 	}
 
-	rows.Next()
-	if err := rows.Scan(&userNote.ID); err != nil {
-		err = errors.Wrap(err, "Could not get the Note ID")
+	if !rows.Next() {
+		rows.Close()
+		return errors.New("Could not get the Note ID")
 	}
-	return
+	err = rows.Scan(&userNote.ID)
+	rows.Close()
+	if err != nil {
+		return errors.Wrap(err, "Could not get the Note ID")
+	}
+
+	activity := model.NewActivity(userNote.TicketID, userNote.UserID, model.ActivityNoteAdded)
+	activity.NoteID = userNote.ID
+	return createTicketActivities(ctx, tx, activity)
 }
 
 const getNoteByIDQuery = `
-	SELECT note_id, note, ticket_id, created_by, created_at, updated_at, deleted_at
+	SELECT note_id, note, ticket_id, public, COALESCE(created_by::text,'') AS created_by, created_at, updated_at, deleted_at,
+	COALESCE((SELECT te.from_address FROM ticket_emails te WHERE te.note_id = ticket_notes.note_id AND te.direction = 'inbound' LIMIT 1), '') AS from_address
 	from ticket_notes
 	WHERE note_id = $1
 	AND deleted_at IS NULL`
@@ -116,7 +141,8 @@ func (d *database) DeleteNote(ctx context.Context, noteID *model.NoteID) (bool,
 }
 
 const listAllNotesQuery = `
-	SELECT note_id, note, ticket_id, created_by, created_at, updated_at, deleted_at
+	SELECT note_id, note, ticket_id, public, COALESCE(created_by::text,'') AS created_by, created_at, updated_at, deleted_at,
+	COALESCE((SELECT te.from_address FROM ticket_emails te WHERE te.note_id = ticket_notes.note_id AND te.direction = 'inbound' LIMIT 1), '') AS from_address
 	from ticket_notes
 	WHERE deleted_at is NULL;
 `
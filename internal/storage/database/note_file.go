@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+)
+
+// NoteFileDB - holds the files of the tickets and of their notes
+type NoteFileDB interface {
+	CreateNoteWithFiles(ctx context.Context, note *model.Note, files []*model.NoteFile) error
+	GetTicketFile(ctx context.Context, ticketID *model.TicketID, fileID *model.NoteFileID) (*model.NoteFile, error)
+	ListTicketFiles(ctx context.Context, ticketID *model.TicketID) ([]*model.NoteFile, error)
+	DeleteTicketFile(ctx context.Context, ticketID *model.TicketID, fileID *model.NoteFileID) (bool, error)
+}
+
+const createNoteFileQuery = `
+	INSERT INTO ticket_notes_files (
+		filename, mime_type, size, ticket_id, note_id, storage_key, content_id, inline, created_by
+	)
+	VALUES (
+		:filename, :mime_type, :size, :ticket_id, NULLIF(:note_id, '')::uuid, :storage_key, :content_id, :inline,
+		NULLIF(:created_by, '')::uuid
+	)
+	RETURNING file_id, created_at, updated_at`
+
+// insertNoteFiles - records the files within the transaction, their contents are already in the file store
+func insertNoteFiles(ctx context.Context, tx *sqlx.Tx, files []*model.NoteFile) error {
+
+	if len(files) == 0 {
+		return nil
+	}
+	stmt, err := tx.PrepareNamedContext(ctx, createNoteFileQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare ticket file")
+	}
+	defer stmt.Close()
+
+	for _, file := range files {
+		if err := stmt.QueryRowxContext(ctx, file).Scan(&file.ID, &file.CreatedAt, &file.UpdatedAt); err != nil {
+			return errors.Wrap(err, "could not create ticket file")
+		}
+	}
+	return nil
+}
+
+// CreateNoteWithFiles - creates a note, its activity and records its files in one transaction
+func (d *database) CreateNoteWithFiles(ctx context.Context, note *model.Note, files []*model.NoteFile) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = insertNote(ctx, tx, note); err != nil {
+		return
+	}
+
+	for _, file := range files {
+		file.TicketID = note.TicketID
+		file.NoteID = note.ID
+	}
+	if err = insertNoteFiles(ctx, tx, files); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+const noteFileColumns = `
+	SELECT file_id, filename, mime_type, size, ticket_id, COALESCE(note_id::text, '') AS note_id,
+	storage_key, content_id, inline, COALESCE(created_by::text, '') AS created_by,
+	created_at, updated_at, deleted_at
+	FROM ticket_notes_files`
+
+const getTicketFileQuery = noteFileColumns + `
+	WHERE ticket_id = $1
+	AND file_id = $2
+	AND deleted_at IS NULL`
+
+// GetTicketFile - returns a file of the ticket, of the ticket itself or of one of its notes
+func (d *database) GetTicketFile(ctx context.Context, ticketID *model.TicketID, fileID *model.NoteFileID) (*model.NoteFile, error) {
+
+	var file model.NoteFile
+	if err := d.conn.GetContext(ctx, &file, getTicketFileQuery, ticketID, fileID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apiErr.ErrNotExist("File")
+		}
+		if pqError, ok := err.(*pq.Error); ok && pqError.Code.Name() == "invalid_text_representation" {
+			return nil, apiErr.ErrNotExist("File")
+		}
+		return nil, errors.Wrap(err, "could not get the ticket file")
+	}
+	return &file, nil
+}
+
+const listTicketFilesQuery = noteFileColumns + `
+	WHERE ticket_id = $1
+	AND deleted_at IS NULL
+	ORDER BY created_at ASC`
+
+// ListTicketFiles - returns the files of a ticket and of its notes
+func (d *database) ListTicketFiles(ctx context.Context, ticketID *model.TicketID) ([]*model.NoteFile, error) {
+
+	files := []*model.NoteFile{}
+	if err := d.conn.SelectContext(ctx, &files, listTicketFilesQuery, ticketID); err != nil {
+		return nil, errors.Wrap(err, "could not get the ticket files")
+	}
+	return files, nil
+}
+
+const deleteTicketFileQuery = `
+	UPDATE ticket_notes_files
+	SET deleted_at = NOW()
+	WHERE ticket_id = $1
+	AND file_id = $2
+	AND deleted_at IS NULL`
+
+// DeleteTicketFile - removes a file, the record is kept for the history of the ticket
+func (d *database) DeleteTicketFile(ctx context.Context, ticketID *model.TicketID, fileID *model.NoteFileID) (bool, error) {
+
+	result, err := d.conn.ExecContext(ctx, deleteTicketFileQuery, ticketID, fileID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/secret"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// OutboundMailDB - holds the settings of the SMTP server the notifications are sent through
+type OutboundMailDB interface {
+	CreateOutboundMail(ctx context.Context, outboundMail *model.OutboundMail) error
+	GetOutboundMail(ctx context.Context) (*model.OutboundMail, error)
+	GetOutboundMailByID(ctx context.Context, outboundMailID *model.OutboundMailID) (*model.OutboundMail, error)
+	ListOutboundMails(ctx context.Context) ([]*model.OutboundMail, error)
+	UpdateOutboundMail(ctx context.Context, outboundMail *model.OutboundMail) error
+	UpdateOutboundMailStatus(ctx context.Context, outboundMail *model.OutboundMail) error
+	DeleteOutboundMail(ctx context.Context, outboundMailID *model.OutboundMailID) (bool, error)
+}
+
+const createOutboundMailQuery = `
+	INSERT INTO outbound_emails (
+		"name", status, address, email_user, email_secret, port, secured,
+		from_address, from_name, is_primary, created_by
+	)
+	VALUES (
+		:name, :status, :address, :email_user, :email_secret, :port, :secured,
+		:from_address, :from_name, :is_primary, NULLIF(:created_by, '')::uuid
+	)
+	RETURNING email_id`
+
+// CreateOutboundMail - saves a mail server, the secret is encrypted
+func (d *database) CreateOutboundMail(ctx context.Context, outboundMail *model.OutboundMail) error {
+
+	stored, err := d.sealOutboundMail(outboundMail)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := d.conn.PrepareNamedContext(ctx, createOutboundMailQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare outbound mail")
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowxContext(ctx, stored).Scan(&outboundMail.ID); err != nil {
+		return outboundMailError(err)
+	}
+	return nil
+}
+
+const outboundMailColumns = `
+	SELECT
+	email_id, "name", status, address, email_user,
+	email_secret, port, secured, from_address, from_name, is_primary,
+	last_sent, COALESCE(created_by::text, '') AS created_by,
+	created_at, updated_at, deleted_at
+	FROM
+	outbound_emails
+	WHERE deleted_at IS NULL`
+
+const getOutboundMailQuery = outboundMailColumns + `
+	ORDER BY is_primary DESC, created_at ASC
+	LIMIT 1`
+
+// GetOutboundMail - returns the primary SMTP server with its secret decrypted
+func (d *database) GetOutboundMail(ctx context.Context) (*model.OutboundMail, error) {
+
+	var outboundMail model.OutboundMail
+	if err := d.conn.GetContext(ctx, &outboundMail, getOutboundMailQuery); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apiErr.ErrNotExist("Outbound mail")
+		}
+		if pqError, ok := err.(*pq.Error); ok {
+			logrus.WithFields(logrus.Fields{
+				"PQ Code.Name":   pqError.Code.Name(),
+				"PQ Constraints": pqError.Constraint,
+				"PQ Column":      pqError.Column,
+			}).Info()
+		}
+		return nil, err
+	}
+
+	if err := d.openOutboundMail(&outboundMail); err != nil {
+		return nil, err
+	}
+	return &outboundMail, nil
+}
+
+const getOutboundMailByIDQuery = outboundMailColumns + `
+	AND email_id = $1`
+
+// GetOutboundMailByID - returns a mail server with its secret decrypted
+func (d *database) GetOutboundMailByID(ctx context.Context, outboundMailID *model.OutboundMailID) (*model.OutboundMail, error) {
+
+	var outboundMail model.OutboundMail
+	if err := d.conn.GetContext(ctx, &outboundMail, getOutboundMailByIDQuery, outboundMailID); err != nil {
+		if pqError, ok := err.(*pq.Error); ok {
+			logrus.WithFields(logrus.Fields{
+				"PQ Code.Name":   pqError.Code.Name(),
+				"PQ Constraints": pqError.Constraint,
+				"PQ Column":      pqError.Column,
+			}).Info()
+		}
+
+		return nil, apiErr.ErrNotExist("Outbound mail")
+	}
+
+	if err := d.openOutboundMail(&outboundMail); err != nil {
+		return nil, err
+	}
+	return &outboundMail, nil
+}
+
+const listOutboundMailsQuery = outboundMailColumns + `
+	ORDER BY is_primary DESC, created_at ASC`
+
+// ListOutboundMails - returns all the mail servers, the primary one first
+func (d *database) ListOutboundMails(ctx context.Context) ([]*model.OutboundMail, error) {
+
+	outboundMails := []*model.OutboundMail{}
+	if err := d.conn.SelectContext(ctx, &outboundMails, listOutboundMailsQuery); err != nil {
+		return nil, errors.Wrap(err, "could not get outbound mails")
+	}
+	for _, outboundMail := range outboundMails {
+		if err := d.openOutboundMail(outboundMail); err != nil {
+			return nil, err
+		}
+	}
+	return outboundMails, nil
+}
+
+const updateOutboundMailQuery = `
+	UPDATE outbound_emails
+	SET
+	name=:name,
+	status=:status,
+	address=:address,
+	email_user=:email_user,
+	email_secret=:email_secret,
+	port=:port,
+	secured=:secured,
+	from_address=:from_address,
+	from_name=:from_name,
+	is_primary=:is_primary,
+	updated_at=NOW()
+	WHERE email_id = :email_id
+	AND deleted_at is null
+`
+
+// UpdateOutboundMail - saves the settings of a mail server, the secret is encrypted again
+func (d *database) UpdateOutboundMail(ctx context.Context, outboundMail *model.OutboundMail) error {
+
+	stored, err := d.sealOutboundMail(outboundMail)
+	if err != nil {
+		return err
+	}
+
+	result, err := d.conn.NamedExecContext(ctx, updateOutboundMailQuery, stored)
+	if err != nil {
+		return outboundMailError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return apiErr.ErrNotFound
+	}
+	return nil
+}
+
+const updateOutboundMailStatusQuery = `
+	UPDATE outbound_emails
+	SET
+	status=:status,
+	last_sent=:last_sent,
+	updated_at=NOW()
+	WHERE email_id = :email_id
+	AND deleted_at is null
+`
+
+// UpdateOutboundMailStatus - saves the connection status and the time of the last mail sent
+func (d *database) UpdateOutboundMailStatus(ctx context.Context, outboundMail *model.OutboundMail) error {
+
+	result, err := d.conn.NamedExecContext(ctx, updateOutboundMailStatusQuery, outboundMail)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return apiErr.ErrNotFound
+	}
+	return nil
+}
+
+const deleteOutboundMailQuery = `
+	UPDATE outbound_emails
+	SET deleted_at = NOW()
+	WHERE email_id = $1
+	AND deleted_at IS NULL`
+
+// DeleteOutboundMail - removes a mail server, the queued mails wait for the next one
+func (d *database) DeleteOutboundMail(ctx context.Context, outboundMailID *model.OutboundMailID) (bool, error) {
+
+	result, err := d.conn.ExecContext(ctx, deleteOutboundMailQuery, outboundMailID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+	return true, nil
+}
+
+// sealOutboundMail - a copy of the mail server with its secret encrypted
+func (d *database) sealOutboundMail(outboundMail *model.OutboundMail) (*model.OutboundMail, error) {
+
+	stored := *outboundMail
+	if stored.EmailSecret == nil || len(*stored.EmailSecret) == 0 {
+		return &stored, nil
+	}
+	sealed, err := d.secrets.Seal(*stored.EmailSecret)
+	if err != nil {
+		if err == secret.ErrNoKey {
+			return nil, apiErr.ErrNoSecretKey
+		}
+		return nil, errors.Wrap(err, "could not encrypt the secret")
+	}
+	stored.EmailSecret = &sealed
+	return &stored, nil
+}
+
+// openOutboundMail - decrypts the secret of a mail server
+func (d *database) openOutboundMail(outboundMail *model.OutboundMail) error {
+
+	if outboundMail.EmailSecret == nil {
+		return nil
+	}
+	plain, err := d.secrets.Open(*outboundMail.EmailSecret)
+	if err != nil {
+		if err == secret.ErrNoKey {
+			return apiErr.ErrNoSecretKey
+		}
+		return errors.Wrap(err, "could not decrypt the secret")
+	}
+	outboundMail.EmailSecret = &plain
+	return nil
+}
+
+// outboundMailError - maps the constraints of the mail servers to their errors
+func outboundMailError(err error) error {
+
+	if pqError, ok := err.(*pq.Error); ok {
+		if pqError.Code.Name() == UniqueViolation && pqError.Constraint == "outbound_emails_unique" {
+			return apiErr.ErrOutboundMailExists
+		}
+		logrus.WithFields(logrus.Fields{
+			"PQ Code.Name":   pqError.Code.Name(),
+			"PQ Constraints": pqError.Constraint,
+			"PQ Column":      pqError.Column,
+		}).Info()
+	}
+	return errors.Wrap(err, "could not save outbound mail")
+}
@@ -2,30 +2,46 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
 )
 
 // SessionDB - holds all the methods for storing all the sessios
 type SessionDB interface {
 	SaveRefreshToken(ctx context.Context, session *model.Session) error
 	GetSession(ctx context.Context, session *model.Session) (*model.Session, error)
+	RotateRefreshToken(ctx context.Context, session *model.Session, refreshToken string) error
+	IsRotatedRefreshToken(ctx context.Context, userID *model.UserID, refreshToken string) (bool, error)
+	RevokeUserSessions(ctx context.Context, userID *model.UserID) error
+	ListUserSessions(ctx context.Context, userID *model.UserID) ([]*model.Session, error)
+	DeleteSession(ctx context.Context, userID *model.UserID, deviceID *model.DeviceID) (bool, error)
 }
 
 const insertOrUpdateSession = `
 	INSERT INTO public.sessions
-	(user_id, device_id, refresh_token, expires_at)
-	VALUES(:user_id,:device_id,:refresh_token,:expires_at)
+	(user_id, device_id, refresh_token, expires_at, ip_address, last_used_at)
+	VALUES(:user_id,:device_id,:refresh_token,:expires_at,:ip_address,NOW())
 	ON CONFLICT (user_id, device_id)
-	DO 
-		UPDATE 
+	DO
+		UPDATE
 			SET refresh_token = :refresh_token ,
-				expires_at = :expires_at
+				expires_at = :expires_at,
+				ip_address = :ip_address,
+				-- signing in again after a logout starts a new session
+				created_at = CASE WHEN sessions.deleted_at IS NULL THEN sessions.created_at ELSE NOW() END,
+				last_used_at = NOW(),
+				updated_at = NOW(),
+				deleted_at = NULL
 
 `
 
 func (d *database) SaveRefreshToken(ctx context.Context, session *model.Session) error {
-	if _, err := d.conn.NamedQueryContext(ctx, insertOrUpdateSession, session); err != nil {
+	if _, err := d.conn.NamedExecContext(ctx, insertOrUpdateSession, session); err != nil {
 		return err
 	}
 	return nil
@@ -38,6 +54,7 @@ const getSessionQuery = `
 		AND device_id = $2
 		AND refresh_token = $3
 		AND to_timestamp(expires_at) > NOW()
+		AND deleted_at IS NULL
 `
 
 func (d *database) GetSession(ctx context.Context, data *model.Session) (*model.Session, error) {
@@ -49,3 +66,139 @@ func (d *database) GetSession(ctx context.Context, data *model.Session) (*model.
 
 	return &session, nil
 }
+
+const rotateRefreshTokenQuery = `
+	UPDATE sessions
+	SET refresh_token = $4,
+		expires_at = $5,
+		ip_address = $6,
+		last_used_at = NOW(),
+		updated_at = NOW()
+	WHERE user_id = $1
+		AND device_id = $2
+		AND refresh_token = $3
+		AND deleted_at IS NULL
+	RETURNING expires_at
+`
+
+const insertRotatedRefreshTokenQuery = `
+	INSERT INTO rotated_refresh_tokens
+	(token_hash, user_id, device_id, expires_at)
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT (token_hash) DO NOTHING
+`
+
+const deleteExpiredRotatedTokensQuery = `
+	DELETE FROM rotated_refresh_tokens
+	WHERE user_id = $1 AND to_timestamp(expires_at) <= NOW()
+`
+
+// RotateRefreshToken - replaces the refresh token of the session by the new one, the replaced token
+// is kept to recognize it if it is presented again
+func (d *database) RotateRefreshToken(ctx context.Context, session *model.Session, refreshToken string) (err error) {
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// only one of the requests presenting the same token replaces it
+	var expiresAt int64
+	if err = tx.QueryRowxContext(ctx, rotateRefreshTokenQuery, session.UserID, session.DeviceID, refreshToken, session.RefreshToken, session.ExpiresAt, session.IPAddress).Scan(&expiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			err = apiErr.ErrInvalidRefreshToken
+			return
+		}
+		return errors.Wrap(err, "could not rotate the refresh token")
+	}
+
+	if _, err = tx.ExecContext(ctx, deleteExpiredRotatedTokensQuery, session.UserID); err != nil {
+		return errors.Wrap(err, "could not remove the expired refresh tokens")
+	}
+	// the old token is recognized until the time it would have expired
+	if _, err = tx.ExecContext(ctx, insertRotatedRefreshTokenQuery, tokenHash(refreshToken), session.UserID, session.DeviceID, expiresAt); err != nil {
+		return errors.Wrap(err, "could not keep the rotated refresh token")
+	}
+
+	return tx.Commit()
+}
+
+const isRotatedRefreshTokenQuery = `
+	SELECT EXISTS(
+		SELECT 1 FROM rotated_refresh_tokens
+		WHERE token_hash = $1 AND user_id = $2
+	)
+`
+
+// IsRotatedRefreshToken - checks if the token was replaced by a newer one
+func (d *database) IsRotatedRefreshToken(ctx context.Context, userID *model.UserID, refreshToken string) (bool, error) {
+	var rotated bool
+	if err := d.conn.GetContext(ctx, &rotated, isRotatedRefreshTokenQuery, tokenHash(refreshToken), userID); err != nil {
+		return false, err
+	}
+	return rotated, nil
+}
+
+const revokeUserSessionsQuery = `
+	UPDATE sessions
+	SET deleted_at = NOW(),
+		updated_at = NOW()
+	WHERE user_id = $1 AND deleted_at IS NULL
+`
+
+// RevokeUserSessions - ends the sessions of the user on all the devices
+func (d *database) RevokeUserSessions(ctx context.Context, userID *model.UserID) error {
+	if _, err := d.conn.ExecContext(ctx, revokeUserSessionsQuery, userID); err != nil {
+		return errors.Wrap(err, "could not revoke the sessions")
+	}
+	return nil
+}
+
+const listUserSessionsQuery = `
+	SELECT user_id, device_id, expires_at, ip_address, created_at, last_used_at
+	FROM sessions
+	WHERE user_id = $1
+		AND to_timestamp(expires_at) > NOW()
+		AND deleted_at IS NULL
+	ORDER BY last_used_at DESC NULLS LAST, created_at DESC
+`
+
+// ListUserSessions - the devices the user is signed in on
+func (d *database) ListUserSessions(ctx context.Context, userID *model.UserID) ([]*model.Session, error) {
+	sessions := []*model.Session{}
+	if err := d.conn.SelectContext(ctx, &sessions, listUserSessionsQuery, userID); err != nil {
+		return nil, errors.Wrap(err, "could not list the sessions")
+	}
+	return sessions, nil
+}
+
+const deleteSessionQuery = `
+	UPDATE sessions
+	SET deleted_at = NOW(),
+		updated_at = NOW()
+	WHERE user_id = $1 AND device_id = $2 AND deleted_at IS NULL
+`
+
+// DeleteSession - ends the session of the user on the device, its refresh token can no longer be used
+func (d *database) DeleteSession(ctx context.Context, userID *model.UserID, deviceID *model.DeviceID) (bool, error) {
+	result, err := d.conn.ExecContext(ctx, deleteSessionQuery, userID, deviceID)
+	if err != nil {
+		return false, errors.Wrap(err, "could not delete the session")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// tokenHash - the tokens given to the users are only kept as hashes
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,351 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/lib/sla"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SLAEscalationDB - holds the methods for the escalation steps and the SLA monitor
+type SLAEscalationDB interface {
+	CreateEscalation(ctx context.Context, escalation *model.Escalation) error
+	GetEscalationByID(ctx context.Context, escalationID *model.EscalationID) (*model.Escalation, error)
+	UpdateEscalation(ctx context.Context, escalation *model.Escalation) error
+	ListAllEscalations(ctx context.Context) ([]*model.Escalation, error)
+	DeleteEscalation(ctx context.Context, escalationID *model.EscalationID) (bool, error)
+
+	ListDueEscalations(ctx context.Context, limit int) ([]*model.TicketEscalation, error)
+	EscalateTicket(ctx context.Context, ticketID model.TicketID, escalation *model.Escalation) (bool, error)
+}
+
+const createEscalationQuery = `
+	INSERT INTO sla_escalations (
+		name, level, trigger_on, after_minutes, agreement_id, priority_id, assign_to, assign_role_id, note, notify, created_by
+	)
+	VALUES (
+		:name, :level, :trigger_on, :after_minutes, NULLIF(:agreement_id, '')::uuid, NULLIF(:priority_id, '')::uuid,
+		NULLIF(:assign_to, '')::uuid, NULLIF(:assign_role_id, '')::uuid, :note, :notify, NULLIF(:created_by, '')::uuid
+	)
+	RETURNING escalation_id`
+
+func (d *database) CreateEscalation(ctx context.Context, escalation *model.Escalation) error {
+	stmt, err := d.conn.PrepareNamedContext(ctx, createEscalationQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare escalation")
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowxContext(ctx, escalation).Scan(&escalation.ID); err != nil {
+		return escalationError(err)
+	}
+	return nil
+}
+
+const escalationColumns = `
+	SELECT escalation_id, name, level, trigger_on, after_minutes,
+	COALESCE(agreement_id::text, '') AS agreement_id, COALESCE(priority_id::text, '') AS priority_id,
+	COALESCE(assign_to::text, '') AS assign_to, COALESCE(assign_role_id::text, '') AS assign_role_id,
+	note, notify, COALESCE(created_by::text, '') AS created_by, created_at, updated_at, deleted_at
+	FROM sla_escalations`
+
+const getEscalationByIDQuery = escalationColumns + `
+	WHERE escalation_id = $1
+	AND deleted_at IS NULL`
+
+func (d *database) GetEscalationByID(ctx context.Context, escalationID *model.EscalationID) (*model.Escalation, error) {
+
+	escalation := model.Escalation{}
+	if err := d.conn.GetContext(ctx, &escalation, getEscalationByIDQuery, escalationID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apiErr.ErrNotExist("Escalation")
+		}
+		return nil, errors.Wrap(err, "could not get escalation")
+	}
+	return &escalation, nil
+}
+
+const updateEscalationQuery = `
+	UPDATE sla_escalations
+	SET name = :name,
+		level = :level,
+		trigger_on = :trigger_on,
+		after_minutes = :after_minutes,
+		agreement_id = NULLIF(:agreement_id, '')::uuid,
+		priority_id = NULLIF(:priority_id, '')::uuid,
+		assign_to = NULLIF(:assign_to, '')::uuid,
+		assign_role_id = NULLIF(:assign_role_id, '')::uuid,
+		note = :note,
+		notify = :notify,
+		updated_at = NOW()
+	WHERE escalation_id = :escalation_id
+	AND deleted_at IS NULL`
+
+func (d *database) UpdateEscalation(ctx context.Context, escalation *model.Escalation) error {
+
+	result, err := d.conn.NamedExecContext(ctx, updateEscalationQuery, escalation)
+	if err != nil {
+		return escalationError(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return apiErr.ErrNotExist("Escalation")
+	}
+	return nil
+}
+
+const listAllEscalationsQuery = escalationColumns + `
+	WHERE deleted_at IS NULL
+	ORDER BY level ASC, agreement_id ASC NULLS LAST`
+
+func (d *database) ListAllEscalations(ctx context.Context) ([]*model.Escalation, error) {
+
+	escalations := []*model.Escalation{}
+	if err := d.conn.SelectContext(ctx, &escalations, listAllEscalationsQuery); err != nil {
+		return nil, errors.Wrap(err, "could not get escalations")
+	}
+	return escalations, nil
+}
+
+const deleteEscalationQuery = `
+	UPDATE sla_escalations
+	SET deleted_at = NOW()
+	WHERE escalation_id = $1
+	AND deleted_at IS NULL`
+
+func (d *database) DeleteEscalation(ctx context.Context, escalationID *model.EscalationID) (bool, error) {
+
+	result, err := d.conn.ExecContext(ctx, deleteEscalationQuery, escalationID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+	return true, nil
+}
+
+// listDueEscalationsQuery - the open and running tickets that reached the time of a step
+// they have not been escalated to, the steps of the ticket's SLA come before the global steps
+const listDueEscalationsQuery = `
+	SELECT tk.ticket_id, es.escalation_id, es.level
+	FROM sla_escalations es
+	INNER JOIN tickets tk ON es.agreement_id IS NULL OR es.agreement_id = tk.sla_id
+	WHERE es.deleted_at IS NULL
+	AND tk.deleted_at IS NULL
+	AND tk.closed_at IS NULL
+	AND tk.sla_paused_at IS NULL
+	AND (CASE es.trigger_on WHEN 'at_risk' THEN tk.sla_warning_at ELSE tk.deadline END)
+		+ es.after_minutes * INTERVAL '1 minute' <= NOW()
+	AND NOT EXISTS (
+		SELECT 1 FROM ticket_escalations te
+		WHERE te.ticket_id = tk.ticket_id
+		AND te.level = es.level
+	)
+	ORDER BY es.level ASC, es.agreement_id ASC NULLS LAST, tk.deadline ASC
+	LIMIT $1`
+
+// ListDueEscalations - returns the tickets that have to be escalated and the steps to run
+func (d *database) ListDueEscalations(ctx context.Context, limit int) ([]*model.TicketEscalation, error) {
+
+	due := []*model.TicketEscalation{}
+	if err := d.conn.SelectContext(ctx, &due, listDueEscalationsQuery, limit); err != nil {
+		return nil, errors.Wrap(err, "could not get due escalations")
+	}
+	return due, nil
+}
+
+const claimEscalationQuery = `
+	INSERT INTO ticket_escalations (ticket_id, level, escalation_id)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (ticket_id, level) DO NOTHING`
+
+const escalatedTicketQuery = `
+	SELECT ticket_id, priority_id, sla_id, COALESCE(assigned_to::text, '') AS assigned_to, first_responded_at,
+	sla_paused_minutes, closed_at, sla_paused_at, created_at
+	FROM tickets
+	WHERE ticket_id = $1
+	AND deleted_at IS NULL
+	FOR UPDATE`
+
+const escalatePriorityQuery = `
+	UPDATE tickets
+	SET priority_id = $2,
+	deadline = $3,
+	sla_warning_at = $4,
+	first_response_due = $5,
+	updated_at = NOW()
+	WHERE ticket_id = $1`
+
+// roleAgentQuery - the active agent of the role with the fewest open tickets
+const roleAgentQuery = `
+	SELECT us.user_id
+	FROM users us
+	LEFT JOIN tickets tk ON tk.assigned_to = us.user_id AND tk.closed_at IS NULL AND tk.deleted_at IS NULL
+	WHERE us.deleted_at IS NULL
+	AND us.is_active
+	AND us.user_type IN ('admin', 'agent')
+	AND (us.role_id = $1 OR EXISTS (
+		SELECT 1 FROM users_roles ur
+		WHERE ur.user_id = us.user_id
+		AND ur.role_id = $1
+		AND ur.deleted_at IS NULL
+	))
+	GROUP BY us.user_id
+	ORDER BY COUNT(tk.ticket_id) ASC, us.created_at ASC
+	LIMIT 1`
+
+const escalationAssignmentQuery = `
+	INSERT INTO ticket_assignments (ticket_id, assigned_to)
+	VALUES ($1, $2)`
+
+const escalationNoteQuery = `
+	INSERT INTO ticket_notes (note, ticket_id)
+	VALUES ($1, $2)
+	RETURNING note_id`
+
+// EscalateTicket - runs the actions of an escalation step in one transaction, the changes are made by the system.
+// It returns false when the ticket was already escalated to the level, by this server or another one,
+// or when it was closed or paused since it was listed.
+func (d *database) EscalateTicket(ctx context.Context, ticketID model.TicketID, escalation *model.Escalation) (escalated bool, err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil || !escalated {
+			tx.Rollback()
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, claimEscalationQuery, ticketID, *escalation.Level, escalation.ID)
+	if err != nil {
+		return false, errors.Wrap(err, "could not claim escalation")
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return false, nil
+	}
+
+	ticket := model.Ticket{}
+	if err = tx.GetContext(ctx, &ticket, escalatedTicketQuery, ticketID); err != nil {
+		return false, errors.Wrap(err, "could not get ticket")
+	}
+	if ticket.ClosedAt != nil || ticket.SLAPausedAt != nil {
+		return false, nil
+	}
+
+	activities := []*model.Activity{}
+	if escalation.PriorityID != model.NilPriorityID && escalation.PriorityID != ticket.PriorityID {
+		previous := ticket.PriorityID
+		ticket.PriorityID = escalation.PriorityID
+		if err = escalatedDeadlines(ctx, tx, &ticket); err != nil {
+			return false, err
+		}
+		if _, err = tx.ExecContext(ctx, escalatePriorityQuery, ticketID, ticket.PriorityID, ticket.DueDate, ticket.SLAWarningAt, ticket.FirstResponseDue); err != nil {
+			return false, errors.Wrap(err, "could not raise priority")
+		}
+		activities = append(activities, model.FieldActivity(ticketID, model.NilUserID, model.ActivityUpdated, "priority_id", string(previous), string(escalation.PriorityID)))
+	}
+
+	assignee := escalation.AssignTo
+	if escalation.AssignRoleID != model.NilRoleID {
+		if err = tx.GetContext(ctx, &assignee, roleAgentQuery, escalation.AssignRoleID); err != nil && err != sql.ErrNoRows {
+			return false, errors.Wrap(err, "could not find an agent of the role")
+		}
+		err = nil
+	}
+	if assignee != model.NilUserID && assignee != ticket.AssignedID {
+		if _, err = tx.ExecContext(ctx, assignTicketQuery, ticketID, assignee); err != nil {
+			return false, errors.Wrap(err, "could not reassign ticket")
+		}
+		if _, err = tx.ExecContext(ctx, removeTicketAssigneeQuery, ticketID); err != nil {
+			return false, errors.Wrap(err, "could not remove the previous assignee")
+		}
+		if _, err = tx.ExecContext(ctx, addTicketAssigneeQuery, ticketID, assignee, nil); err != nil {
+			return false, errors.Wrap(err, "could not add the assignee")
+		}
+		if _, err = tx.ExecContext(ctx, escalationAssignmentQuery, ticketID, assignee); err != nil {
+			return false, errors.Wrap(err, "could not record assignment")
+		}
+		activities = append(activities, model.FieldActivity(ticketID, model.NilUserID, model.ActivityAssigned, "assigned_to", string(ticket.AssignedID), string(assignee)))
+	}
+
+	activity := model.FieldActivity(ticketID, model.NilUserID, model.ActivityEscalated, "level", "", strconv.Itoa(*escalation.Level))
+	if escalation.Note != nil {
+		if err = tx.GetContext(ctx, &activity.NoteID, escalationNoteQuery, *escalation.Note, ticketID); err != nil {
+			return false, errors.Wrap(err, "could not add escalation note")
+		}
+	}
+	activities = append(activities, activity)
+	if err = createTicketActivities(ctx, tx, activities...); err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"TicketID": ticketID,
+		"Level":    *escalation.Level,
+	}).Info("Ticket escalated")
+	return true, nil
+}
+
+// escalatedDeadlines - sets the deadlines of the raised priority, counted from when the ticket was opened.
+// The ticket is not paused, the time it was paused is added back as when its status changes.
+func escalatedDeadlines(ctx context.Context, tx *sqlx.Tx, ticket *model.Ticket) error {
+
+	target, err := getSLATarget(ctx, tx, &ticket.SLAID, &ticket.PriorityID)
+	if err != nil {
+		return err
+	}
+	calendar, err := sla.LoadCalendar(ctx, txCalendars{tx}, target.CalendarID)
+	if err != nil {
+		return err
+	}
+	sla.Apply(ticket, *ticket.CreatedAt, target, calendar)
+	if ticket.SLAPausedMinutes != nil {
+		sla.Extend(ticket, time.Duration(*ticket.SLAPausedMinutes)*time.Minute, calendar)
+	}
+	return nil
+}
+
+// escalationError - maps the constraint errors of the escalation steps
+func escalationError(err error) error {
+
+	if pqError, ok := err.(*pq.Error); ok {
+		switch pqError.Code.Name() {
+		case UniqueViolation:
+			if pqError.Constraint == "sla_escalations_level" {
+				return apiErr.ErrEscalationExists
+			}
+		case "foreign_key_violation":
+			switch pqError.Constraint {
+			case "sla_escalations_agreement_id_fkey":
+				return apiErr.ErrNotExist("SLA")
+			case "sla_escalations_priority_id_fkey":
+				return apiErr.ErrNotExist("Priority")
+			case "sla_escalations_assign_to_fkey":
+				return apiErr.ErrNotExist("User")
+			case "sla_escalations_assign_role_id_fkey":
+				return apiErr.ErrNotExist("Role")
+			}
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"PQ Code.Name":   pqError.Code.Name(),
+			"PQ Constraints": pqError.Constraint,
+			"PQ Column":      pqError.Column,
+		}).Info()
+	}
+	return errors.Wrap(err, "could not save escalation")
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+)
+
+// escalationSQL - a database/sql driver with the rows an escalation reads, the claims of the levels
+// conflict as with the unique key of ticket_escalations
+type escalationSQL struct {
+	ticket  []driver.Value // the row of escalatedTicketQuery
+	target  []driver.Value // the row of getSLATargetQuery
+	claimed map[string]bool
+
+	execs     []execCall // the statements run after the claims
+	commits   int
+	rollbacks int
+}
+
+type execCall struct {
+	query string
+	args  []driver.Value
+}
+
+func (s *escalationSQL) Connect(ctx context.Context) (driver.Conn, error) { return s, nil }
+func (s *escalationSQL) Driver() driver.Driver                            { return nil }
+
+func (s *escalationSQL) Prepare(query string) (driver.Stmt, error) {
+	return &escalationStmt{sql: s, query: query}, nil
+}
+func (s *escalationSQL) Close() error              { return nil }
+func (s *escalationSQL) Begin() (driver.Tx, error) { return s, nil }
+func (s *escalationSQL) Commit() error             { s.commits++; return nil }
+func (s *escalationSQL) Rollback() error           { s.rollbacks++; return nil }
+
+type escalationStmt struct {
+	sql   *escalationSQL
+	query string
+}
+
+func (st *escalationStmt) Close() error  { return nil }
+func (st *escalationStmt) NumInput() int { return -1 }
+
+func (st *escalationStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if st.query == claimEscalationQuery {
+		key := fmt.Sprint(args[0], args[1])
+		if st.sql.claimed[key] {
+			return driver.RowsAffected(0), nil
+		}
+		st.sql.claimed[key] = true
+		return driver.RowsAffected(1), nil
+	}
+	st.sql.execs = append(st.sql.execs, execCall{st.query, args})
+	return driver.RowsAffected(1), nil
+}
+
+func (st *escalationStmt) Query(args []driver.Value) (driver.Rows, error) {
+	switch st.query {
+	case escalatedTicketQuery:
+		return &escalationRows{
+			columns: []string{"ticket_id", "priority_id", "sla_id", "assigned_to", "first_responded_at",
+				"sla_paused_minutes", "closed_at", "sla_paused_at", "created_at"},
+			row: st.sql.ticket,
+		}, nil
+	case getSLATargetQuery:
+		return &escalationRows{
+			columns: []string{"target_id", "agreement_id", "priority_id", "first_response_minutes", "resolution_minutes", "calendar_id"},
+			row:     st.sql.target,
+		}, nil
+	}
+	return nil, errors.New("unexpected query")
+}
+
+type escalationRows struct {
+	columns []string
+	row     []driver.Value
+	read    bool
+}
+
+func (r *escalationRows) Columns() []string { return r.columns }
+func (r *escalationRows) Close() error      { return nil }
+
+func (r *escalationRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	copy(dest, r.row)
+	return nil
+}
+
+func TestEscalateTicketOncePerLevel(t *testing.T) {
+
+	created := time.Date(2020, 10, 5, 9, 0, 0, 0, time.UTC)
+	fake := &escalationSQL{
+		// opened at 9:00 and paused for 30 minutes since
+		ticket:  []driver.Value{"ticket", "low", "sla", "", nil, int64(30), nil, nil, created},
+		target:  []driver.Value{"target", "sla", "urgent", int64(60), int64(240), ""},
+		claimed: map[string]bool{},
+	}
+	d := &database{conn: sqlx.NewDb(sql.OpenDB(fake), "postgres")}
+
+	name, level := "Raise", 1
+	escalation := &model.Escalation{ID: "step", Name: &name, Level: &level, PriorityID: "urgent"}
+
+	escalated, err := d.EscalateTicket(context.Background(), "ticket", escalation)
+	if err != nil || !escalated {
+		t.Fatalf("EscalateTicket() = %v, %v, want the ticket escalated", escalated, err)
+	}
+
+	var update *execCall
+	for i := range fake.execs {
+		if fake.execs[i].query == escalatePriorityQuery {
+			update = &fake.execs[i]
+		}
+	}
+	if update == nil {
+		t.Fatal("the priority was not raised")
+	}
+	// the deadlines of the new priority are counted from the opening, the paused time is added back
+	want := []time.Time{
+		created.Add(270 * time.Minute), // deadline
+		created.Add(210 * time.Minute), // sla_warning_at, at 75% of the resolution time
+		created.Add(90 * time.Minute),  // first_response_due
+	}
+	if update.args[1] != "urgent" {
+		t.Errorf("priority = %v, want urgent", update.args[1])
+	}
+	for i, wantTime := range want {
+		if got, ok := update.args[i+2].(time.Time); !ok || !got.Equal(wantTime) {
+			t.Errorf("argument %d = %v, want %v", i+2, update.args[i+2], wantTime)
+		}
+	}
+
+	// the level was claimed, another server running the same step changes nothing
+	execs := len(fake.execs)
+	escalated, err = d.EscalateTicket(context.Background(), "ticket", escalation)
+	if err != nil || escalated {
+		t.Fatalf("EscalateTicket() = %v, %v, want the level already claimed", escalated, err)
+	}
+	if len(fake.execs) != execs {
+		t.Errorf("%d statements run for a claimed level", len(fake.execs)-execs)
+	}
+	if fake.commits != 1 || fake.rollbacks != 1 {
+		t.Errorf("commits = %d, rollbacks = %d, want 1 and 1", fake.commits, fake.rollbacks)
+	}
+
+	// the next level runs on its own
+	next := 2
+	escalation.Level = &next
+	escalation.PriorityID = model.NilPriorityID
+	if escalated, err := d.EscalateTicket(context.Background(), "ticket", escalation); err != nil || !escalated {
+		t.Fatalf("EscalateTicket() = %v, %v, want the next level escalated", escalated, err)
+	}
+	for _, exec := range fake.execs[execs:] {
+		if strings.Contains(exec.query, "priority_id = $2") {
+			t.Error("the priority was raised without a step raising it")
+		}
+	}
+}
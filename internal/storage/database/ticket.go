@@ -2,7 +2,12 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
 	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
@@ -16,50 +21,59 @@ var ()
 type TicketsDB interface {
 	CreateTicket(ctx context.Context, ticket *model.Ticket) (err error)
 	GetTicketByID(ctx context.Context, ticketID *model.TicketID) (*model.Ticket, error)
-	ListAllTickets(ctx context.Context) ([]*model.Ticket, error)
-	UpdateTicket(ctx context.Context, ticket *model.Ticket) error
-	DeleteTicket(ctx context.Context, ticketID *model.TicketID) (bool, error)
+	GetTicketDetails(ctx context.Context, ticketID *model.TicketID) (*model.Ticket, error)
+	ListTickets(ctx context.Context, filter *model.TicketFilter) ([]*model.Ticket, int, error)
+	UpdateTicket(ctx context.Context, ticket *model.Ticket, activities ...*model.Activity) error
+	DeleteTicket(ctx context.Context, ticketID *model.TicketID, activities ...*model.Activity) (bool, error)
 
 	/* MISC */
 	ListAllTicketNotes(ctx context.Context, ticketID *model.TicketID) ([]*model.Note, error)
-	DeleteTicketNote(ctx context.Context,ticketID *model.TicketID, noteID *model.NoteID) (bool, error)
-	CloseTicket(ctx context.Context, ticketID *model.TicketID) (bool,error)
+	DeleteTicketNote(ctx context.Context, ticketID *model.TicketID, noteID *model.NoteID, activities ...*model.Activity) (bool, error)
+	MarkFirstResponse(ctx context.Context, ticketID *model.TicketID) (bool, error)
+	CloseTicket(ctx context.Context, closingRemark *model.ClosingRemark) error
+	UpdateAndCloseTicket(ctx context.Context, ticket *model.Ticket, closingRemark *model.ClosingRemark, activities ...*model.Activity) error
+	ReopenTicket(ctx context.Context, reopening *model.Reopening) error
 	ClosingRemark(ctx context.Context, ticketID *model.TicketID) (*model.ClosingRemark, error)
 }
 
-func (d *database) GrantTicket(ctx context.Context) error {
-	if _, err := d.conn.ExecContext(ctx, "todo"); err != nil {
-		if pqError, ok := err.(*pq.Error); ok {
-			if pqError.Code.Name() == "unique_violation" && pqError.Constraint == "user_tickets_user" {
-				return nil
-			}
-			logrus.WithFields(logrus.Fields{
-				"PQ Code.Name":   pqError.Code.Name(),
-				"PQ Constraints": pqError.Constraint,
-				"PQ Column":      pqError.Column,
-			}).Info()
-		}
-		return errors.Wrap(err, "could not grant user ticket")
-	}
-	return nil
-}
-
 const createTicketQuery = `
 		INSERT INTO tickets (
-			 	subject, description, created_by, category_id, status_id, priority_id, source_id, sla_id,  deadline
+			 	subject, description, created_by, category_id, status_id, priority_id, source_id, sla_id,  deadline,
+				first_response_due, sla_warning_at, sla_paused_at, contact_id
 			)
 			VALUES (
-				:subject, :description, :created_by,  :category_id,  :status_id,  :priority_id,  :source_id, :sla_id,  :deadline
+				:subject, :description, :created_by,  :category_id,  :status_id,  :priority_id,  :source_id, :sla_id,  :deadline,
+				:first_response_due, :sla_warning_at, :sla_paused_at, NULLIF(:contact_id, '')::uuid
 				)
 				RETURNING ticket_id`
 
+// CreateTicket - creates the ticket and starts its timeline in one transaction
 func (d *database) CreateTicket(ctx context.Context, ticket *model.Ticket) (err error) {
-	rows, err := d.conn.NamedQueryContext(ctx, createTicketQuery, ticket)
-	if rows != nil {
-		defer rows.Close()
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
 	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = insertTicket(ctx, tx, ticket); err != nil {
+		return
+	}
+	return tx.Commit()
+}
 
+// insertTicket - saves the ticket and records its created activity within the transaction
+func insertTicket(ctx context.Context, tx *sqlx.Tx, ticket *model.Ticket) (err error) {
+	stmt, err := tx.PrepareNamedContext(ctx, createTicketQuery)
 	if err != nil {
+		return errors.Wrap(err, "could not prepare ticket")
+	}
+	defer stmt.Close()
+
+	if err = stmt.QueryRowxContext(ctx, ticket).Scan(&ticket.ID); err != nil {
 		if pqError, ok := err.(*pq.Error); ok {
 			if pqError.Constraint == "unique_ticket" {
 				err = apiErr.ErrTicketExists
@@ -104,17 +118,16 @@ func (d *database) CreateTicket(ctx context.Context, ticket *model.Ticket) (err
 		return apiErr.ErrCreatingTicket
 	}
 
-	rows.Next()
-	if err := rows.Scan(&ticket.ID); err != nil {
-		err = errors.Wrap(err, "Could not get the Ticket ID")
-	}
-	return
+	return createTicketActivities(ctx, tx, model.NewActivity(ticket.ID, ticket.UserID, model.ActivityCreated))
 }
 
 const getTicketByIDQuery = `
 	SELECT tk.ticket_id, tk.subject, tk.description, tk.created_by, tk.number, 
 	tk.category_id, tk.status_id, tk.priority_id, tk.source_id, tk.sla_id,  
-	tk.deadline, tk.closed_at, tk.created_at, tk.updated_at, tk.deleted_at
+	COALESCE(tk.assigned_to::text, '') AS assigned_to,
+	tk.deadline, tk.first_response_due, tk.first_responded_at, tk.sla_warning_at,
+	tk.sla_paused_at, tk.sla_paused_minutes, COALESCE(tk.contact_id::text, '') AS contact_id,
+	tk.closed_at, tk.created_at, tk.updated_at, tk.deleted_at
 	FROM tickets tk
 	WHERE tk.ticket_id = $1
 	AND tk.deleted_at IS NULL
@@ -130,21 +143,221 @@ func (d *database) GetTicketByID(ctx context.Context, ticketID *model.TicketID)
 	return &ticket, nil
 }
 
-const listAllTicketsQuery = `
-	SELECT tk.ticket_id, tk.subject, tk.description, tk.created_by,tk.number, 
+// ticketSortColumns - maps the sort fields allowed by the filter to their columns
+var ticketSortColumns = map[string]string{
+	"created_at": "tk.created_at",
+	"updated_at": "tk.updated_at",
+	"deadline":   "tk.deadline",
+	"closed_at":  "tk.closed_at",
+	"number":     "tk.number",
+	"subject":    "tk.subject",
+}
+
+// ticketDetailsColumns - the ticket columns and the columns of its properties,
+// the aliases map the joined columns into the nested structs of model.Ticket
+const ticketDetailsColumns = `
+	SELECT tk.ticket_id, tk.subject, tk.description, tk.created_by, tk.number, 
 	tk.category_id, tk.status_id, tk.priority_id, tk.source_id, tk.sla_id,  
-	tk.deadline, tk.closed_at, tk.created_at, tk.updated_at, tk.deleted_at
+	COALESCE(tk.assigned_to::text, '') AS assigned_to,
+	tk.deadline, tk.first_response_due, tk.first_responded_at, tk.sla_warning_at,
+	tk.sla_paused_at, tk.sla_paused_minutes, COALESCE(tk.contact_id::text, '') AS contact_id,
+	tk.closed_at, tk.created_at, tk.updated_at, tk.deleted_at,
+	COALESCE(ca.category_id::text, '') AS "category.category_id", ca.name AS "category.name",
+	COALESCE(pr.priority_id::text, '') AS "priority.priority_id", pr.name AS "priority.name",
+	COALESCE(st.status_id::text, '') AS "status.status_id", st.name AS "status.name",
+	COALESCE(sl.agreement_id::text, '') AS "sla.agreement_id", sl.name AS "sla.name", sl.grace_period AS "sla.grace_period",
+	COALESCE(sl.calendar_id::text, '') AS "sla.calendar_id",
+	COALESCE(so.source_id::text, '') AS "source.source_id", so.name AS "source.name",
+	COALESCE(cr.user_id::text, '') AS "creator.user_id", cr.firstname AS "creator.firstname", cr.lastname AS "creator.lastname",
+	COALESCE(asg.user_id::text, '') AS "assignee.user_id", asg.firstname AS "assignee.firstname", asg.lastname AS "assignee.lastname"
+`
+
+// ticketDetailsJoins - joins the tickets with their properties
+const ticketDetailsJoins = `
 	FROM tickets tk
-	WHERE tk.deleted_at IS NULL
+	LEFT JOIN ticket_categories ca ON ca.category_id = tk.category_id
+	LEFT JOIN ticket_priorities pr ON pr.priority_id = tk.priority_id
+	LEFT JOIN ticket_statuses st ON st.status_id = tk.status_id
+	LEFT JOIN ticket_slas sl ON sl.agreement_id = tk.sla_id
+	LEFT JOIN ticket_sources so ON so.source_id = tk.source_id
+	LEFT JOIN users cr ON cr.user_id = tk.created_by
+	LEFT JOIN users asg ON asg.user_id = tk.assigned_to
 `
 
-func (d *database) ListAllTickets(ctx context.Context) ([]*model.Ticket, error) {
+// ticketDetailsSelect - selects tickets joined with their properties
+const ticketDetailsSelect = ticketDetailsColumns + ticketDetailsJoins
+
+const getTicketDetailsQuery = ticketDetailsSelect + `
+	WHERE tk.ticket_id = $1
+	AND tk.deleted_at IS NULL
+`
+
+// GetTicketDetails - retrieves a ticket together with its properties in one query
+func (d *database) GetTicketDetails(ctx context.Context, ticketID *model.TicketID) (*model.Ticket, error) {
+	ticket := model.Ticket{}
+	if err := d.conn.GetContext(ctx, &ticket, getTicketDetailsQuery, ticketID); err != nil {
+
+		logrus.WithError(err).Error()
+		return nil, apiErr.ErrNotFound
+	}
+	tidyTicketProps(&ticket)
+	return &ticket, nil
+}
+
+// tidyTicketProps - drops the properties that were not joined and fills the user names
+func tidyTicketProps(ticket *model.Ticket) {
+
+	if ticket.Category != nil && ticket.Category.ID == model.NilCategoryID {
+		ticket.Category = nil
+	}
+	if ticket.Priority != nil && ticket.Priority.ID == model.NilPriorityID {
+		ticket.Priority = nil
+	}
+	if ticket.Status != nil && ticket.Status.ID == model.NilStatusID {
+		ticket.Status = nil
+	}
+	if ticket.SLA != nil && ticket.SLA.ID == model.NilSLAID {
+		ticket.SLA = nil
+	}
+	if ticket.Source != nil && ticket.Source.ID == model.NilSourceID {
+		ticket.Source = nil
+	}
+	ticket.CreatedBy = tidyUserName(ticket.CreatedBy)
+	ticket.AssignedTo = tidyUserName(ticket.AssignedTo)
+	ticket.ComputeSLAState(time.Now())
+}
+
+// tidyUserName - replaces the first and last names of a joined user with the full name
+func tidyUserName(user *model.User) *model.User {
+
+	if user == nil || user.ID == model.NilUserID {
+		return nil
+	}
+	if user.Firstname != nil && user.Lastname != nil {
+		name := fmt.Sprintf("%s %s", *user.Firstname, *user.Lastname)
+		user.Name = &name
+	}
+	user.Firstname = nil
+	user.Lastname = nil
+	return user
+}
+
+const listTicketsQuery = ticketDetailsSelect + `
+	WHERE %s
+	ORDER BY %s %s NULLS LAST, tk.ticket_id %s
+	LIMIT %d OFFSET %d
+`
+
+const countTicketsQuery = `
+	SELECT COUNT(*)
+	FROM tickets tk
+	WHERE %s
+`
+
+// slaPausedCondition - the open ticket is in a status that stops the SLA clock,
+// the conditions below have to match model.Ticket.ComputeSLAState
+const slaPausedCondition = `(tk.closed_at IS NULL AND tk.sla_paused_at IS NOT NULL)`
+
+// slaBreachedCondition - the ticket missed its resolution or first response target
+const slaBreachedCondition = `(NOT ` + slaPausedCondition + ` AND (COALESCE(tk.deadline < COALESCE(tk.closed_at, NOW()), FALSE)
+	OR COALESCE(tk.first_response_due < COALESCE(tk.first_responded_at, NOW()), FALSE)))`
+
+// slaAtRiskCondition - the open ticket is in the last part of its resolution time
+const slaAtRiskCondition = `(tk.closed_at IS NULL AND tk.sla_paused_at IS NULL AND COALESCE(tk.sla_warning_at <= NOW(), FALSE))`
+
+// ticketFilterClause - builds the WHERE clause and its arguments for the filter
+func ticketFilterClause(filter *model.TicketFilter) (string, []interface{}) {
+	conditions := []string{"tk.deleted_at IS NULL"}
+	args := []interface{}{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.StatusID != model.NilStatusID {
+		add("tk.status_id = $%d", filter.StatusID)
+	}
+	if filter.PriorityID != model.NilPriorityID {
+		add("tk.priority_id = $%d", filter.PriorityID)
+	}
+	if filter.CategoryID != model.NilCategoryID {
+		add("tk.category_id = $%d", filter.CategoryID)
+	}
+	if filter.SourceID != model.NilSourceID {
+		add("tk.source_id = $%d", filter.SourceID)
+	}
+	if filter.SLAID != model.NilSLAID {
+		add("tk.sla_id = $%d", filter.SLAID)
+	}
+	if filter.AssignedID != model.NilUserID {
+		add("tk.assigned_to = $%d", filter.AssignedID)
+	}
+	if filter.UserID != model.NilUserID {
+		add("tk.created_by = $%d", filter.UserID)
+	}
+	if filter.CreatedFrom != nil {
+		add("tk.created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		add("tk.created_at <= $%d", *filter.CreatedTo)
+	}
+	if filter.DeadlineFrom != nil {
+		add("tk.deadline >= $%d", *filter.DeadlineFrom)
+	}
+	if filter.DeadlineTo != nil {
+		add("tk.deadline <= $%d", *filter.DeadlineTo)
+	}
+
+	switch filter.SLAState {
+	case model.SLAStateBreached:
+		conditions = append(conditions, slaBreachedCondition)
+	case model.SLAStateAtRisk:
+		conditions = append(conditions, "NOT "+slaBreachedCondition, slaAtRiskCondition)
+	case model.SLAStateOK:
+		conditions = append(conditions, "tk.deadline IS NOT NULL", "NOT "+slaPausedCondition, "NOT "+slaBreachedCondition, "NOT "+slaAtRiskCondition)
+	case model.SLAStatePaused:
+		conditions = append(conditions, slaPausedCondition)
+	}
+
+	switch filter.State {
+	case model.TicketStateOpen:
+		conditions = append(conditions, "tk.closed_at IS NULL")
+	case model.TicketStateClosed:
+		conditions = append(conditions, "tk.closed_at IS NOT NULL")
+	}
+
+	return strings.Join(conditions, "\n\tAND "), args
+}
+
+// ListTickets - returns a page of tickets, joined with their properties, matching the filter and the total number of matches
+func (d *database) ListTickets(ctx context.Context, filter *model.TicketFilter) ([]*model.Ticket, int, error) {
+
+	where, args := ticketFilterClause(filter)
+
+	var total int
+	if err := d.conn.GetContext(ctx, &total, fmt.Sprintf(countTicketsQuery, where), args...); err != nil {
+		return nil, 0, errors.Wrap(err, "could not count tickets")
+	}
+
+	column, ok := ticketSortColumns[filter.Sort]
+	if !ok {
+		column = ticketSortColumns["created_at"]
+	}
+	order := "DESC"
+	if filter.Order == "asc" {
+		order = "ASC"
+	}
+
+	query := fmt.Sprintf(listTicketsQuery, where, column, order, order, filter.Limit, filter.Offset)
 	tickets := []*model.Ticket{}
-	if err := d.conn.SelectContext(ctx, &tickets, listAllTicketsQuery); err != nil {
-		println(err.Error())
-		return nil, err
+	if err := d.conn.SelectContext(ctx, &tickets, query, args...); err != nil {
+		return nil, 0, errors.Wrap(err, "could not list tickets")
 	}
-	return tickets, nil
+	for index := range tickets {
+		tidyTicketProps(tickets[index])
+	}
+	return tickets, total, nil
 }
 
 const updateTicketQuery = `
@@ -155,12 +368,64 @@ const updateTicketQuery = `
 		status_id = :status_id,
 		priority_id = :priority_id,
 		source_id = :source_id,
+		sla_id = :sla_id,
+		deadline = :deadline,
+		first_response_due = :first_response_due,
+		sla_warning_at = :sla_warning_at,
+		sla_paused_at = :sla_paused_at,
+		sla_paused_minutes = COALESCE(:sla_paused_minutes, sla_paused_minutes),
 		updated_at = NOW()
 		WHERE ticket_id = :ticket_id
 		AND deleted_at is null`
 
-func (d *database) UpdateTicket(ctx context.Context, ticket *model.Ticket) error {
-	result, err := d.conn.NamedExecContext(ctx, updateTicketQuery, ticket)
+// UpdateTicket - saves the ticket, the activities of the change are recorded in the same transaction
+func (d *database) UpdateTicket(ctx context.Context, ticket *model.Ticket, activities ...*model.Activity) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = updateTicket(ctx, tx, ticket); err != nil {
+		return
+	}
+	if err = createTicketActivities(ctx, tx, activities...); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// UpdateAndCloseTicket - saves the ticket and closes it in one transaction, the ticket is left as it was when it cannot be closed
+func (d *database) UpdateAndCloseTicket(ctx context.Context, ticket *model.Ticket, closingRemark *model.ClosingRemark, activities ...*model.Activity) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = updateTicket(ctx, tx, ticket); err != nil {
+		return
+	}
+	if err = createTicketActivities(ctx, tx, activities...); err != nil {
+		return
+	}
+	if err = closeTicket(ctx, tx, closingRemark); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// updateTicket - saves the ticket in the transaction
+func updateTicket(ctx context.Context, tx *sqlx.Tx, ticket *model.Ticket) error {
+	result, err := tx.NamedExecContext(ctx, updateTicketQuery, ticket)
 	if err != nil {
 		println("PQERROR => ", err.Error())
 		if pqError, ok := err.(*pq.Error); ok {
@@ -202,7 +467,6 @@ func (d *database) UpdateTicket(ctx context.Context, ticket *model.Ticket) error
 	if err != nil || rows == 0 {
 		return errors.New("Ticket Not found")
 	}
-
 	return nil
 }
 
@@ -212,8 +476,34 @@ const deleteTicketQuery = `
 	WHERE ticket_id = $1 AND deleted_at is NULL;
 	`
 
-func (d *database) DeleteTicket(ctx context.Context, ticketID *model.TicketID) (bool, error) {
-	result, err := d.conn.ExecContext(ctx, deleteTicketQuery, ticketID)
+// DeleteTicket - deletes the ticket, the activities are recorded in the same transaction when it was deleted
+func (d *database) DeleteTicket(ctx context.Context, ticketID *model.TicketID, activities ...*model.Activity) (deleted bool, err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if deleted, err = deleteRow(ctx, tx, deleteTicketQuery, ticketID); err != nil {
+		return false, err
+	}
+	if !deleted {
+		tx.Rollback()
+		return false, nil
+	}
+	if err = createTicketActivities(ctx, tx, activities...); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// deleteRow - runs the soft delete query, tells whether a row was deleted
+func deleteRow(ctx context.Context, tx *sqlx.Tx, query string, args ...interface{}) (bool, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return false, err
 	}
@@ -225,17 +515,18 @@ func (d *database) DeleteTicket(ctx context.Context, ticketID *model.TicketID) (
 	return true, nil
 }
 
-
-const closeTicketquery = `
+const markFirstResponseQuery = `
 	UPDATE tickets
-	SET closed_at = NOW()
-	WHERE ticket_id = $1 AND deleted_at is NULL;
-	`
+	SET first_responded_at = NOW()
+	WHERE ticket_id = $1
+	AND first_responded_at IS NULL
+	AND deleted_at IS NULL`
 
-func (d *database) CloseTicket(ctx context.Context, ticketID *model.TicketID) (bool, error) {
-	result, err := d.conn.ExecContext(ctx, closeTicketquery, ticketID)
+// MarkFirstResponse - records the first response to the ticket, later responses are ignored
+func (d *database) MarkFirstResponse(ctx context.Context, ticketID *model.TicketID) (bool, error) {
+	result, err := d.conn.ExecContext(ctx, markFirstResponseQuery, ticketID)
 	if err != nil {
-		return false, err
+		return false, errors.Wrap(err, "could not mark the first response")
 	}
 
 	rows, err := result.RowsAffected()
@@ -245,9 +536,153 @@ func (d *database) CloseTicket(ctx context.Context, ticketID *model.TicketID) (b
 	return true, nil
 }
 
+const closeTicketquery = `
+	UPDATE tickets
+	SET closed_at = NOW(),
+	updated_at = NOW()
+	WHERE ticket_id = $1
+	AND deleted_at is NULL
+	AND closed_at IS NULL
+	RETURNING closed_at`
+
+// CloseTicket - sets the closing remark and closed_at of a ticket in one transaction
+func (d *database) CloseTicket(ctx context.Context, closingRemark *model.ClosingRemark) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = closeTicket(ctx, tx, closingRemark); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// closeTicket - sets closed_at and the closing remark of the ticket in the transaction
+func closeTicket(ctx context.Context, tx *sqlx.Tx, closingRemark *model.ClosingRemark) error {
+
+	var closedAt time.Time
+	if err := tx.GetContext(ctx, &closedAt, closeTicketquery, closingRemark.TicketID); err != nil {
+		if err == sql.ErrNoRows {
+			return apiErr.ErrTicketClosed
+		}
+		return errors.Wrap(err, "could not close ticket")
+	}
+
+	stmt, err := tx.PrepareNamedContext(ctx, createClosingRemarkQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare closing remark")
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowxContext(ctx, closingRemark).Scan(&closingRemark.ID); err != nil {
+		if pqError, ok := err.(*pq.Error); ok {
+			switch pqError.Code.Name() {
+			case "unique_violation":
+				if pqError.Constraint == "ticket_closing_remarks_unique" {
+					return apiErr.ErrClosingRemarkExists
+				}
+			case "foreign_key_violation":
+				if pqError.Constraint == "ticket_closing_remarks_cause_id_fkey" {
+					return apiErr.ErrNotExist("Cause")
+				}
+			}
+			logrus.WithFields(logrus.Fields{
+				"PQ Code.Name":   pqError.Code.Name(),
+				"PQ Constraints": pqError.Constraint,
+				"PQ Column":      pqError.Column,
+			}).Info()
+		}
+		return errors.Wrap(err, "could not create closing remark")
+	}
+
+	activity := model.FieldActivity(closingRemark.TicketID, closingRemark.UserID, model.ActivityClosed, "closed_at", "", closedAt.UTC().Format(time.RFC3339))
+	return createTicketActivities(ctx, tx, activity)
+}
+
+const ticketClosedAtQuery = `
+	SELECT closed_at
+	FROM tickets
+	WHERE ticket_id = $1
+	AND deleted_at IS NULL
+	FOR UPDATE`
+
+const removeClosingRemarkQuery = `
+	UPDATE ticket_closing_remarks
+	SET deleted_at = NOW()
+	WHERE ticket_id = $1
+	AND deleted_at IS NULL`
+
+const reopenTicketQuery = `
+	UPDATE tickets
+	SET closed_at = NULL,
+	updated_at = NOW()
+	WHERE ticket_id = $1`
+
+// ReopenTicket - removes the active closing remark, clears closed_at and keeps the reason as a note,
+// all in one transaction
+func (d *database) ReopenTicket(ctx context.Context, reopening *model.Reopening) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var closedAt *time.Time
+	if err = tx.GetContext(ctx, &closedAt, ticketClosedAtQuery, reopening.TicketID); err != nil {
+		if err == sql.ErrNoRows {
+			err = apiErr.ErrNotFound
+			return
+		}
+		return errors.Wrap(err, "could not get ticket")
+	}
+	if closedAt == nil {
+		err = apiErr.ErrTicketNotClosed
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, removeClosingRemarkQuery, reopening.TicketID); err != nil {
+		return errors.Wrap(err, "could not remove closing remark")
+	}
+	if _, err = tx.ExecContext(ctx, reopenTicketQuery, reopening.TicketID); err != nil {
+		return errors.Wrap(err, "could not reopen ticket")
+	}
+
+	note := model.Note{
+		Note:     reopening.Reason,
+		TicketID: reopening.TicketID,
+		UserID:   reopening.UserID,
+	}
+	stmt, err := tx.PrepareNamedContext(ctx, createNoteQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare reason")
+	}
+	defer stmt.Close()
+	if err = stmt.QueryRowxContext(ctx, note).Scan(&reopening.NoteID); err != nil {
+		return errors.Wrap(err, "could not save reason")
+	}
+
+	activity := model.FieldActivity(reopening.TicketID, reopening.UserID, model.ActivityReopened, "closed_at", closedAt.UTC().Format(time.RFC3339), "")
+	activity.NoteID = reopening.NoteID
+	if err = createTicketActivities(ctx, tx, activity); err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
 
 const listAllTicketNotesQuery = `
-	SELECT note_id, note, ticket_id, created_by, created_at, updated_at, deleted_at
+	SELECT note_id, note, ticket_id, public, COALESCE(created_by::text,'') AS created_by, created_at, updated_at, deleted_at,
+	COALESCE((SELECT te.from_address FROM ticket_emails te WHERE te.note_id = ticket_notes.note_id AND te.direction = 'inbound' LIMIT 1), '') AS from_address
 	from ticket_notes
 	WHERE ticket_id = $1
 	AND deleted_at IS NULL
@@ -273,22 +708,33 @@ const deleteTicketNoteQuery = `
 	AND deleted_at is NULL;
 	`
 
-func (d *database) DeleteTicketNote(ctx context.Context,ticketID *model.TicketID, noteID *model.NoteID) (bool, error) {
-
-	result, err := d.conn.ExecContext(ctx, deleteTicketNoteQuery,ticketID, noteID)
+// DeleteTicketNote - deletes a note of the ticket, the activities are recorded in the same transaction when it was deleted
+func (d *database) DeleteTicketNote(ctx context.Context, ticketID *model.TicketID, noteID *model.NoteID, activities ...*model.Activity) (deleted bool, err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
 	if err != nil {
-		return false, err
+		return false, errors.Wrap(err, "could not start transaction")
 	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
 
-	rows, err := result.RowsAffected()
-	if err != nil || rows == 0 {
+	if deleted, err = deleteRow(ctx, tx, deleteTicketNoteQuery, ticketID, noteID); err != nil {
 		return false, err
 	}
-	return true, nil
+	if !deleted {
+		tx.Rollback()
+		return false, nil
+	}
+	if err = createTicketActivities(ctx, tx, activities...); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
 
 const closingRemarkForTicket = `
-	SELECT remark_id, ticket_id, cause_id, closed_by, remark, created_at, updated_at, deleted_at
+	SELECT remark_id, ticket_id, COALESCE(cause_id::text, '') AS cause_id, closed_by, remark, created_at, updated_at, deleted_at
 	FROM ticket_closing_remarks
 	WHERE ticket_id = $1 
 	AND deleted_at is NULL`
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+)
+
+// TicketActivityDB - holds the methods for the ticket audit trail
+type TicketActivityDB interface {
+	CreateTicketActivities(ctx context.Context, activities ...*model.Activity) error
+	ListTicketActivities(ctx context.Context, ticketID *model.TicketID) ([]*model.Activity, error)
+}
+
+const createTicketActivityQuery = `
+	INSERT INTO ticket_activities (
+		ticket_id, user_id, action, field, old_value, new_value, note_id
+	)
+	VALUES (
+		:ticket_id, NULLIF(:user_id, '')::uuid, :action, :field, :old_value, :new_value, NULLIF(:note_id, '')::uuid
+	)`
+
+func (d *database) CreateTicketActivities(ctx context.Context, activities ...*model.Activity) error {
+	return createTicketActivities(ctx, d.conn, activities...)
+}
+
+// createTicketActivities - records the activities with the given connection or transaction
+func createTicketActivities(ctx context.Context, conn sqlx.ExtContext, activities ...*model.Activity) error {
+	for _, activity := range activities {
+		if _, err := sqlx.NamedExecContext(ctx, conn, createTicketActivityQuery, activity); err != nil {
+			return errors.Wrap(err, "could not record ticket activity")
+		}
+	}
+	return nil
+}
+
+const listTicketActivitiesQuery = `
+	SELECT ac.activity_id, ac.ticket_id, COALESCE(ac.user_id::text, '') AS user_id, ac.action, ac.field,
+	ac.old_value, ac.new_value, COALESCE(ac.note_id::text, '') AS note_id, ac.created_at,
+	COALESCE(us.user_id::text, '') AS "actor.user_id", us.firstname AS "actor.firstname", us.lastname AS "actor.lastname",
+	COALESCE(nt.note_id::text, '') AS "note.note_id", nt.note AS "note.note", nt.created_at AS "note.created_at", nt.deleted_at AS "note.deleted_at",
+	COALESCE(te.from_address, '') AS "note.from_address"
+	FROM ticket_activities ac
+	LEFT JOIN users us ON us.user_id = ac.user_id
+	LEFT JOIN ticket_notes nt ON nt.note_id = ac.note_id
+	LEFT JOIN ticket_emails te ON te.note_id = ac.note_id AND te.direction = 'inbound'
+	WHERE ac.ticket_id = $1
+	ORDER BY ac.created_at ASC`
+
+// ListTicketActivities - returns the timeline of a ticket, the notes are joined to the activities that added them
+func (d *database) ListTicketActivities(ctx context.Context, ticketID *model.TicketID) ([]*model.Activity, error) {
+	activities := []*model.Activity{}
+	if err := d.conn.SelectContext(ctx, &activities, listTicketActivitiesQuery, ticketID); err != nil {
+		return nil, errors.Wrap(err, "could not get the ticket activities")
+	}
+	for _, activity := range activities {
+		activity.Actor = tidyUserName(activity.Actor)
+		if activity.Note != nil && activity.Note.ID == model.NilNoteID {
+			activity.Note = nil
+		}
+	}
+	return activities, nil
+}
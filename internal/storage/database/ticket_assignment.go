@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// TicketAssignmentDB - holds the methods for assigning tickets and their assignment history
+type TicketAssignmentDB interface {
+	AssignTicket(ctx context.Context, assignment *model.Assignment) error
+	ListTicketAssignments(ctx context.Context, ticketID *model.TicketID) ([]*model.Assignment, error)
+}
+
+const currentAssigneeQuery = `
+	SELECT COALESCE(assigned_to::text, '')
+	FROM tickets
+	WHERE ticket_id = $1
+	AND deleted_at IS NULL
+	FOR UPDATE`
+
+const assignTicketQuery = `
+	UPDATE tickets
+	SET assigned_to = NULLIF($2, '')::uuid,
+	updated_at = NOW()
+	WHERE ticket_id = $1
+	AND deleted_at IS NULL`
+
+const removeTicketAssigneeQuery = `
+	UPDATE tickets_users
+	SET deleted_at = NOW()
+	WHERE ticket_id = $1
+	AND role = 'assignee'
+	AND deleted_at IS NULL`
+
+const addTicketAssigneeQuery = `
+	INSERT INTO tickets_users (ticket_id, user_id, role, added_by)
+	VALUES ($1, $2, 'assignee', $3)
+	ON CONFLICT (ticket_id, user_id) WHERE deleted_at IS NULL
+	DO UPDATE SET role = 'assignee', updated_at = NOW()`
+
+const createAssignmentQuery = `
+	INSERT INTO ticket_assignments (
+		ticket_id, assigned_to, assigned_by
+	)
+	VALUES (
+		:ticket_id, NULLIF(:assigned_to, '')::uuid, :assigned_by
+		)
+		RETURNING assignment_id, created_at`
+
+// AssignTicket - sets the assignee of the ticket and records the change in the assignment history,
+// an empty AssignedID unassigns the ticket
+func (d *database) AssignTicket(ctx context.Context, assignment *model.Assignment) (err error) {
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var previousID model.UserID
+	if err = tx.GetContext(ctx, &previousID, currentAssigneeQuery, assignment.TicketID); err != nil {
+		if err == sql.ErrNoRows {
+			err = apiErr.ErrNotFound
+			return
+		}
+		return errors.Wrap(err, "could not get the current assignee")
+	}
+
+	result, err := tx.ExecContext(ctx, assignTicketQuery, assignment.TicketID, assignment.AssignedID)
+	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok {
+			if pqError.Code.Name() == "foreign_key_violation" && pqError.Constraint == "tickets_assigned_to_fkey" {
+				return apiErr.ErrNotExist("Assignee")
+			}
+			logrus.WithFields(logrus.Fields{
+				"PQ Code.Name":   pqError.Code.Name(),
+				"PQ Constraints": pqError.Constraint,
+				"PQ Column":      pqError.Column,
+			}).Info()
+		}
+		return apiErr.ErrUpdatingTicket
+	}
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		err = apiErr.ErrNotFound
+		return
+	}
+
+	// keep the assignee in the ticket users
+	if _, err = tx.ExecContext(ctx, removeTicketAssigneeQuery, assignment.TicketID); err != nil {
+		return errors.Wrap(err, "could not remove the previous assignee")
+	}
+	if assignment.AssignedID != model.NilUserID {
+		if _, err = tx.ExecContext(ctx, addTicketAssigneeQuery, assignment.TicketID, assignment.AssignedID, assignment.UserID); err != nil {
+			return errors.Wrap(err, "could not add the assignee")
+		}
+	}
+
+	stmt, err := tx.PrepareNamedContext(ctx, createAssignmentQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare assignment")
+	}
+	defer stmt.Close()
+	if err = stmt.QueryRowxContext(ctx, assignment).Scan(&assignment.ID, &assignment.CreatedAt); err != nil {
+		return errors.Wrap(err, "could not record assignment")
+	}
+
+	action := model.ActivityAssigned
+	if assignment.AssignedID == model.NilUserID {
+		action = model.ActivityUnassigned
+	}
+	activity := model.FieldActivity(assignment.TicketID, assignment.UserID, action, "assigned_to", string(previousID), string(assignment.AssignedID))
+	if err = createTicketActivities(ctx, tx, activity); err != nil {
+		return
+	}
+
+	return tx.Commit()
+}
+
+const listTicketAssignmentsQuery = `
+	SELECT ta.assignment_id, ta.ticket_id, COALESCE(ta.assigned_to::text, '') AS assigned_to, ta.assigned_by, ta.created_at,
+	COALESCE(asg.user_id::text, '') AS "assignee.user_id", asg.firstname AS "assignee.firstname", asg.lastname AS "assignee.lastname",
+	COALESCE(asb.user_id::text, '') AS "assigner.user_id", asb.firstname AS "assigner.firstname", asb.lastname AS "assigner.lastname"
+	FROM ticket_assignments ta
+	LEFT JOIN users asg ON asg.user_id = ta.assigned_to
+	LEFT JOIN users asb ON asb.user_id = ta.assigned_by
+	WHERE ta.ticket_id = $1
+	AND ta.deleted_at IS NULL
+	ORDER BY ta.created_at ASC`
+
+// ListTicketAssignments - returns the assignment history of a ticket, oldest first
+func (d *database) ListTicketAssignments(ctx context.Context, ticketID *model.TicketID) ([]*model.Assignment, error) {
+
+	assignments := []*model.Assignment{}
+	if err := d.conn.SelectContext(ctx, &assignments, listTicketAssignmentsQuery, ticketID); err != nil {
+		return nil, errors.Wrap(err, "could not get ticket assignments")
+	}
+	for _, assignment := range assignments {
+		assignment.AssignedTo = tidyUserName(assignment.AssignedTo)
+		assignment.AssignedBy = tidyUserName(assignment.AssignedBy)
+	}
+	return assignments, nil
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// TicketEmailDB - holds the methods for the mails of the tickets
+type TicketEmailDB interface {
+	CreateEmailTicket(ctx context.Context, ticket *model.Ticket, contact *model.Contact, email *model.TicketEmail, files []*model.NoteFile) error
+	CreateEmailNote(ctx context.Context, note *model.Note, email *model.TicketEmail, files []*model.NoteFile) error
+	FindEmailTicket(ctx context.Context, messageIDs []string, number int, from string) (model.TicketID, error)
+	ListTicketMessageIDs(ctx context.Context, ticketID *model.TicketID) ([]string, error)
+}
+
+const contactByEmailQuery = `
+	SELECT contact_id
+	FROM contacts
+	WHERE LOWER(email) = LOWER($1)
+	AND deleted_at IS NULL
+	LIMIT 1`
+
+// the no-op update returns the id of a contact created at the same time
+const createEmailContactQuery = `
+	INSERT INTO contacts (firstname, lastname, phone_no, email, created_by)
+	VALUES ($1, $2, '', $3, NULLIF($4, '')::uuid)
+	ON CONFLICT (email) WHERE deleted_at IS NULL
+	DO UPDATE SET updated_at = contacts.updated_at
+	RETURNING contact_id`
+
+const createTicketEmailQuery = `
+	INSERT INTO ticket_emails (
+		ticket_id, note_id, inbound_email_id, direction, message_id, in_reply_to, from_address, subject, html_body
+	)
+	VALUES (
+		:ticket_id, NULLIF(:note_id, '')::uuid, NULLIF(:inbound_email_id, '')::uuid, :direction, :message_id,
+		:in_reply_to, :from_address, :subject, :html_body
+	)
+	RETURNING ticket_email_id, created_at`
+
+// the latest mail of the thread wins when a reply refers to several tickets
+const ticketByMessageIDQuery = `
+	SELECT te.ticket_id
+	FROM ticket_emails te
+	JOIN tickets tk ON tk.ticket_id = te.ticket_id
+	WHERE te.message_id = ANY($1)
+	AND tk.deleted_at IS NULL
+	ORDER BY te.created_at DESC
+	LIMIT 1`
+
+// the number is in every subject, only the contact and the users of the ticket may reply through it
+const ticketByNumberQuery = `
+	SELECT tk.ticket_id
+	FROM tickets tk
+	LEFT JOIN contacts ct ON ct.contact_id = tk.contact_id
+	WHERE tk.number = $1
+	AND tk.deleted_at IS NULL
+	AND (
+		LOWER(ct.email) = LOWER($2)
+		OR EXISTS (
+			SELECT 1
+			FROM tickets_users tu
+			JOIN users us ON us.user_id = tu.user_id
+			WHERE tu.ticket_id = tk.ticket_id
+			AND tu.deleted_at IS NULL
+			AND LOWER(us.email) = LOWER($2)
+		)
+	)`
+
+const createEmailNoteQuery = `
+	INSERT INTO ticket_notes (note, ticket_id, created_by, public)
+	VALUES ($1, $2, NULLIF($3, '')::uuid, TRUE)
+	RETURNING note_id, created_at`
+
+const listTicketMessageIDsQuery = `
+	SELECT message_id
+	FROM ticket_emails
+	WHERE ticket_id = $1
+	AND message_id <> ''
+	ORDER BY created_at ASC`
+
+// ListTicketMessageIDs - returns the Message-IDs of the mails of a ticket, the oldest first
+func (d *database) ListTicketMessageIDs(ctx context.Context, ticketID *model.TicketID) ([]string, error) {
+	messageIDs := []string{}
+	if err := d.conn.SelectContext(ctx, &messageIDs, listTicketMessageIDsQuery, ticketID); err != nil {
+		return nil, errors.Wrap(err, "could not get the ticket message ids")
+	}
+	return messageIDs, nil
+}
+
+// CreateEmailTicket - creates the ticket of a received mail and its files in one transaction,
+// the contact is created from the sender when it does not exist
+func (d *database) CreateEmailTicket(ctx context.Context, ticket *model.Ticket, contact *model.Contact, email *model.TicketEmail, files []*model.NoteFile) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = saveEmailContact(ctx, tx, contact); err != nil {
+		return
+	}
+	ticket.ContactID = contact.ID
+
+	if err = insertTicket(ctx, tx, ticket); err != nil {
+		return
+	}
+
+	email.TicketID = ticket.ID
+	if err = insertTicketEmail(ctx, tx, email); err != nil {
+		return
+	}
+
+	for _, file := range files {
+		file.TicketID = ticket.ID
+	}
+	if err = insertNoteFiles(ctx, tx, files); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// FindEmailTicket - returns the ticket a reply belongs to, from the Message-IDs it refers to
+// or else from the ticket number when from is its contact or one of its users, number is 0 when the subject has none
+func (d *database) FindEmailTicket(ctx context.Context, messageIDs []string, number int, from string) (model.TicketID, error) {
+
+	var ticketID model.TicketID
+	if len(messageIDs) != 0 {
+		err := d.conn.GetContext(ctx, &ticketID, ticketByMessageIDQuery, pq.Array(messageIDs))
+		if err == nil {
+			return ticketID, nil
+		}
+		if err != sql.ErrNoRows {
+			return model.NilTicketID, errors.Wrap(err, "could not get the ticket of the mail")
+		}
+	}
+
+	if number != 0 {
+		err := d.conn.GetContext(ctx, &ticketID, ticketByNumberQuery, number, from)
+		if err == nil {
+			return ticketID, nil
+		}
+		if err != sql.ErrNoRows {
+			return model.NilTicketID, errors.Wrap(err, "could not get the ticket of the mail")
+		}
+	}
+	return model.NilTicketID, apiErr.ErrNotExist("Ticket")
+}
+
+// CreateEmailNote - adds a reply and its files to its ticket as a public note, the Message-ID is kept for the next replies
+func (d *database) CreateEmailNote(ctx context.Context, note *model.Note, email *model.TicketEmail, files []*model.NoteFile) (err error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = tx.QueryRowxContext(ctx, createEmailNoteQuery, note.Note, note.TicketID, note.UserID).Scan(&note.ID, &note.CreatedAt); err != nil {
+		err = errors.Wrap(err, "could not create note")
+		return
+	}
+
+	email.TicketID = note.TicketID
+	email.NoteID = note.ID
+	if err = insertTicketEmail(ctx, tx, email); err != nil {
+		return
+	}
+
+	for _, file := range files {
+		file.TicketID = note.TicketID
+		file.NoteID = note.ID
+	}
+	if err = insertNoteFiles(ctx, tx, files); err != nil {
+		return
+	}
+
+	activity := model.NewActivity(note.TicketID, note.UserID, model.ActivityNoteAdded)
+	activity.NoteID = note.ID
+	if err = createTicketActivities(ctx, tx, activity); err != nil {
+		return
+	}
+	return tx.Commit()
+}
+
+// saveEmailContact - finds the contact of the address, or creates it
+func saveEmailContact(ctx context.Context, tx *sqlx.Tx, contact *model.Contact) error {
+
+	err := tx.GetContext(ctx, &contact.ID, contactByEmailQuery, *contact.Email)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return errors.Wrap(err, "could not get contact")
+	}
+
+	email := strings.ToLower(*contact.Email)
+	if err := tx.GetContext(ctx, &contact.ID, createEmailContactQuery, contact.Firstname, contact.Lastname, email, contact.UserID); err != nil {
+		return errors.Wrap(err, "could not create contact")
+	}
+	return nil
+}
+
+// insertTicketEmail - records the mail within the transaction, a Message-ID that was already received is refused
+func insertTicketEmail(ctx context.Context, tx *sqlx.Tx, email *model.TicketEmail) error {
+
+	stmt, err := tx.PrepareNamedContext(ctx, createTicketEmailQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare ticket email")
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowxContext(ctx, email).Scan(&email.ID, &email.CreatedAt); err != nil {
+		if pqError, ok := err.(*pq.Error); ok {
+			if pqError.Code.Name() == UniqueViolation && pqError.Constraint == "ticket_emails_message_id" {
+				return apiErr.ErrTicketEmailExists
+			}
+			logrus.WithFields(logrus.Fields{
+				"PQ Code.Name":   pqError.Code.Name(),
+				"PQ Constraints": pqError.Constraint,
+				"PQ Column":      pqError.Column,
+			}).Info()
+		}
+		return errors.Wrap(err, "could not save ticket email")
+	}
+	return nil
+}
@@ -20,10 +20,10 @@ type SLADB interface {
 }
 
 const createSLAQuery = `INSERT INTO ticket_slas (
-	 name, weight,grace_period
+	 name, weight,grace_period, first_response_minutes, resolution_minutes, calendar_id
 	)
 	VALUES (
-		 :name, :weight, :grace_period
+		 :name, :weight, :grace_period, :first_response_minutes, :resolution_minutes, NULLIF(:calendar_id, '')::uuid
 		)
 		RETURNING agreement_id`
 
@@ -40,6 +40,10 @@ func (d *database) CreateSLA(ctx context.Context, sla *model.SLA) (err error) {
 				err = apiErr.ErrSLAExists
 				return
 			}
+			if pqError.Code.Name() == "foreign_key_violation" && pqError.Constraint == "ticket_slas_calendar_id_fkey" {
+				err = apiErr.ErrNotExist("Calendar")
+				return
+			}
 
 			logrus.WithFields(logrus.Fields{
 				"PQ Code.Name":   pqError.Code.Name(),
@@ -58,7 +62,8 @@ func (d *database) CreateSLA(ctx context.Context, sla *model.SLA) (err error) {
 }
 
 const getSLAByIDQuery = `
-	SELECT agreement_id, name, weight,grace_period, created_at, updated_at, deleted_at
+	SELECT agreement_id, name, weight,grace_period, first_response_minutes, resolution_minutes,
+	COALESCE(calendar_id::text, '') AS calendar_id, created_at, updated_at, deleted_at
 	FROM ticket_slas
 	WHERE agreement_id = $1 
 	AND deleted_at is NULL`
@@ -80,6 +85,9 @@ const updateSLAQuery = `
 		agreement_id = :agreement_id,
 		name = :name,
 		grace_period = :grace_period,
+		first_response_minutes = :first_response_minutes,
+		resolution_minutes = :resolution_minutes,
+		calendar_id = NULLIF(:calendar_id, '')::uuid,
 		weight = :weight,
 		updated_at = NOW()
 	WHERE agreement_id = :agreement_id 
@@ -89,6 +97,9 @@ func (d *database) UpdateSLA(ctx context.Context, sla *model.SLA) error {
 
 	result, err := d.conn.NamedExecContext(ctx, updateSLAQuery, sla)
 	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok && pqError.Constraint == "ticket_slas_calendar_id_fkey" {
+			return apiErr.ErrNotExist("Calendar")
+		}
 		return err
 	}
 
@@ -100,7 +111,8 @@ func (d *database) UpdateSLA(ctx context.Context, sla *model.SLA) error {
 }
 
 const listAllSLAQuery = `
-	SELECT agreement_id, name,grace_period, weight, created_at, updated_at, deleted_at
+	SELECT agreement_id, name,grace_period, first_response_minutes, resolution_minutes,
+	COALESCE(calendar_id::text, '') AS calendar_id, weight, created_at, updated_at, deleted_at
 	FROM ticket_slas
 	WHERE deleted_at is NULL
 	ORDER BY weight ASC`
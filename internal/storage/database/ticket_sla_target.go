@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SLATargetDB - holds the methods for the per priority targets of the SLAs
+type SLATargetDB interface {
+	SaveSLATarget(ctx context.Context, target *model.SLATarget) error
+	ListSLATargets(ctx context.Context, slaID *model.SLAID) ([]*model.SLATarget, error)
+	DeleteSLATarget(ctx context.Context, slaID *model.SLAID, priorityID *model.PriorityID) (bool, error)
+	GetSLATarget(ctx context.Context, slaID *model.SLAID, priorityID *model.PriorityID) (*model.SLATarget, error)
+}
+
+const saveSLATargetQuery = `
+	INSERT INTO ticket_sla_targets (
+		agreement_id, priority_id, first_response_minutes, resolution_minutes
+	)
+	VALUES (
+		:agreement_id, :priority_id, :first_response_minutes, :resolution_minutes
+	)
+	ON CONFLICT (agreement_id, priority_id) WHERE deleted_at IS NULL
+	DO UPDATE SET first_response_minutes = EXCLUDED.first_response_minutes,
+	resolution_minutes = EXCLUDED.resolution_minutes,
+	updated_at = NOW()
+	RETURNING target_id`
+
+// SaveSLATarget - creates or replaces the target of an SLA for a priority
+func (d *database) SaveSLATarget(ctx context.Context, target *model.SLATarget) error {
+	stmt, err := d.conn.PrepareNamedContext(ctx, saveSLATargetQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare sla target")
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowxContext(ctx, target).Scan(&target.ID); err != nil {
+		if pqError, ok := err.(*pq.Error); ok {
+			if pqError.Code.Name() == "foreign_key_violation" {
+				switch pqError.Constraint {
+				case "ticket_sla_targets_agreement_id_fkey":
+					return apiErr.ErrNotExist("SLA")
+				case "ticket_sla_targets_priority_id_fkey":
+					return apiErr.ErrNotExist("Priority")
+				}
+			}
+			logrus.WithFields(logrus.Fields{
+				"PQ Code.Name":   pqError.Code.Name(),
+				"PQ Constraints": pqError.Constraint,
+				"PQ Column":      pqError.Column,
+			}).Info()
+		}
+		return errors.Wrap(err, "could not save sla target")
+	}
+	return nil
+}
+
+const listSLATargetsQuery = `
+	SELECT tg.target_id, tg.agreement_id, tg.priority_id, tg.first_response_minutes, tg.resolution_minutes,
+	tg.created_at, tg.updated_at,
+	pr.priority_id AS "priority.priority_id", pr.name AS "priority.name"
+	FROM ticket_sla_targets tg
+	INNER JOIN ticket_priorities pr ON pr.priority_id = tg.priority_id AND pr.deleted_at IS NULL
+	WHERE tg.agreement_id = $1
+	AND tg.deleted_at IS NULL
+	ORDER BY pr.weight ASC`
+
+func (d *database) ListSLATargets(ctx context.Context, slaID *model.SLAID) ([]*model.SLATarget, error) {
+	targets := []*model.SLATarget{}
+	if err := d.conn.SelectContext(ctx, &targets, listSLATargetsQuery, slaID); err != nil {
+		return nil, errors.Wrap(err, "could not get sla targets")
+	}
+	return targets, nil
+}
+
+const deleteSLATargetQuery = `
+	UPDATE ticket_sla_targets
+	SET deleted_at = NOW()
+	WHERE agreement_id = $1
+	AND priority_id = $2
+	AND deleted_at IS NULL`
+
+func (d *database) DeleteSLATarget(ctx context.Context, slaID *model.SLAID, priorityID *model.PriorityID) (bool, error) {
+	result, err := d.conn.ExecContext(ctx, deleteSLATargetQuery, slaID, priorityID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+	return true, nil
+}
+
+const getSLATargetQuery = `
+	SELECT COALESCE(tg.target_id::text, '') AS target_id, sl.agreement_id, COALESCE(tg.priority_id::text, '') AS priority_id,
+	COALESCE(tg.first_response_minutes, sl.first_response_minutes) AS first_response_minutes,
+	COALESCE(tg.resolution_minutes, sl.resolution_minutes, sl.grace_period * 60) AS resolution_minutes,
+	COALESCE(sl.calendar_id::text, '') AS calendar_id
+	FROM ticket_slas sl
+	LEFT JOIN ticket_sla_targets tg ON tg.agreement_id = sl.agreement_id AND tg.priority_id = $2 AND tg.deleted_at IS NULL
+	WHERE sl.agreement_id = $1
+	AND sl.deleted_at IS NULL`
+
+// GetSLATarget - returns the targets that apply to a ticket, the SLA defaults are used when the priority has no target
+func (d *database) GetSLATarget(ctx context.Context, slaID *model.SLAID, priorityID *model.PriorityID) (*model.SLATarget, error) {
+	return getSLATarget(ctx, d.conn, slaID, priorityID)
+}
+
+func getSLATarget(ctx context.Context, q sqlx.QueryerContext, slaID *model.SLAID, priorityID *model.PriorityID) (*model.SLATarget, error) {
+	target := model.SLATarget{}
+	if err := sqlx.GetContext(ctx, q, &target, getSLATargetQuery, slaID, priorityID); err != nil {
+		return nil, apiErr.ErrNotExist("SLA")
+	}
+	return &target, nil
+}
@@ -14,6 +14,7 @@ import (
 type TicketStatusDB interface {
 	CreateStatus(ctx context.Context, status *model.Status) error
 	GetStatusByID(ctx context.Context, statusID *model.StatusID) (*model.Status, error)
+	GetInitialStatus(ctx context.Context) (*model.Status, error)
 	UpdateStatus(ctx context.Context, status *model.Status) error
 	ListAllStatus(ctx context.Context) ([]*model.Status, error)
 	DeleteStatus(ctx context.Context, StatusID *model.StatusID) (bool, error)
@@ -21,10 +22,10 @@ type TicketStatusDB interface {
 
 
 const createStatusQuery = `INSERT INTO ticket_statuses (
-	 name, weight
+	 name, weight, type, pauses_sla
 	)
 	VALUES (
-		 :name, :weight
+		 :name, :weight, :type, :pauses_sla
 		)
 		RETURNING status_id`
 
@@ -41,6 +42,10 @@ func (d *database) CreateStatus(ctx context.Context, status *model.Status) (err
 				err = apiErr.ErrStatusExists
 				return
 			}
+			if pqError.Code.Name() == "unique_violation" && pqError.Constraint == "ticket_statuses_initial" {
+				err = apiErr.ErrInitialStatusExists
+				return
+			}
 
 			logrus.WithFields(logrus.Fields{
 				"PQ Code.Name":   pqError.Code.Name(),
@@ -59,7 +64,7 @@ func (d *database) CreateStatus(ctx context.Context, status *model.Status) (err
 }
 
 const getStatusByIDQuery = `
-	SELECT status_id, name, weight, created_at, updated_at, deleted_at
+	SELECT status_id, name, weight, type, pauses_sla, created_at, updated_at, deleted_at
 	FROM ticket_statuses
 	WHERE status_id = $1 
 	AND deleted_at is NULL`
@@ -75,12 +80,30 @@ func (d *database) GetStatusByID(ctx context.Context, statusID *model.StatusID)
 
 }
 
+const getInitialStatusQuery = `
+	SELECT status_id, name, weight, type, pauses_sla, created_at, updated_at, deleted_at
+	FROM ticket_statuses
+	WHERE type = 'initial'
+	AND deleted_at is NULL`
+
+// GetInitialStatus - retrieves the status new tickets start in
+func (d *database) GetInitialStatus(ctx context.Context) (*model.Status, error) {
+
+	status := model.Status{}
+	if err := d.conn.GetContext(ctx, &status, getInitialStatusQuery); err != nil {
+		return nil, apiErr.ErrNotExist("Initial status")
+	}
+	return &status, nil
+}
+
 const updateStatusQuery = `
 	UPDATE ticket_statuses
 	SET 
 		status_id = :status_id,
 		name = :name,
 		weight = :weight,
+		type = :type,
+		pauses_sla = :pauses_sla,
 		updated_at = NOW()
 	WHERE status_id = :status_id 
 	AND deleted_at is NULL`
@@ -90,6 +113,9 @@ func (d *database) UpdateStatus(ctx context.Context, status *model.Status) error
 	//println(*status.PasswordHash)
 	result, err := d.conn.NamedExecContext(ctx, updateStatusQuery, status)
 	if err != nil {
+		if pqError, ok := err.(*pq.Error); ok && pqError.Constraint == "ticket_statuses_initial" {
+			return apiErr.ErrInitialStatusExists
+		}
 		return err
 	}
 
@@ -101,7 +127,7 @@ func (d *database) UpdateStatus(ctx context.Context, status *model.Status) error
 }
 
 const listAllStatusQuery = `
-	SELECT status_id, name, weight, created_at, updated_at, deleted_at
+	SELECT status_id, name, weight, type, pauses_sla, created_at, updated_at, deleted_at
 	FROM ticket_statuses
 	WHERE deleted_at is NULL
 	ORDER BY weight ASC`
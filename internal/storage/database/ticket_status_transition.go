@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// TicketStatusTransitionDB - holds the methods for the ticket status workflow
+type TicketStatusTransitionDB interface {
+	CreateStatusTransition(ctx context.Context, transition *model.Transition) error
+	GetStatusTransitionByID(ctx context.Context, transitionID *model.TransitionID) (*model.Transition, error)
+	UpdateStatusTransition(ctx context.Context, transition *model.Transition) error
+	ListStatusTransitions(ctx context.Context) ([]*model.Transition, error)
+	DeleteStatusTransition(ctx context.Context, transitionID *model.TransitionID) (bool, error)
+
+	GetStatusTransition(ctx context.Context, fromStatusID, toStatusID *model.StatusID) (*model.Transition, error)
+	ListTransitionsFrom(ctx context.Context, fromStatusID *model.StatusID) ([]*model.Transition, error)
+	HasStatusWorkflow(ctx context.Context) (bool, error)
+}
+
+const createStatusTransitionQuery = `
+	INSERT INTO ticket_status_transitions (
+		from_status_id, to_status_id, requires_note, role_id, closes_ticket, created_by
+	)
+	VALUES (
+		:from_status_id, :to_status_id, :requires_note, NULLIF(:role_id, '')::uuid, :closes_ticket, :created_by
+	)
+	RETURNING transition_id`
+
+func (d *database) CreateStatusTransition(ctx context.Context, transition *model.Transition) error {
+	stmt, err := d.conn.PrepareNamedContext(ctx, createStatusTransitionQuery)
+	if err != nil {
+		return errors.Wrap(err, "could not prepare transition")
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowxContext(ctx, transition).Scan(&transition.ID); err != nil {
+		return transitionError(err)
+	}
+	return nil
+}
+
+// transitionError - maps the constraint errors of the transitions table to API errors
+func transitionError(err error) error {
+	if pqError, ok := err.(*pq.Error); ok {
+		switch pqError.Code.Name() {
+		case "unique_violation":
+			if pqError.Constraint == "ticket_status_transitions_unique" {
+				return apiErr.ErrTransitionExists
+			}
+		case "foreign_key_violation":
+			switch pqError.Constraint {
+			case "ticket_status_transitions_from_status_id_fkey", "ticket_status_transitions_to_status_id_fkey":
+				return apiErr.ErrNotExist("Status")
+			case "ticket_status_transitions_role_id_fkey":
+				return apiErr.ErrNotExist("Role")
+			}
+		}
+		logrus.WithFields(logrus.Fields{
+			"PQ Code.Name":   pqError.Code.Name(),
+			"PQ Constraints": pqError.Constraint,
+			"PQ Column":      pqError.Column,
+		}).Info()
+	}
+	return errors.Wrap(err, "could not save transition")
+}
+
+// statusTransitionSelect - selects the transitions joined with their statuses
+const statusTransitionSelect = `
+	SELECT tr.transition_id, tr.from_status_id, tr.to_status_id, tr.requires_note,
+	COALESCE(tr.role_id::text, '') AS role_id, tr.closes_ticket, COALESCE(tr.created_by::text, '') AS created_by,
+	tr.created_at, tr.updated_at, tr.deleted_at,
+	fs.status_id AS "from_status.status_id", fs.name AS "from_status.name", fs.type AS "from_status.type",
+	ts.status_id AS "to_status.status_id", ts.name AS "to_status.name", ts.type AS "to_status.type"
+	FROM ticket_status_transitions tr
+	INNER JOIN ticket_statuses fs ON fs.status_id = tr.from_status_id AND fs.deleted_at IS NULL
+	INNER JOIN ticket_statuses ts ON ts.status_id = tr.to_status_id AND ts.deleted_at IS NULL
+`
+
+const getStatusTransitionByIDQuery = statusTransitionSelect + `
+	WHERE tr.transition_id = $1
+	AND tr.deleted_at IS NULL`
+
+func (d *database) GetStatusTransitionByID(ctx context.Context, transitionID *model.TransitionID) (*model.Transition, error) {
+	transition := model.Transition{}
+	if err := d.conn.GetContext(ctx, &transition, getStatusTransitionByIDQuery, transitionID); err != nil {
+		return nil, apiErr.ErrNotFound
+	}
+	return &transition, nil
+}
+
+const updateStatusTransitionQuery = `
+	UPDATE ticket_status_transitions
+	SET requires_note = :requires_note,
+	role_id = NULLIF(:role_id, '')::uuid,
+	closes_ticket = :closes_ticket,
+	updated_at = NOW()
+	WHERE transition_id = :transition_id
+	AND deleted_at IS NULL`
+
+func (d *database) UpdateStatusTransition(ctx context.Context, transition *model.Transition) error {
+	result, err := d.conn.NamedExecContext(ctx, updateStatusTransitionQuery, transition)
+	if err != nil {
+		return transitionError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return errors.New("Transition Not found")
+	}
+	return nil
+}
+
+const listStatusTransitionsQuery = statusTransitionSelect + `
+	WHERE tr.deleted_at IS NULL
+	ORDER BY fs.weight ASC, ts.weight ASC`
+
+func (d *database) ListStatusTransitions(ctx context.Context) ([]*model.Transition, error) {
+	transitions := []*model.Transition{}
+	if err := d.conn.SelectContext(ctx, &transitions, listStatusTransitionsQuery); err != nil {
+		return nil, errors.Wrap(err, "could not get transitions")
+	}
+	return transitions, nil
+}
+
+const deleteStatusTransitionQuery = `
+	UPDATE ticket_status_transitions
+	SET deleted_at = NOW()
+	WHERE transition_id = $1
+	AND deleted_at IS NULL`
+
+func (d *database) DeleteStatusTransition(ctx context.Context, transitionID *model.TransitionID) (bool, error) {
+	result, err := d.conn.ExecContext(ctx, deleteStatusTransitionQuery, transitionID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+	return true, nil
+}
+
+const getStatusTransitionQuery = statusTransitionSelect + `
+	WHERE tr.from_status_id = $1
+	AND tr.to_status_id = $2
+	AND tr.deleted_at IS NULL`
+
+// GetStatusTransition - retrieves the transition between two statuses, ErrIllegalTransition is returned when there is none
+func (d *database) GetStatusTransition(ctx context.Context, fromStatusID, toStatusID *model.StatusID) (*model.Transition, error) {
+	transition := model.Transition{}
+	if err := d.conn.GetContext(ctx, &transition, getStatusTransitionQuery, fromStatusID, toStatusID); err != nil {
+		return nil, apiErr.ErrIllegalTransition
+	}
+	return &transition, nil
+}
+
+const listTransitionsFromQuery = statusTransitionSelect + `
+	WHERE tr.from_status_id = $1
+	AND tr.deleted_at IS NULL
+	ORDER BY ts.weight ASC`
+
+// ListTransitionsFrom - returns the moves available from a status
+func (d *database) ListTransitionsFrom(ctx context.Context, fromStatusID *model.StatusID) ([]*model.Transition, error) {
+	transitions := []*model.Transition{}
+	if err := d.conn.SelectContext(ctx, &transitions, listTransitionsFromQuery, fromStatusID); err != nil {
+		return nil, errors.Wrap(err, "could not get transitions")
+	}
+	return transitions, nil
+}
+
+const hasStatusWorkflowQuery = `
+	SELECT EXISTS (
+		SELECT 1
+		FROM ticket_status_transitions
+		WHERE deleted_at IS NULL
+	)`
+
+// HasStatusWorkflow - checks if any transition has been defined, tickets can move freely between statuses until then
+func (d *database) HasStatusWorkflow(ctx context.Context) (bool, error) {
+	var exists bool
+	if err := d.conn.GetContext(ctx, &exists, hasStatusWorkflowQuery); err != nil {
+		return false, errors.Wrap(err, "could not check the status workflow")
+	}
+	return exists, nil
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	apiErr "github.com/lilkid3/ASA-Ticket/Backend/internal/api/errors"
+	"github.com/lilkid3/ASA-Ticket/Backend/internal/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// TicketUserDB - holds the methods for the people involved in tickets
+type TicketUserDB interface {
+	AddTicketUser(ctx context.Context, ticketUser *model.TicketUser) error
+	RemoveTicketUser(ctx context.Context, ticketID *model.TicketID, userID *model.UserID) (bool, error)
+	ListTicketUsers(ctx context.Context, ticketID *model.TicketID) ([]*model.TicketUser, error)
+	ListUserTickets(ctx context.Context, userID *model.UserID) ([]*model.TicketUser, error)
+}
+
+const addTicketUserQuery = `
+	INSERT INTO tickets_users (
+		ticket_id, user_id, role, added_by
+	)
+	VALUES (
+		:ticket_id, :user_id, :role, :added_by
+	)`
+
+func (d *database) AddTicketUser(ctx context.Context, ticketUser *model.TicketUser) error {
+	if _, err := d.conn.NamedExecContext(ctx, addTicketUserQuery, ticketUser); err != nil {
+		if pqError, ok := err.(*pq.Error); ok {
+			switch pqError.Code.Name() {
+			case "unique_violation":
+				if pqError.Constraint == "user_tickets_user" {
+					return apiErr.ErrTicketUserExists
+				}
+			case "foreign_key_violation":
+				switch pqError.Constraint {
+				case "tickets_users_ticket_id_fkey":
+					return apiErr.ErrNotExist("Ticket")
+				case "tickets_users_user_id_fkey":
+					return apiErr.ErrNotExist("User")
+				}
+			}
+			logrus.WithFields(logrus.Fields{
+				"PQ Code.Name":   pqError.Code.Name(),
+				"PQ Constraints": pqError.Constraint,
+				"PQ Column":      pqError.Column,
+			}).Info()
+		}
+		return errors.Wrap(err, "could not add ticket user")
+	}
+	return nil
+}
+
+const removeTicketUserQuery = `
+	UPDATE tickets_users
+	SET deleted_at = NOW()
+	WHERE ticket_id = $1
+	AND user_id = $2
+	AND role <> 'assignee'
+	AND deleted_at IS NULL`
+
+// RemoveTicketUser - removes a collaborator or watcher from a ticket, the assignee is removed by unassigning the ticket
+func (d *database) RemoveTicketUser(ctx context.Context, ticketID *model.TicketID, userID *model.UserID) (bool, error) {
+	result, err := d.conn.ExecContext(ctx, removeTicketUserQuery, ticketID, userID)
+	if err != nil {
+		return false, errors.Wrap(err, "could not remove ticket user")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+	return true, nil
+}
+
+const listTicketUsersQuery = `
+	SELECT tu.ticket_id, tu.user_id, tu.role, COALESCE(tu.added_by::text, '') AS added_by, tu.created_at,
+	us.user_id AS "member.user_id", us.firstname AS "member.firstname", us.lastname AS "member.lastname", us.email AS "member.email"
+	FROM tickets_users tu
+	INNER JOIN users us ON us.user_id = tu.user_id
+	WHERE tu.ticket_id = $1
+	AND tu.deleted_at IS NULL
+	ORDER BY tu.role ASC, tu.created_at ASC`
+
+func (d *database) ListTicketUsers(ctx context.Context, ticketID *model.TicketID) ([]*model.TicketUser, error) {
+	ticketUsers := []*model.TicketUser{}
+	if err := d.conn.SelectContext(ctx, &ticketUsers, listTicketUsersQuery, ticketID); err != nil {
+		return nil, errors.Wrap(err, "could not get the users for a ticket")
+	}
+	for _, ticketUser := range ticketUsers {
+		ticketUser.User = tidyUserName(ticketUser.User)
+	}
+	return ticketUsers, nil
+}
+
+const listUserTicketsQuery = `
+	SELECT tu.ticket_id, tu.user_id, tu.role, COALESCE(tu.added_by::text, '') AS added_by, tu.created_at,
+	tk.ticket_id AS "ticket.ticket_id", tk.subject AS "ticket.subject", tk.number AS "ticket.number",
+	tk.deadline AS "ticket.deadline", tk.closed_at AS "ticket.closed_at",
+	COALESCE(st.status_id::text, '') AS "ticket.status.status_id", st.name AS "ticket.status.name"
+	FROM tickets_users tu
+	INNER JOIN tickets tk ON tk.ticket_id = tu.ticket_id
+	LEFT JOIN ticket_statuses st ON st.status_id = tk.status_id
+	WHERE tu.user_id = $1
+	AND tu.deleted_at IS NULL
+	AND tk.deleted_at IS NULL
+	ORDER BY tk.closed_at DESC NULLS FIRST, tk.created_at DESC`
+
+// ListUserTickets - returns the tickets a user is involved in and the part they play in each
+func (d *database) ListUserTickets(ctx context.Context, userID *model.UserID) ([]*model.TicketUser, error) {
+	ticketUsers := []*model.TicketUser{}
+	if err := d.conn.SelectContext(ctx, &ticketUsers, listUserTicketsQuery, userID); err != nil {
+		return nil, errors.Wrap(err, "could not get the tickets for a user")
+	}
+	for _, ticketUser := range ticketUsers {
+		if ticketUser.Ticket.Status != nil && ticketUser.Ticket.Status.ID == model.NilStatusID {
+			ticketUser.Ticket.Status = nil
+		}
+	}
+	return ticketUsers, nil
+}
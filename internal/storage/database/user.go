@@ -81,7 +81,7 @@ func (d *database) CreateUser(ctx context.Context, user *model.User) (err error)
 }
 
 const getUserByIDQuery = `
-	SELECT user_id, firstname, lastname, email,role_id, password_hash, user_type, is_active, is_system, created_at, updated_at, deleted_at
+	SELECT user_id, firstname, lastname, email,role_id, password_hash, user_type, is_active, is_system, email_verified_at, created_at, updated_at, deleted_at
 	FROM users
 	WHERE user_id = $1`
 
@@ -103,7 +103,7 @@ func (d *database) GetUserByID(ctx context.Context, userID *model.UserID) (*mode
 }
 
 const getUserByEmailQuery = `
-	SELECT user_id, firstname, lastname, email,role_id, password_hash, user_type, is_active, is_system, created_at, updated_at, deleted_at
+	SELECT user_id, firstname, lastname, email,role_id, password_hash, user_type, is_active, is_system, email_verified_at, created_at, updated_at, deleted_at
 	FROM users
 	WHERE email = $1 AND deleted_at is NULL`
 
@@ -146,7 +146,7 @@ func (d *database) UpdateUser(ctx context.Context, user *model.User) error {
 }
 
 const listAllUsersQuery = `
-	SELECT  user_id, firstname, lastname, email,role_id, password_hash, user_type, is_active, is_system, created_at, updated_at, deleted_at
+	SELECT  user_id, firstname, lastname, email,role_id, password_hash, user_type, is_active, is_system, email_verified_at, created_at, updated_at, deleted_at
 	FROM users
 	WHERE deleted_at is NULL;
 `